@@ -0,0 +1,43 @@
+package autenticacion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Token agrega "authorization: Bearer <token>" a cada llamada del cliente
+type Token struct {
+	valor   string
+	archivo string
+	conTLS  bool
+}
+
+// TokenDeEntorno usa AUTH_TOKEN o, si no está, el contenido de
+// AUTH_TOKEN_ARCHIVO (que se vuelve a leer en cada llamada, así un JWT
+// renovado se usa sin reiniciar). Devuelve nil si no hay ninguno. Sin TLS el
+// token viaja en claro, así que conTLS solo debe ser false en desarrollo.
+func TokenDeEntorno(conTLS bool) *Token {
+	t := &Token{valor: os.Getenv("AUTH_TOKEN"), archivo: os.Getenv("AUTH_TOKEN_ARCHIVO"), conTLS: conTLS}
+	if t.valor == "" && t.archivo == "" {
+		return nil
+	}
+	return t
+}
+
+func (t *Token) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	valor := t.valor
+	if valor == "" {
+		datos, err := os.ReadFile(t.archivo)
+		if err != nil {
+			return nil, fmt.Errorf("leyendo el token: %w", err)
+		}
+		valor = strings.TrimSpace(string(datos))
+	}
+	return map[string]string{"authorization": "Bearer " + valor}, nil
+}
+
+func (t *Token) RequireTransportSecurity() bool {
+	return t.conTLS
+}
@@ -0,0 +1,145 @@
+package autenticacion
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Los health checks de Kubernetes no llevan token
+const prefijoHealth = "/grpc.health.v1.Health/"
+
+type claveIdentidad struct{}
+
+// Identidad devuelve el cliente autenticado de la llamada, o nil si el
+// servidor no tiene política
+func Identidad(ctx context.Context) *Cliente {
+	c, _ := ctx.Value(claveIdentidad{}).(*Cliente)
+	return c
+}
+
+// PaisesPermitidos devuelve PermissionDenied si el cliente de la llamada no
+// puede usar alguno de los países. Sin países significa "todos", lo que solo
+// pueden pedir los clientes sin restricción de países.
+func PaisesPermitidos(ctx context.Context, paises ...string) error {
+	c := Identidad(ctx)
+	if c == nil || c.permitePaises(paises) {
+		return nil
+	}
+	if len(paises) == 0 {
+		return status.Errorf(codes.PermissionDenied, "%s debe filtrar por sus países: %s", c.Nombre, strings.Join(c.Paises, ", "))
+	}
+	return status.Errorf(codes.PermissionDenied, "%s no tiene permiso para %s", c.Nombre, strings.Join(paises, ", "))
+}
+
+// Autenticador verifica el token de cada llamada contra la política
+type Autenticador struct {
+	politica *Politica
+}
+
+// NuevoAutenticador crea el autenticador de la política
+func NuevoAutenticador(p *Politica) *Autenticador {
+	return &Autenticador{politica: p}
+}
+
+// Unario es el interceptor de los RPC unarios
+func (a *Autenticador) Unario() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.autorizar(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream es el interceptor de los RPC con stream
+func (a *Autenticador) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.autorizar(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &streamConIdentidad{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// streamConIdentidad reemplaza el contexto del stream por uno con la identidad
+type streamConIdentidad struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *streamConIdentidad) Context() context.Context {
+	return s.ctx
+}
+
+// autorizar identifica al cliente y revisa que pueda llamar al método
+func (a *Autenticador) autorizar(ctx context.Context, metodo string) (context.Context, error) {
+	if strings.HasPrefix(metodo, prefijoHealth) {
+		return ctx, nil
+	}
+
+	cliente, err := a.identificar(ctx)
+	if err != nil {
+		log.Printf("Llamada rechazada a %s: %v", metodo, err)
+		if _, esStatus := status.FromError(err); esStatus {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !cliente.permiteMetodo(metodo) {
+		log.Printf("Llamada rechazada a %s: %s no tiene permiso", metodo, cliente.Nombre)
+		return nil, status.Errorf(codes.PermissionDenied, "%s no tiene permiso para %s", cliente.Nombre, metodo)
+	}
+	return context.WithValue(ctx, claveIdentidad{}, cliente), nil
+}
+
+// identificar busca el cliente del token: un JWT tiene dos puntos, lo demás
+// se trata como API key
+func (a *Autenticador) identificar(ctx context.Context) (*Cliente, error) {
+	token, err := tokenBearer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Count(token, ".") == 2 {
+		if a.politica.llaves == nil {
+			return nil, errJWTDeshabilitado
+		}
+		sub, err := a.politica.verificarJWT(token, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		cliente, ok := a.politica.porNombre[sub]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "%s no está en la política", sub)
+		}
+		return cliente, nil
+	}
+
+	cliente, ok := a.politica.porClave[huella(token)]
+	if !ok {
+		return nil, errClaveInvalida
+	}
+	return cliente, nil
+}
+
+func tokenBearer(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	valores := md.Get("authorization")
+	if len(valores) != 1 {
+		return "", errSinToken
+	}
+	tipo, token, ok := strings.Cut(valores[0], " ")
+	if !ok || !strings.EqualFold(tipo, "bearer") || token == "" {
+		return "", errSinToken
+	}
+	return token, nil
+}
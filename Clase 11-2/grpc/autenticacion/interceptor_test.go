@@ -0,0 +1,189 @@
+package autenticacion
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "grpc/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// servicioPrueba responde con el nombre del cliente autenticado y revisa los
+// países igual que el servidor
+type servicioPrueba struct {
+	pb.UnimplementedTweetServiceServer
+}
+
+func (servicioPrueba) SendTweet(ctx context.Context, req *pb.TweetRequest) (*pb.TweetResponse, error) {
+	if err := PaisesPermitidos(ctx, req.Country); err != nil {
+		return nil, err
+	}
+	return &pb.TweetResponse{Status: Identidad(ctx).Nombre}, nil
+}
+
+func (servicioPrueba) ListTweets(ctx context.Context, req *pb.ListTweetsRequest) (*pb.ListTweetsResponse, error) {
+	var paises []string
+	if req.Country != "" {
+		paises = append(paises, req.Country)
+	}
+	if err := PaisesPermitidos(ctx, paises...); err != nil {
+		return nil, err
+	}
+	return &pb.ListTweetsResponse{}, nil
+}
+
+func (servicioPrueba) SubscribeTweets(req *pb.SubscribeRequest, stream grpc.ServerStreamingServer[pb.Tweet]) error {
+	if err := PaisesPermitidos(stream.Context(), req.Countries...); err != nil {
+		return err
+	}
+	return stream.Send(&pb.Tweet{Description: Identidad(stream.Context()).Nombre})
+}
+
+// claves de la política de prueba
+const (
+	claveLocust  = "clave-de-locust-123"
+	claveLectora = "clave-de-lectora-456"
+)
+
+// levantar arranca el servicio con la política de prueba y devuelve un cliente
+func levantar(t *testing.T, ll *llavesPrueba) (pb.TweetServiceClient, healthpb.HealthClient) {
+	t.Helper()
+	p := escribirPolitica(t, ll, Politica{Audiencia: "tweets", Clientes: []Cliente{
+		{Nombre: "locust", ApiKey: claveLocust, Metodos: []string{"SendTweet", "SubscribeTweets"}, Paises: []string{"Guatemala"}},
+		{Nombre: "lectora", ApiKey: claveLectora, Metodos: []string{"ListTweets", "SubscribeTweets"}},
+		{Nombre: "jwt", Metodos: []string{"*"}},
+	}})
+	a := NuevoAutenticador(p)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(a.Unario()), grpc.ChainStreamInterceptor(a.Stream()))
+	pb.RegisterTweetServiceServer(srv, servicioPrueba{})
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTweetServiceClient(conn), healthpb.NewHealthClient(conn)
+}
+
+func conToken(t *testing.T, token string) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	t.Cleanup(cancel)
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestInterceptorUnario(t *testing.T) {
+	ll := nuevasLlaves(t)
+	cliente, _ := levantar(t, ll)
+	exp := time.Now().Add(time.Hour).Unix()
+	jwtValido := firmar(t, "rs", ll.rsa, Reclamos{Sub: "jwt", Aud: audiencia("tweets"), Exp: exp})
+	jwtDesconocido := firmar(t, "rs", ll.rsa, Reclamos{Sub: "nadie", Aud: audiencia("tweets"), Exp: exp})
+	jwtExpirado := firmar(t, "hs", ll.secreto, Reclamos{Sub: "jwt", Aud: audiencia("tweets"), Exp: time.Now().Add(-time.Hour).Unix()})
+	guatemala := &pb.TweetRequest{Country: "guatemala"}
+
+	casos := []struct {
+		nombre string
+		llamar func() (string, error)
+		codigo codes.Code
+		desea  string
+	}{
+		{"api key", func() (string, error) {
+			r, err := cliente.SendTweet(conToken(t, claveLocust), guatemala)
+			return r.GetStatus(), err
+		}, codes.OK, "locust"},
+		{"jwt", func() (string, error) {
+			r, err := cliente.SendTweet(conToken(t, jwtValido), &pb.TweetRequest{Country: "Honduras"})
+			return r.GetStatus(), err
+		}, codes.OK, "jwt"},
+		{"sin token", func() (string, error) {
+			_, err := cliente.SendTweet(conToken(t, ""), guatemala)
+			return "", err
+		}, codes.Unauthenticated, ""},
+		{"api key inválida", func() (string, error) {
+			_, err := cliente.SendTweet(conToken(t, "clave-que-no-existe"), guatemala)
+			return "", err
+		}, codes.Unauthenticated, ""},
+		{"jwt expirado", func() (string, error) {
+			_, err := cliente.SendTweet(conToken(t, jwtExpirado), guatemala)
+			return "", err
+		}, codes.Unauthenticated, ""},
+		{"jwt de un sub fuera de la política", func() (string, error) {
+			_, err := cliente.SendTweet(conToken(t, jwtDesconocido), guatemala)
+			return "", err
+		}, codes.PermissionDenied, ""},
+		{"método no permitido", func() (string, error) {
+			_, err := cliente.ListTweets(conToken(t, claveLocust), &pb.ListTweetsRequest{Country: "Guatemala"})
+			return "", err
+		}, codes.PermissionDenied, ""},
+		{"país no permitido", func() (string, error) {
+			_, err := cliente.SendTweet(conToken(t, claveLocust), &pb.TweetRequest{Country: "Honduras"})
+			return "", err
+		}, codes.PermissionDenied, ""},
+		{"lectora sin restricción de países", func() (string, error) {
+			_, err := cliente.ListTweets(conToken(t, claveLectora), &pb.ListTweetsRequest{})
+			return "", err
+		}, codes.OK, ""},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			got, err := caso.llamar()
+			if status.Code(err) != caso.codigo {
+				t.Fatalf("código %s (%v), se esperaba %s", status.Code(err), err, caso.codigo)
+			}
+			if got != caso.desea {
+				t.Fatalf("respondió %q, se esperaba %q", got, caso.desea)
+			}
+		})
+	}
+}
+
+func TestInterceptorStream(t *testing.T) {
+	cliente, _ := levantar(t, nuevasLlaves(t))
+
+	recibir := func(token string, paises ...string) (*pb.Tweet, error) {
+		stream, err := cliente.SubscribeTweets(conToken(t, token), &pb.SubscribeRequest{Countries: paises})
+		if err != nil {
+			return nil, err
+		}
+		return stream.Recv()
+	}
+	if tw, err := recibir(claveLocust, "Guatemala"); err != nil || tw.Description != "locust" {
+		t.Fatalf("el stream debería ver la identidad: %v, %v", tw, err)
+	}
+	if _, err := recibir(claveLocust); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("un cliente restringido debe filtrar por sus países: %v", err)
+	}
+	if _, err := recibir(""); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("stream sin token: %v", err)
+	}
+}
+
+func TestHealthSinToken(t *testing.T) {
+	_, salud := levantar(t, nuevasLlaves(t))
+	r, err := salud.Check(conToken(t, ""), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("el health check no debería pedir token: %v", err)
+	}
+	if r.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("estado %s", r.Status)
+	}
+}
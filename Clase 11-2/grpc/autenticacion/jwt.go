@@ -0,0 +1,261 @@
+package autenticacion
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Algoritmos aceptados; cada llave del JWKS sirve solo para el suyo
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// Tolerancia para exp y nbf por diferencias de reloj entre pods
+const tolerancia = 30 * time.Second
+
+// llave de verificación de un JWT
+type llave struct {
+	alg  string
+	hmac []byte
+	rsa  *rsa.PublicKey
+}
+
+// llaves por kid
+type llaves map[string]llave
+
+// JWK en el formato de RFC 7517; solo se usan las llaves "oct" (HS256) y "RSA" (RS256)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	K   string `json:"k,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS es el contenido del archivo de llaves
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func cargarJWKS(ruta string) (llaves, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, err
+	}
+	var jwks JWKS
+	if err := json.Unmarshal(datos, &jwks); err != nil {
+		return nil, fmt.Errorf("%s: %w", ruta, err)
+	}
+
+	ll := make(llaves)
+	for i, k := range jwks.Keys {
+		if k.Kid == "" {
+			return nil, fmt.Errorf("%s: la llave %d no tiene kid", ruta, i)
+		}
+		l, err := k.llave()
+		if err != nil {
+			return nil, fmt.Errorf("%s: llave %q: %w", ruta, k.Kid, err)
+		}
+		ll[k.Kid] = l
+	}
+	return ll, nil
+}
+
+func (k JWK) llave() (llave, error) {
+	switch k.Kty {
+	case "oct":
+		if k.Alg != "" && k.Alg != HS256 {
+			return llave{}, fmt.Errorf("alg %s no sirve para una llave oct", k.Alg)
+		}
+		secreto, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return llave{}, fmt.Errorf("k: %w", err)
+		}
+		if len(secreto) < 32 {
+			return llave{}, errors.New("el secreto HS256 debe tener al menos 32 bytes")
+		}
+		return llave{alg: HS256, hmac: secreto}, nil
+	case "RSA":
+		if k.Alg != "" && k.Alg != RS256 {
+			return llave{}, fmt.Errorf("alg %s no sirve para una llave RSA", k.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return llave{}, fmt.Errorf("n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return llave{}, fmt.Errorf("e: %w", err)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return llave{}, errors.New("la llave RSA debe tener al menos 2048 bits")
+		}
+		return llave{alg: RS256, rsa: pub}, nil
+	default:
+		return llave{}, fmt.Errorf("kty %q no soportado", k.Kty)
+	}
+}
+
+// JWKPublica arma el JWK de una llave pública RSA (para cmd/token)
+func JWKPublica(kid string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: RS256,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+type encabezado struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Reclamos que se revisan; aud puede ser un texto o una lista
+type Reclamos struct {
+	Sub string          `json:"sub"`
+	Iss string          `json:"iss,omitempty"`
+	Aud json.RawMessage `json:"aud,omitempty"`
+	Exp int64           `json:"exp"`
+	Nbf int64           `json:"nbf,omitempty"`
+	Iat int64           `json:"iat,omitempty"`
+}
+
+func (r Reclamos) tieneAudiencia(audiencia string) bool {
+	var una string
+	if json.Unmarshal(r.Aud, &una) == nil {
+		return una == audiencia
+	}
+	var varias []string
+	if json.Unmarshal(r.Aud, &varias) == nil {
+		for _, a := range varias {
+			if a == audiencia {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verificarJWT comprueba firma, fechas, emisor y audiencia y devuelve el sub
+func (p *Politica) verificarJWT(token string, ahora time.Time) (string, error) {
+	partes := strings.Split(token, ".")
+	if len(partes) != 3 {
+		return "", errors.New("JWT mal formado")
+	}
+
+	var enc encabezado
+	if err := decodificarParte(partes[0], &enc); err != nil {
+		return "", err
+	}
+	l, ok := p.llaves[enc.Kid]
+	if !ok {
+		return "", fmt.Errorf("kid %q desconocido", enc.Kid)
+	}
+	// El alg lo fija la llave, no el token: así no se aceptan "none" ni HS256
+	// firmado con una llave pública RSA
+	if enc.Alg != l.alg {
+		return "", fmt.Errorf("alg %q no corresponde a la llave %q", enc.Alg, enc.Kid)
+	}
+
+	firma, err := base64.RawURLEncoding.DecodeString(partes[2])
+	if err != nil {
+		return "", errors.New("firma mal codificada")
+	}
+	firmado := []byte(partes[0] + "." + partes[1])
+	switch l.alg {
+	case HS256:
+		mac := hmac.New(sha256.New, l.hmac)
+		mac.Write(firmado)
+		if !hmac.Equal(firma, mac.Sum(nil)) {
+			return "", errors.New("firma inválida")
+		}
+	case RS256:
+		resumen := sha256.Sum256(firmado)
+		if err := rsa.VerifyPKCS1v15(l.rsa, crypto.SHA256, resumen[:], firma); err != nil {
+			return "", errors.New("firma inválida")
+		}
+	}
+
+	var r Reclamos
+	if err := decodificarParte(partes[1], &r); err != nil {
+		return "", err
+	}
+	switch {
+	case r.Sub == "":
+		return "", errors.New("el JWT no tiene sub")
+	case r.Exp == 0:
+		return "", errors.New("el JWT no tiene exp")
+	case ahora.After(time.Unix(r.Exp, 0).Add(tolerancia)):
+		return "", errors.New("el JWT expiró")
+	case r.Nbf != 0 && ahora.Before(time.Unix(r.Nbf, 0).Add(-tolerancia)):
+		return "", errors.New("el JWT todavía no es válido")
+	case p.Emisor != "" && r.Iss != p.Emisor:
+		return "", errors.New("iss inesperado")
+	case p.Audiencia != "" && !r.tieneAudiencia(p.Audiencia):
+		return "", errors.New("aud inesperado")
+	}
+	return r.Sub, nil
+}
+
+func decodificarParte(parte string, v any) error {
+	datos, err := base64.RawURLEncoding.DecodeString(parte)
+	if err != nil {
+		return errors.New("JWT mal codificado")
+	}
+	if err := json.Unmarshal(datos, v); err != nil {
+		return errors.New("JWT mal codificado")
+	}
+	return nil
+}
+
+// FirmarJWT crea un JWT con la llave privada (para cmd/token y pruebas):
+// un []byte para HS256 o un *rsa.PrivateKey para RS256
+func FirmarJWT(kid string, privada any, r Reclamos) (string, error) {
+	var alg string
+	switch privada.(type) {
+	case []byte:
+		alg = HS256
+	case *rsa.PrivateKey:
+		alg = RS256
+	default:
+		return "", fmt.Errorf("llave %T no soportada", privada)
+	}
+
+	enc, _ := json.Marshal(encabezado{Alg: alg, Kid: kid})
+	cuerpo, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	firmado := base64.RawURLEncoding.EncodeToString(enc) + "." + base64.RawURLEncoding.EncodeToString(cuerpo)
+
+	var firma []byte
+	switch k := privada.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(firmado))
+		firma = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		resumen := sha256.Sum256([]byte(firmado))
+		if firma, err = rsa.SignPKCS1v15(nil, k, crypto.SHA256, resumen[:]); err != nil {
+			return "", err
+		}
+	}
+	return firmado + "." + base64.RawURLEncoding.EncodeToString(firma), nil
+}
@@ -0,0 +1,184 @@
+package autenticacion
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// llavesPrueba son las llaves privadas del JWKS de prueba
+type llavesPrueba struct {
+	secreto []byte
+	rsa     *rsa.PrivateKey
+}
+
+var llavesCompartidas *llavesPrueba
+
+// nuevasLlaves genera un secreto HS256 y una llave RSA de 2048 bits (una sola
+// vez: generar RSA tarda)
+func nuevasLlaves(t *testing.T) *llavesPrueba {
+	t.Helper()
+	if llavesCompartidas != nil {
+		return llavesCompartidas
+	}
+	privada, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secreto := make([]byte, 32)
+	rand.Read(secreto)
+	llavesCompartidas = &llavesPrueba{secreto: secreto, rsa: privada}
+	return llavesCompartidas
+}
+
+// escribirPolitica escribe el JWKS ("hs" y "rs") y la política en una carpeta
+// temporal y la carga
+func escribirPolitica(t *testing.T, ll *llavesPrueba, p Politica) *Politica {
+	t.Helper()
+	dir := t.TempDir()
+	jwks := JWKS{Keys: []JWK{
+		{Kty: "oct", Kid: "hs", Alg: HS256, K: base64.RawURLEncoding.EncodeToString(ll.secreto)},
+		JWKPublica("rs", &ll.rsa.PublicKey),
+	}}
+	escribirJSON(t, filepath.Join(dir, "jwks.json"), jwks)
+	p.JWKS = "jwks.json"
+	ruta := filepath.Join(dir, "politica.json")
+	escribirJSON(t, ruta, p)
+
+	cargada, err := CargarPolitica(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cargada
+}
+
+func escribirJSON(t *testing.T, ruta string, v any) {
+	t.Helper()
+	datos, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ruta, datos, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func firmar(t *testing.T, kid string, privada any, r Reclamos) string {
+	t.Helper()
+	token, err := FirmarJWT(kid, privada, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func audiencia(v any) json.RawMessage {
+	datos, _ := json.Marshal(v)
+	return datos
+}
+
+// sinFirma arma un JWT con el encabezado dado y la firma vacía
+func sinFirma(encabezado string, r Reclamos) string {
+	cuerpo, _ := json.Marshal(r)
+	return base64.RawURLEncoding.EncodeToString([]byte(encabezado)) + "." + base64.RawURLEncoding.EncodeToString(cuerpo) + "."
+}
+
+func TestVerificarJWT(t *testing.T) {
+	ll := nuevasLlaves(t)
+	p := escribirPolitica(t, ll, Politica{Emisor: "sopes1", Audiencia: "tweets"})
+	ahora := time.Now()
+	valido := Reclamos{Sub: "locust", Iss: "sopes1", Aud: audiencia("tweets"), Exp: ahora.Add(time.Hour).Unix()}
+	con := func(cambiar func(r *Reclamos)) Reclamos {
+		r := valido
+		cambiar(&r)
+		return r
+	}
+	llavePublica, _ := json.Marshal(JWKPublica("rs", &ll.rsa.PublicKey))
+
+	casos := []struct {
+		nombre string
+		token  string
+		error  string // vacío si el token es válido
+	}{
+		{"HS256", firmar(t, "hs", ll.secreto, valido), ""},
+		{"RS256", firmar(t, "rs", ll.rsa, valido), ""},
+		{"aud en lista", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Aud = audiencia([]string{"otra", "tweets"}) })), ""},
+		{"exp dentro de la tolerancia", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Exp = ahora.Add(-tolerancia / 2).Unix() })), ""},
+		{"nbf dentro de la tolerancia", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Nbf = ahora.Add(tolerancia / 2).Unix() })), ""},
+
+		{"kid desconocido", firmar(t, "otro", ll.secreto, valido), "kid"},
+		{"HS256 con la llave pública RSA", firmar(t, "rs", llavePublica, valido), "alg"},
+		{"RS256 con kid de HS256", firmar(t, "hs", ll.rsa, valido), "alg"},
+		{"alg none", sinFirma(`{"alg":"none","kid":"hs"}`, valido), "alg"},
+		{"firma de otro secreto", firmar(t, "hs", []byte(strings.Repeat("x", 32)), valido), "firma"},
+		{"reclamos alterados", alterar(firmar(t, "rs", ll.rsa, valido)), "firma"},
+		{"expirado", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Exp = ahora.Add(-2 * tolerancia).Unix() })), "expiró"},
+		{"sin exp", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Exp = 0 })), "exp"},
+		{"nbf en el futuro", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Nbf = ahora.Add(2 * tolerancia).Unix() })), "todavía no"},
+		{"sin sub", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Sub = "" })), "sub"},
+		{"otro emisor", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Iss = "otro" })), "iss"},
+		{"otra audiencia", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Aud = audiencia("otra") })), "aud"},
+		{"audiencia que no está en la lista", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Aud = audiencia([]string{"a", "b"}) })), "aud"},
+		{"sin audiencia", firmar(t, "hs", ll.secreto, con(func(r *Reclamos) { r.Aud = nil })), "aud"},
+		{"mal formado", "a.b", "mal formado"},
+		{"mal codificado", "###.###.###", "mal codificado"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			sub, err := p.verificarJWT(caso.token, ahora)
+			if caso.error == "" {
+				if err != nil || sub != "locust" {
+					t.Fatalf("sub = %q, err = %v", sub, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), caso.error) {
+				t.Fatalf("err = %v, se esperaba un error con %q", err, caso.error)
+			}
+		})
+	}
+}
+
+func TestVerificarJWTSinEmisorNiAudiencia(t *testing.T) {
+	p := escribirPolitica(t, nuevasLlaves(t), Politica{})
+	token := firmar(t, "hs", nuevasLlaves(t).secreto, Reclamos{Sub: "locust", Iss: "cualquiera", Exp: time.Now().Add(time.Hour).Unix()})
+	if sub, err := p.verificarJWT(token, time.Now()); err != nil || sub != "locust" {
+		t.Fatalf("sin emisor ni audiencia en la política no se revisan: sub = %q, err = %v", sub, err)
+	}
+}
+
+func TestCargarJWKSInvalido(t *testing.T) {
+	ll := nuevasLlaves(t)
+	pequena, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	casos := map[string]JWK{
+		"sin kid":         {Kty: "oct", K: base64.RawURLEncoding.EncodeToString(ll.secreto)},
+		"secreto corto":   {Kty: "oct", Kid: "hs", K: base64.RawURLEncoding.EncodeToString([]byte("corto"))},
+		"alg de otra kty": {Kty: "oct", Kid: "hs", Alg: RS256, K: base64.RawURLEncoding.EncodeToString(ll.secreto)},
+		"RSA pequeña":     JWKPublica("rs", &pequena.PublicKey),
+		"kty desconocida": {Kty: "EC", Kid: "ec"},
+	}
+	for nombre, k := range casos {
+		ruta := filepath.Join(t.TempDir(), "jwks.json")
+		escribirJSON(t, ruta, JWKS{Keys: []JWK{k}})
+		if _, err := cargarJWKS(ruta); err == nil {
+			t.Errorf("%s: se esperaba un error", nombre)
+		}
+	}
+}
+
+// alterar cambia el sub de un JWT sin volver a firmarlo
+func alterar(token string) string {
+	partes := strings.Split(token, ".")
+	cuerpo, _ := json.Marshal(Reclamos{Sub: "locust", Iss: "sopes1", Aud: audiencia("tweets"), Exp: time.Now().Add(24 * time.Hour).Unix()})
+	partes[1] = base64.RawURLEncoding.EncodeToString(cuerpo)
+	return strings.Join(partes, ".")
+}
@@ -0,0 +1,127 @@
+// Package autenticacion controla quién puede llamar a TweetService. Cada
+// llamada trae "authorization: Bearer <token>", donde el token es una API key
+// estática o un JWT (HS256 o RS256) firmado con una llave del JWKS local. La
+// política (un archivo JSON) dice qué RPC puede llamar cada cliente y de qué
+// países puede enviar o leer tweets.
+package autenticacion
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Cliente de la política. Nombre es el sub de sus JWT; ApiKey es opcional.
+// Metodos son nombres de RPC de TweetService ("SendTweet") o métodos
+// completos ("/grpc.reflection.v1.ServerReflection/ServerReflectionInfo");
+// "*" permite todos. Paises vacío permite todos los países.
+type Cliente struct {
+	Nombre  string   `json:"nombre"`
+	ApiKey  string   `json:"api_key"`
+	Metodos []string `json:"metodos"`
+	Paises  []string `json:"paises"`
+}
+
+// Politica es el contenido del archivo de AUTH_POLITICA
+type Politica struct {
+	// JWKS es la ruta del archivo con las llaves de los JWT; sin JWKS solo se
+	// aceptan API keys
+	JWKS string `json:"jwks"`
+	// Emisor y Audiencia se comparan con iss y aud si no están vacíos
+	Emisor    string    `json:"emisor"`
+	Audiencia string    `json:"audiencia"`
+	Clientes  []Cliente `json:"clientes"`
+
+	porNombre map[string]*Cliente
+	// Las API keys se buscan por su SHA-256 para que comparar no dependa del
+	// contenido de la clave
+	porClave map[[sha256.Size]byte]*Cliente
+	llaves   llaves
+}
+
+// CargarPolitica lee y valida la política y, si tiene, el JWKS
+func CargarPolitica(ruta string) (*Politica, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, err
+	}
+	var p Politica
+	if err := json.Unmarshal(datos, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", ruta, err)
+	}
+
+	p.porNombre = make(map[string]*Cliente)
+	p.porClave = make(map[[sha256.Size]byte]*Cliente)
+	for i := range p.Clientes {
+		c := &p.Clientes[i]
+		if c.Nombre == "" {
+			return nil, fmt.Errorf("%s: el cliente %d no tiene nombre", ruta, i)
+		}
+		if _, repetido := p.porNombre[c.Nombre]; repetido {
+			return nil, fmt.Errorf("%s: el cliente %q está repetido", ruta, c.Nombre)
+		}
+		p.porNombre[c.Nombre] = c
+		if c.ApiKey == "" {
+			continue
+		}
+		if len(c.ApiKey) < 16 {
+			return nil, fmt.Errorf("%s: la api_key de %q debe tener al menos 16 caracteres", ruta, c.Nombre)
+		}
+		if _, repetida := p.porClave[huella(c.ApiKey)]; repetida {
+			return nil, fmt.Errorf("%s: la api_key de %q está repetida", ruta, c.Nombre)
+		}
+		p.porClave[huella(c.ApiKey)] = c
+	}
+
+	if p.JWKS != "" {
+		// Una ruta relativa es relativa al archivo de la política
+		if !filepath.IsAbs(p.JWKS) {
+			p.JWKS = filepath.Join(filepath.Dir(ruta), p.JWKS)
+		}
+		if p.llaves, err = cargarJWKS(p.JWKS); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
+// permiteMetodo indica si c puede llamar a metodo ("/tweet.TweetService/SendTweet")
+func (c *Cliente) permiteMetodo(metodo string) bool {
+	corto := path.Base(metodo)
+	return slices.ContainsFunc(c.Metodos, func(m string) bool {
+		return m == "*" || m == metodo || (strings.HasPrefix(metodo, "/tweet.TweetService/") && m == corto)
+	})
+}
+
+// permitePaises indica si c puede usar todos los países de la lista. Un
+// cliente restringido no puede pedir "todos los países" (lista vacía).
+func (c *Cliente) permitePaises(paises []string) bool {
+	if len(c.Paises) == 0 {
+		return true
+	}
+	if len(paises) == 0 {
+		return false
+	}
+	for _, p := range paises {
+		if !slices.ContainsFunc(c.Paises, func(permitido string) bool { return strings.EqualFold(permitido, p) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func huella(clave string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(clave))
+}
+
+var (
+	errSinToken         = errors.New("falta el encabezado authorization: Bearer <token>")
+	errClaveInvalida    = errors.New("api key inválida")
+	errJWTDeshabilitado = errors.New("el servidor no acepta JWT (la política no tiene jwks)")
+)
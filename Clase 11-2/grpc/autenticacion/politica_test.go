@@ -0,0 +1,92 @@
+package autenticacion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPermiteMetodo(t *testing.T) {
+	c := &Cliente{Metodos: []string{"SendTweet", "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"}}
+	casos := map[string]bool{
+		"/tweet.TweetService/SendTweet":                             true,
+		"/tweet.TweetService/DeleteTweet":                           false,
+		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo": true,
+		// El nombre corto solo vale para TweetService
+		"/otro.Servicio/SendTweet": false,
+	}
+	for metodo, desea := range casos {
+		if got := c.permiteMetodo(metodo); got != desea {
+			t.Errorf("%s: %v, se esperaba %v", metodo, got, desea)
+		}
+	}
+	todos := &Cliente{Metodos: []string{"*"}}
+	if !todos.permiteMetodo("/otro.Servicio/Cualquiera") {
+		t.Error("* debería permitir cualquier método")
+	}
+	if (&Cliente{}).permiteMetodo("/tweet.TweetService/SendTweet") {
+		t.Error("un cliente sin métodos no debería poder llamar nada")
+	}
+}
+
+func TestPermitePaises(t *testing.T) {
+	restringido := &Cliente{Paises: []string{"Guatemala", "México"}}
+	libre := &Cliente{}
+	casos := []struct {
+		cliente *Cliente
+		paises  []string
+		desea   bool
+	}{
+		{restringido, []string{"guatemala"}, true},
+		{restringido, []string{"Guatemala", "MÉXICO"}, true},
+		{restringido, []string{"Guatemala", "Honduras"}, false},
+		{restringido, nil, false},
+		{libre, nil, true},
+		{libre, []string{"Honduras"}, true},
+	}
+	for _, caso := range casos {
+		if got := caso.cliente.permitePaises(caso.paises); got != caso.desea {
+			t.Errorf("%v con %v: %v, se esperaba %v", caso.cliente.Paises, caso.paises, got, caso.desea)
+		}
+	}
+}
+
+func TestCargarPoliticaInvalida(t *testing.T) {
+	casos := map[string]string{
+		"sin nombre":      `{"clientes": [{"api_key": "0123456789abcdef"}]}`,
+		"nombre repetido": `{"clientes": [{"nombre": "a"}, {"nombre": "a"}]}`,
+		"clave corta":     `{"clientes": [{"nombre": "a", "api_key": "corta"}]}`,
+		"clave repetida":  `{"clientes": [{"nombre": "a", "api_key": "0123456789abcdef"}, {"nombre": "b", "api_key": "0123456789abcdef"}]}`,
+		"jwks que falta":  `{"jwks": "no-existe.json", "clientes": []}`,
+		"json inválido":   `{"clientes": [`,
+	}
+	for nombre, contenido := range casos {
+		ruta := filepath.Join(t.TempDir(), "politica.json")
+		if err := os.WriteFile(ruta, []byte(contenido), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CargarPolitica(ruta); err == nil {
+			t.Errorf("%s: se esperaba un error", nombre)
+		}
+	}
+}
+
+func TestClavesPorHuella(t *testing.T) {
+	p := escribirPolitica(t, nuevasLlaves(t), Politica{Clientes: []Cliente{
+		{Nombre: "locust", ApiKey: "clave-de-locust-123"},
+		{Nombre: "solo-jwt"},
+	}})
+	c, ok := p.porClave[huella("clave-de-locust-123")]
+	if !ok || c.Nombre != "locust" {
+		t.Fatalf("la clave de locust debería encontrarse por su huella: %v", c)
+	}
+	if len(p.porClave) != 1 {
+		t.Fatalf("solo los clientes con api_key tienen huella: %d", len(p.porClave))
+	}
+	for h := range p.porClave {
+		if strings.Contains(string(h[:]), "clave-de-locust") {
+			t.Fatal("la huella no debería contener la clave")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package balanceo
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// EsquemaArchivo es el esquema del resolver de archivo:
+// archivo:///etc/grpc/servidores
+const EsquemaArchivo = "archivo"
+
+// RegistrarArchivo registra el resolver "archivo", que lee las direcciones
+// (host:puerto, una por línea; # para comentarios) de un archivo y lo vuelve
+// a leer cuando cambia, revisándolo cada intervalo. Sirve para fijar las
+// réplicas a mano o desde un ConfigMap, sin DNS.
+func RegistrarArchivo(intervalo time.Duration) {
+	resolver.Register(&constructorArchivo{intervalo: intervalo})
+}
+
+type constructorArchivo struct {
+	intervalo time.Duration
+}
+
+func (b *constructorArchivo) Scheme() string {
+	return EsquemaArchivo
+}
+
+func (b *constructorArchivo) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ruta := target.URL.Path
+	if ruta == "" {
+		return nil, fmt.Errorf("%s no tiene la ruta del archivo", target.URL.String())
+	}
+	r := &resolverArchivo{ruta: ruta, cc: cc, ahora: make(chan struct{}, 1), fin: make(chan struct{})}
+	// La primera lectura es síncrona para que un archivo inválido falle al conectar
+	if err := r.leer(); err != nil {
+		return nil, err
+	}
+	go r.vigilar(b.intervalo)
+	return r, nil
+}
+
+type resolverArchivo struct {
+	ruta string
+	cc   resolver.ClientConn
+
+	version   time.Time
+	ahora     chan struct{}
+	fin       chan struct{}
+	cerrarUna sync.Once
+}
+
+// leer actualiza las direcciones si el archivo cambió
+func (r *resolverArchivo) leer() error {
+	info, err := os.Stat(r.ruta)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(r.version) {
+		return nil
+	}
+
+	direcciones, err := leerDirecciones(r.ruta)
+	if err != nil {
+		return err
+	}
+	estado := resolver.State{}
+	for _, d := range direcciones {
+		estado.Addresses = append(estado.Addresses, resolver.Address{Addr: d})
+	}
+	if err := r.cc.UpdateState(estado); err != nil {
+		return err
+	}
+	r.version = info.ModTime()
+	log.Printf("Servidores gRPC de %s: %s", r.ruta, strings.Join(direcciones, ", "))
+	return nil
+}
+
+func leerDirecciones(ruta string) ([]string, error) {
+	f, err := os.Open(ruta)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var direcciones []string
+	lineas := bufio.NewScanner(f)
+	for n := 1; lineas.Scan(); n++ {
+		linea, _, _ := strings.Cut(lineas.Text(), "#")
+		linea = strings.TrimSpace(linea)
+		if linea == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(linea); err != nil {
+			return nil, fmt.Errorf("%s:%d: %q no es host:puerto", ruta, n, linea)
+		}
+		direcciones = append(direcciones, linea)
+	}
+	if err := lineas.Err(); err != nil {
+		return nil, err
+	}
+	if len(direcciones) == 0 {
+		return nil, fmt.Errorf("%s no tiene direcciones", ruta)
+	}
+	return direcciones, nil
+}
+
+func (r *resolverArchivo) vigilar(intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.fin:
+			return
+		case <-ticker.C:
+		case <-r.ahora:
+		}
+		if err := r.leer(); err != nil {
+			// Se siguen usando las direcciones anteriores
+			log.Printf("Error leyendo los servidores gRPC: %v", err)
+			r.cc.ReportError(err)
+		}
+	}
+}
+
+// ResolveNow lo llama gRPC cuando falla una conexión
+func (r *resolverArchivo) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.ahora <- struct{}{}:
+	default:
+	}
+}
+
+func (r *resolverArchivo) Close() {
+	r.cerrarUna.Do(func() { close(r.fin) })
+}
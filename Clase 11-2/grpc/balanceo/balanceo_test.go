@@ -0,0 +1,237 @@
+package balanceo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "grpc/proto"
+	"grpc/resiliencia"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Cada cuánto el resolver vuelve a leer el archivo en las pruebas
+const resolucionPrueba = 20 * time.Millisecond
+
+// servidorPrueba cuenta los SendTweet que recibe
+type servidorPrueba struct {
+	pb.UnimplementedTweetServiceServer
+	direccion string
+	grpc      *grpc.Server
+	llamadas  atomic.Int64
+}
+
+func (s *servidorPrueba) SendTweet(ctx context.Context, req *pb.TweetRequest) (*pb.TweetResponse, error) {
+	s.llamadas.Add(1)
+	return &pb.TweetResponse{Status: "Tweet recibido por " + s.direccion}, nil
+}
+
+func (s *servidorPrueba) detener() {
+	if s.grpc != nil {
+		s.grpc.Stop()
+		s.grpc = nil
+	}
+}
+
+// levantar arranca n servidores en este proceso, en puertos libres de localhost
+func levantar(t *testing.T, n int) []*servidorPrueba {
+	t.Helper()
+	var servidores []*servidorPrueba
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &servidorPrueba{direccion: lis.Addr().String(), grpc: grpc.NewServer()}
+		pb.RegisterTweetServiceServer(s.grpc, s)
+		go s.grpc.Serve(lis)
+		t.Cleanup(s.detener)
+		servidores = append(servidores, s)
+	}
+	return servidores
+}
+
+// escribirDirecciones deja en el archivo los servidores que siguen activos.
+// La fecha se adelanta para que el resolver vea el cambio aunque el sistema
+// de archivos tenga poca resolución.
+func escribirDirecciones(t *testing.T, ruta string, servidores []*servidorPrueba) {
+	t.Helper()
+	var texto strings.Builder
+	texto.WriteString("# Servidores de prueba\n")
+	for _, s := range servidores {
+		if s.grpc != nil {
+			fmt.Fprintln(&texto, s.direccion)
+		}
+	}
+	if err := os.WriteFile(ruta, []byte(texto.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	version := time.Now()
+	if info, err := os.Stat(ruta); err == nil && !info.ModTime().Before(version) {
+		version = info.ModTime().Add(time.Second)
+	}
+	if err := os.Chtimes(ruta, version, version); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// conectar escribe el archivo con los servidores y conecta con archivo:///ruta,
+// igual que el cliente REST
+func conectar(t *testing.T, servidores []*servidorPrueba, politica string) (pb.TweetServiceClient, string) {
+	t.Helper()
+	ruta := filepath.Join(t.TempDir(), "servidores")
+	escribirDirecciones(t, ruta, servidores)
+
+	RegistrarArchivo(resolucionPrueba)
+	conn, err := grpc.NewClient(EsquemaArchivo+"://"+ruta,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(resiliencia.ConfiguracionServicio(resiliencia.Reintentos{Intentos: 3, Espera: 10 * time.Millisecond, EsperaMaxima: 100 * time.Millisecond}, politica)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTweetServiceClient(conn), ruta
+}
+
+// enviar manda n tweets y devuelve cuántos fallaron
+func enviar(cliente pb.TweetServiceClient, n int) int {
+	errores := 0
+	for i := 0; i < n; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := cliente.SendTweet(ctx, &pb.TweetRequest{Description: "prueba de balanceo", Country: "Guatemala", Weather: "soleado"})
+		cancel()
+		if err != nil {
+			errores++
+		}
+	}
+	return errores
+}
+
+// esperarTrafico manda llamadas hasta que todos los servidores activos
+// reciban alguna: round_robin solo usa las conexiones ya establecidas
+func esperarTrafico(t *testing.T, cliente pb.TweetServiceClient, servidores []*servidorPrueba) {
+	t.Helper()
+	for limite := time.Now().Add(5 * time.Second); time.Now().Before(limite); {
+		reiniciar(servidores)
+		enviar(cliente, 10*len(servidores))
+		todos := true
+		for _, s := range servidores {
+			if s.grpc != nil && s.llamadas.Load() == 0 {
+				todos = false
+			}
+		}
+		if todos {
+			return
+		}
+		time.Sleep(resolucionPrueba)
+	}
+	t.Fatalf("no todos los servidores recibieron llamadas: %s", reparto(servidores))
+}
+
+func reiniciar(servidores []*servidorPrueba) {
+	for _, s := range servidores {
+		s.llamadas.Store(0)
+	}
+}
+
+func reparto(servidores []*servidorPrueba) string {
+	var partes []string
+	for _, s := range servidores {
+		partes = append(partes, fmt.Sprintf("%s=%d", s.direccion, s.llamadas.Load()))
+	}
+	return strings.Join(partes, " ")
+}
+
+// comprobarReparto exige que cada servidor activo atienda al menos la mitad
+// de lo que le tocaría en un reparto parejo, y que los detenidos no atiendan nada
+func comprobarReparto(t *testing.T, servidores []*servidorPrueba, llamadas int) {
+	t.Helper()
+	activos := 0
+	for _, s := range servidores {
+		if s.grpc != nil {
+			activos++
+		}
+	}
+	t.Logf("%d llamadas: %s", llamadas, reparto(servidores))
+	for _, s := range servidores {
+		n := s.llamadas.Load()
+		if s.grpc == nil && n != 0 {
+			t.Errorf("%s está detenido y atendió %d llamadas", s.direccion, n)
+		}
+		if s.grpc != nil && n < int64(llamadas/activos/2) {
+			t.Errorf("%s atendió %d de %d llamadas entre %d servidores: %s", s.direccion, n, llamadas, activos, reparto(servidores))
+		}
+	}
+}
+
+func TestRoundRobinReparte(t *testing.T) {
+	servidores := levantar(t, 3)
+	cliente, ruta := conectar(t, servidores, "round_robin")
+	esperarTrafico(t, cliente, servidores)
+
+	reiniciar(servidores)
+	if errores := enviar(cliente, 300); errores != 0 {
+		t.Fatalf("%d llamadas fallaron", errores)
+	}
+	comprobarReparto(t, servidores, 300)
+
+	// Una réplica nueva recibe tráfico cuando el resolver vuelve a leer el archivo
+	servidores = append(servidores, levantar(t, 1)...)
+	escribirDirecciones(t, ruta, servidores)
+	esperarTrafico(t, cliente, servidores)
+	reiniciar(servidores)
+	if errores := enviar(cliente, 300); errores != 0 {
+		t.Fatalf("%d llamadas fallaron después de agregar un servidor", errores)
+	}
+	comprobarReparto(t, servidores, 300)
+
+	// Una réplica que se cae deja de recibir llamadas sin tocar el archivo;
+	// las llamadas que le tocaban se reintentan en otra
+	servidores[0].detener()
+	time.Sleep(100 * time.Millisecond)
+	reiniciar(servidores)
+	if errores := enviar(cliente, 300); errores != 0 {
+		t.Fatalf("%d llamadas fallaron después de detener un servidor", errores)
+	}
+	comprobarReparto(t, servidores, 300)
+}
+
+func TestPickFirstUsaUnServidor(t *testing.T) {
+	servidores := levantar(t, 3)
+	cliente, _ := conectar(t, servidores, "pick_first")
+
+	if errores := enviar(cliente, 100); errores != 0 {
+		t.Fatalf("%d llamadas fallaron", errores)
+	}
+	t.Logf("100 llamadas: %s", reparto(servidores))
+	usados := 0
+	for _, s := range servidores {
+		if s.llamadas.Load() > 0 {
+			usados++
+		}
+	}
+	if usados != 1 {
+		t.Fatalf("pick_first usó %d servidores: %s", usados, reparto(servidores))
+	}
+}
+
+func TestArchivoInvalido(t *testing.T) {
+	ruta := filepath.Join(t.TempDir(), "servidores")
+	for _, contenido := range []string{"# sin direcciones\n", "127.0.0.1\n"} {
+		if err := os.WriteFile(ruta, []byte(contenido), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := leerDirecciones(ruta); err == nil {
+			t.Errorf("%q: se esperaba un error", contenido)
+		}
+	}
+}
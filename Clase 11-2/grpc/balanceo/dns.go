@@ -0,0 +1,69 @@
+// Package balanceo reparte las llamadas del cliente entre las réplicas del
+// servidor gRPC. Con una sola conexión HTTP/2 detrás de un Service ClusterIP
+// todo el tráfico de un pod va a una sola réplica; con round_robin el
+// cliente abre una conexión a cada dirección que resuelve y alterna entre
+// ellas. Las direcciones salen del DNS (un Service headless devuelve la IP de
+// cada pod) o de un archivo.
+package balanceo
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/dns"
+)
+
+// RegistrarDNSPeriodico reemplaza el resolver "dns" de gRPC por uno que
+// además vuelve a resolver cada intervalo. El de gRPC solo resuelve de nuevo
+// cuando falla una conexión, así que las réplicas que agrega el HPA no
+// reciben tráfico hasta que algo se cae. Se llama una vez antes de Dial.
+func RegistrarDNSPeriodico(intervalo time.Duration) {
+	// gRPC ignora los ResolveNow más seguidos que este mínimo (30s por defecto)
+	dns.SetMinResolutionInterval(min(intervalo, 30*time.Second))
+	resolver.Register(&dnsPeriodico{base: resolver.Get("dns"), intervalo: intervalo})
+}
+
+type dnsPeriodico struct {
+	base      resolver.Builder
+	intervalo time.Duration
+}
+
+func (b *dnsPeriodico) Scheme() string {
+	return "dns"
+}
+
+func (b *dnsPeriodico) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	r, err := b.base.Build(target, cc, opts)
+	if err != nil {
+		return nil, err
+	}
+	p := &resolverPeriodico{Resolver: r, fin: make(chan struct{})}
+	go p.refrescar(b.intervalo)
+	return p, nil
+}
+
+// resolverPeriodico llama a ResolveNow del resolver de gRPC cada intervalo
+type resolverPeriodico struct {
+	resolver.Resolver
+	fin       chan struct{}
+	cerrarUna sync.Once
+}
+
+func (p *resolverPeriodico) refrescar(intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.fin:
+			return
+		case <-ticker.C:
+			p.Resolver.ResolveNow(resolver.ResolveNowOptions{})
+		}
+	}
+}
+
+func (p *resolverPeriodico) Close() {
+	p.cerrarUna.Do(func() { close(p.fin) })
+	p.Resolver.Close()
+}
@@ -3,15 +3,32 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 	"fmt"
+	"strconv"
 	pb "grpc/proto"
+	"grpc/autenticacion"
+	"grpc/balanceo"
+	"grpc/cola"
+	"grpc/lotes"
+	"grpc/resiliencia"
+	"grpc/seguridad"
+	"grpc/validacion"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Estructura para recibir datos de Rust
@@ -25,6 +42,33 @@ type DatosClima struct {
 // Cliente gRPC global
 var grpcClient pb.TweetServiceClient
 
+// Agrupador de tweets en lotes; nil si cada POST se envía con SendTweet
+var agrupador *lotes.Agrupador
+
+// Tiempo máximo de la llamada gRPC de cada POST (o menos si Rust corta antes)
+var timeoutGRPC time.Duration
+
+// Circuito que responde 503 sin llamar al servidor gRPC mientras falla; nil si está desactivado
+var circuito *resiliencia.Circuito
+
+// Cola donde el POST deja los datos para que los envíen los trabajadores; nil si cada POST espera a gRPC
+var colaTweets cola.Cola
+
+// Trabajadores que mandan al servidor los tweets de la cola; cuentan los
+// descartados que se exponen en /cola
+var trabajadoresCola *cola.Trabajadores
+
+// Cómo reintentan los trabajadores un tweet de la cola
+var insistenciaCola cola.Insistencia
+
+// duracionEnv lee una duración de una variable de entorno o devuelve defecto
+func duracionEnv(nombre string, defecto time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(nombre)); err == nil && d > 0 {
+		return d
+	}
+	return defecto
+}
+
 func main() {
 	// Configurar conexión gRPC
 	grpcServerAddr := os.Getenv("GRPC_SERVER_ADDR")
@@ -32,7 +76,77 @@ func main() {
 		grpcServerAddr = "localhost:50051"
 	}
 
-	conn, err := grpc.Dial(grpcServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Con TLS_CA se verifica al servidor con esa CA; con TLS_CERT y TLS_KEY además
+	// se presenta un certificado de cliente (mTLS)
+	certificados, err := seguridad.DeEntorno(context.Background())
+	if err != nil {
+		log.Fatalf("Error cargando los certificados TLS: %v", err)
+	}
+	var creds credentials.TransportCredentials
+	if certificados != nil {
+		creds = certificados.Cliente(os.Getenv("TLS_SERVER_NAME"))
+		log.Printf("Conexión gRPC con TLS (certificado de cliente: %v)", certificados.ConCertificado())
+	} else {
+		creds = insecure.NewCredentials()
+		log.Printf("ADVERTENCIA: conexión gRPC sin cifrar (TLS_CA no está definida)")
+	}
+
+	opciones := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	// GRPC_INTENTOS intentos por llamada: reintentos en UNAVAILABLE o cuando
+	// vence el deadline del intento o, con GRPC_HEDGING=true, copias de la
+	// llamada cada GRPC_HEDGING_DEMORA si la anterior no responde
+	timeoutGRPC = duracionEnv("GRPC_TIMEOUT", 5*time.Second)
+	intentos, err := strconv.Atoi(os.Getenv("GRPC_INTENTOS"))
+	if err != nil || intentos < 1 {
+		intentos = 3
+	}
+	reintentos := resiliencia.Reintentos{Intentos: intentos, Espera: 100 * time.Millisecond, EsperaMaxima: time.Second}
+	// Cada intento tiene su propio deadline, por defecto una parte de
+	// GRPC_TIMEOUT, para que una réplica colgada no gaste toda la petición
+	porIntento := duracionEnv("GRPC_TIMEOUT_INTENTO", timeoutGRPC/time.Duration(intentos))
+	switch {
+	case os.Getenv("GRPC_HEDGING") == "true":
+		demora := duracionEnv("GRPC_HEDGING_DEMORA", 100*time.Millisecond)
+		opciones = append(opciones, grpc.WithChainUnaryInterceptor(resiliencia.Hedging(intentos, demora, porIntento)))
+		reintentos.Intentos = 1
+		log.Printf("Hedging: hasta %d copias de cada llamada, una cada %s, de hasta %s cada una", intentos, demora, porIntento)
+	case intentos > 1:
+		opciones = append(opciones, grpc.WithChainUnaryInterceptor(resiliencia.Reintentar(reintentos, porIntento)))
+		reintentos.Intentos = 1
+		log.Printf("Reintentos en UNAVAILABLE y DEADLINE_EXCEEDED: hasta %d intentos de hasta %s cada uno", intentos, porIntento)
+	}
+
+	// Con GRPC_SERVER_ADDR=dns:///host:puerto (un Service headless) o
+	// archivo:///ruta el cliente conecta con cada réplica y reparte las
+	// llamadas según GRPC_BALANCEO; las direcciones se vuelven a leer cada
+	// GRPC_RESOLUCION para enterarse de las réplicas nuevas
+	resolucion := duracionEnv("GRPC_RESOLUCION", 30*time.Second)
+	balanceo.RegistrarDNSPeriodico(resolucion)
+	balanceo.RegistrarArchivo(resolucion)
+	politicaBalanceo := os.Getenv("GRPC_BALANCEO")
+	if politicaBalanceo == "" {
+		politicaBalanceo = "round_robin"
+	}
+	log.Printf("Balanceo gRPC: %s, resolviendo de nuevo cada %s", politicaBalanceo, resolucion)
+	opciones = append(opciones, grpc.WithDefaultServiceConfig(resiliencia.ConfiguracionServicio(reintentos, politicaBalanceo)))
+
+	// Después de CIRCUITO_FALLAS errores seguidos se responde 503 durante CIRCUITO_ESPERA
+	if fallas, err := strconv.Atoi(os.Getenv("CIRCUITO_FALLAS")); err != nil || fallas > 0 {
+		if err != nil {
+			fallas = 5
+		}
+		espera := duracionEnv("CIRCUITO_ESPERA", 10*time.Second)
+		circuito = resiliencia.NuevoCircuito(fallas, espera)
+		log.Printf("Circuito: se abre con %d fallas seguidas durante %s", fallas, espera)
+	}
+
+	// AUTH_TOKEN (o AUTH_TOKEN_ARCHIVO) es la API key o el JWT del cliente
+	if token := autenticacion.TokenDeEntorno(certificados != nil); token != nil {
+		opciones = append(opciones, grpc.WithPerRPCCredentials(token))
+	}
+
+	conn, err := grpc.Dial(grpcServerAddr, opciones...)
 	if err != nil {
 		log.Fatalf("No se pudo conectar al servidor gRPC: %v", err)
 	}
@@ -41,21 +155,202 @@ func main() {
 	grpcClient = pb.NewTweetServiceClient(conn)
 	log.Printf("Conectado al servidor gRPC en %s", grpcServerAddr)
 
+	// LOTE_MAX tweets por stream como máximo, esperando hasta LOTE_INTERVALO desde el primero
+	if max, _ := strconv.Atoi(os.Getenv("LOTE_MAX")); max > 1 {
+		intervalo := duracionEnv("LOTE_INTERVALO", 100*time.Millisecond)
+		agrupador = lotes.Nuevo(grpcClient, max, intervalo, timeoutGRPC)
+		log.Printf("Envío en lotes de hasta %d tweets cada %s", max, intervalo)
+	}
+
+	// Con COLA=memoria o COLA=kafka el POST responde 202 al encolar y
+	// COLA_TRABAJADORES trabajadores mandan los tweets al servidor gRPC
+	colaTweets = abrirCola()
+	ctxTrabajadores, detenerTrabajadores := context.WithCancel(context.Background())
+	defer detenerTrabajadores()
+	if colaTweets != nil {
+		trabajadores, err := strconv.Atoi(os.Getenv("COLA_TRABAJADORES"))
+		if err != nil || trabajadores < 1 {
+			trabajadores = 8
+		}
+		maxIntentos, err := strconv.Atoi(os.Getenv("COLA_MAX_INTENTOS"))
+		if err != nil || maxIntentos < 1 {
+			maxIntentos = 10
+		}
+		insistenciaCola = cola.Insistencia{
+			MaxIntentos:  maxIntentos,
+			Espera:       100 * time.Millisecond,
+			EsperaMaxima: 30 * time.Second,
+			Reintentable: resiliencia.Falla,
+			Permitir:     permitirLlamada,
+		}
+		trabajadoresCola = cola.Procesar(ctxTrabajadores, colaTweets, trabajadores, procesarMensaje)
+		log.Printf("%d trabajadores enviando los tweets de la cola (hasta %d intentos por tweet)", trabajadores, maxIntentos)
+	}
+
 	// Configurar servidor HTTP REST
 	http.HandleFunc("/clima", recibirClimaHandler)
 	http.HandleFunc("/health", healthCheckHandler)
+	if colaTweets != nil {
+		http.HandleFunc("/cola", estadoColaHandler)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
+	servidorHTTP := &http.Server{Addr: ":" + port}
+
+	// Manejo de señales para shutdown graceful: primero se dejan de aceptar
+	// POST y después los trabajadores vacían la cola, hasta COLA_DRENADO
+	apagado := make(chan struct{})
+	go func() {
+		defer close(apagado)
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		<-sigChan
+
+		log.Println("\nSeñal de apagado recibida, cerrando cliente...")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		servidorHTTP.Shutdown(ctx)
+		if colaTweets == nil {
+			return
+		}
+
+		drenado := duracionEnv("COLA_DRENADO", 10*time.Second)
+		go func() {
+			if err := colaTweets.Cerrar(); err != nil {
+				log.Printf("Error cerrando la cola: %v", err)
+			}
+		}()
+		select {
+		case <-trabajadoresCola.Terminados():
+			log.Println("Trabajadores de la cola terminados")
+		case <-time.After(drenado):
+			detenerTrabajadores()
+			<-trabajadoresCola.Terminados()
+			// En Kafka los mensajes sin confirmar se entregan al volver a arrancar
+			if memoria, ok := colaTweets.(*cola.Memoria); ok {
+				log.Printf("La cola no se vació en %s: se pierden los tweets sin enviar (%d en la cola)", drenado, memoria.Pendientes())
+			} else {
+				log.Printf("Los trabajadores no terminaron en %s", drenado)
+			}
+		}
+	}()
 
 	log.Printf("Servidor REST corriendo en puerto %s", port)
 	log.Printf("Esperando datos de Rust...")
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	if err := servidorHTTP.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatalf("Error al iniciar servidor HTTP: %v", err)
 	}
+	<-apagado
+	log.Println("Cliente cerrado correctamente")
+}
+
+// abrirCola crea la cola de COLA: "memoria" (COLA_CAPACIDAD mensajes) o
+// "kafka" (KAFKA_BROKER, KAFKA_TOPIC, KAFKA_GRUPO y KAFKA_TOPIC_DESCARTADOS).
+// Devuelve nil si COLA no está definida.
+func abrirCola() cola.Cola {
+	switch os.Getenv("COLA") {
+	case "":
+		return nil
+	case "memoria":
+		capacidad, err := strconv.Atoi(os.Getenv("COLA_CAPACIDAD"))
+		if err != nil || capacidad < 1 {
+			capacidad = 1000
+		}
+		log.Printf("Cola en memoria de %d tweets", capacidad)
+		return cola.NuevaMemoria(capacidad)
+	case "kafka":
+		brokers := os.Getenv("KAFKA_BROKER")
+		if brokers == "" {
+			brokers = "localhost:9092"
+		}
+		topic := os.Getenv("KAFKA_TOPIC")
+		if topic == "" {
+			topic = "clima"
+		}
+		grupo := os.Getenv("KAFKA_GRUPO")
+		if grupo == "" {
+			grupo = "grpc-client"
+		}
+		descartados := os.Getenv("KAFKA_TOPIC_DESCARTADOS")
+		if descartados == "" {
+			descartados = topic + "-descartados"
+		}
+		log.Printf("Cola en Kafka: topic %s en %s (grupo %s, descartados en %s)", topic, brokers, grupo, descartados)
+		return cola.NuevaKafka(strings.Split(brokers, ","), topic, grupo, descartados)
+	default:
+		log.Fatalf("COLA debe ser memoria o kafka, no %q", os.Getenv("COLA"))
+		return nil
+	}
+}
+
+// mensajeClima es un mensaje de la cola. Acepta también el formato del
+// productor de la Clase 12, que manda municipio en lugar de name.
+type mensajeClima struct {
+	DatosClima
+	Municipio string `json:"municipio"`
+}
+
+// procesarMensaje manda al servidor gRPC un tweet de la cola. Mientras el
+// servidor falle (o el circuito esté abierto) se reintenta según
+// insistenciaCola; si el servidor rechaza los datos, el mensaje no es JSON o
+// se agotan los intentos, el error es el motivo para descartarlo.
+func procesarMensaje(ctx context.Context, valor []byte) error {
+	var mensaje mensajeClima
+	if err := json.Unmarshal(valor, &mensaje); err != nil {
+		return fmt.Errorf("JSON inválido: %v", err)
+	}
+	datos := mensaje.DatosClima
+	if datos.Name == "" {
+		datos.Name = mensaje.Municipio
+	}
+	datos.Clima = normalizarClima(datos.Clima)
+
+	return cola.Insistir(ctx, insistenciaCola, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeoutGRPC)
+		defer cancel()
+		_, err := enviarTweet(ctx, datos)
+		return err
+	})
+}
+
+// climasProductor corrige los climas que manda el productor de la Clase 12
+// y que el servidor no reconoce. Los mensajes viejos del topic pueden traer
+// la errata "Nubslado" aunque el productor ya esté corregido.
+var climasProductor = map[string]string{
+	"nubslado": "nublado",
+}
+
+// normalizarClima pasa el clima a minúsculas y corrige los valores conocidos
+// del productor; los demás se envían igual y el servidor decide
+func normalizarClima(clima string) string {
+	clima = strings.ToLower(strings.TrimSpace(clima))
+	if corregido, ok := climasProductor[clima]; ok {
+		return corregido
+	}
+	return clima
+}
+
+// estadoColaHandler responde los mensajes descartados y, en la cola en
+// memoria, los pendientes
+func estadoColaHandler(w http.ResponseWriter, r *http.Request) {
+	estado := map[string]int64{"descartados": trabajadoresCola.Descartados()}
+	if memoria, ok := colaTweets.(*cola.Memoria); ok {
+		estado["pendientes"] = int64(memoria.Pendientes())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(estado)
+}
+
+// permitirLlamada consulta el circuito, si está activado
+func permitirLlamada() (terminar func(error), espera time.Duration, ok bool) {
+	if circuito == nil {
+		return func(error) {}, 0, true
+	}
+	return circuito.Permitir()
 }
 
 // Handler para recibir datos de clima desde Rust
@@ -68,7 +363,7 @@ func recibirClimaHandler(w http.ResponseWriter, r *http.Request) {
 	var datos DatosClima
 	if err := json.NewDecoder(r.Body).Decode(&datos); err != nil {
 		log.Printf("Error al decodificar JSON: %v", err)
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		responderError(w, http.StatusBadRequest, "JSON inválido", nil)
 		return
 	}
 
@@ -78,26 +373,36 @@ func recibirClimaHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Humedad: %d%%", datos.Humedad)
 	log.Printf("Clima: %s", datos.Clima)
 
-	// Enviar datos al servidor gRPC
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Con cola se responde 202 apenas el tweet queda encolado; los datos
+	// inválidos ya no llegan a Rust como 422, se descartan en el trabajador
+	if colaTweets != nil {
+		encolarDatos(w, r, datos)
+		return
+	}
+
+	// Con el circuito abierto se responde 503 sin llamar al servidor gRPC
+	terminar, espera, ok := permitirLlamada()
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(espera.Seconds())))))
+		responderError(w, http.StatusServiceUnavailable, "Servidor gRPC no disponible, intente de nuevo", nil)
+		return
+	}
+
+	// Enviar datos al servidor gRPC. El deadline sale del contexto de la
+	// petición HTTP: si Rust corta la conexión se cancela la llamada (y sus
+	// reintentos) en lugar de seguir ocupando al servidor
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGRPC)
 	defer cancel()
 
-	description := formatDescription(datos)
-	
-	resp, err := grpcClient.SendTweet(ctx, &pb.TweetRequest{
-		Description: description,
-		Country:     datos.Name,
-		Weather:     datos.Clima,
-	})
+	trailer, err := enviarTweet(ctx, datos)
+	terminar(err)
 
 	if err != nil {
 		log.Printf("Error al enviar a gRPC: %v", err)
-		http.Error(w, "Error al procesar datos", http.StatusInternalServerError)
+		responderErrorGRPC(w, err, trailer)
 		return
 	}
 
-	log.Printf("Respuesta gRPC: %s", resp.Status)
-
 	// Responder a Rust
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -107,6 +412,121 @@ func recibirClimaHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// enviarTweet manda los datos al servidor gRPC. El trailer trae retry-after
+// si el servidor rechazó la llamada por sus límites.
+func enviarTweet(ctx context.Context, datos DatosClima) (metadata.MD, error) {
+	description := formatDescription(datos)
+	
+	req := &pb.TweetRequest{
+		Description: description,
+		Country:     datos.Name,
+		Weather:     datos.Clima,
+	}
+
+	// Con LOTE_MAX > 1 el tweet sale junto con los de otros POST en un stream SendTweets
+	if agrupador != nil {
+		return nil, agrupador.Enviar(ctx, req)
+	}
+	var trailer metadata.MD
+	resp, err := grpcClient.SendTweet(ctx, req, grpc.Trailer(&trailer))
+	if err == nil {
+		log.Printf("Respuesta gRPC: %s", resp.Status)
+	}
+	return trailer, err
+}
+
+// encolarDatos deja los datos en la cola y responde 202, o 503 si la cola
+// está llena o Kafka no responde
+func encolarDatos(w http.ResponseWriter, r *http.Request, datos DatosClima) {
+	valor, _ := json.Marshal(datos)
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGRPC)
+	defer cancel()
+	if err := colaTweets.Encolar(ctx, valor); err != nil {
+		log.Printf("Error al encolar: %v", err)
+		mensaje := "Cola no disponible, intente de nuevo"
+		if errors.Is(err, cola.ErrLlena) {
+			mensaje = "Cola llena, intente de nuevo"
+		}
+		w.Header().Set("Retry-After", "1")
+		responderError(w, http.StatusServiceUnavailable, mensaje, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "accepted",
+		"message": "Datos encolados para enviar a gRPC",
+	})
+}
+
+// Respuesta de error para Rust; violations tiene un elemento por campo inválido
+type respuestaError struct {
+	Status     string      `json:"status"`
+	Message    string      `json:"message"`
+	Violations []violacion `json:"violations,omitempty"`
+}
+
+type violacion struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Nombres de los campos de TweetRequest en el JSON que envía Rust
+var camposREST = map[string]string{
+	"country": "name",
+	"weather": "clima",
+}
+
+// responderErrorGRPC responde con el estado HTTP del código gRPC y, si el
+// servidor mandó un BadRequest, con las violaciones por campo. Con 429 el
+// encabezado Retry-After repite el retry-after del trailer (1 si no vino).
+func responderErrorGRPC(w http.ResponseWriter, err error, trailer metadata.MD) {
+	st := status.Convert(err)
+	estado := validacion.EstadoHTTP(st.Code())
+	if estado == http.StatusTooManyRequests {
+		reintento := "1"
+		if v := trailer.Get("retry-after"); len(v) > 0 {
+			reintento = v[0]
+		}
+		w.Header().Set("Retry-After", reintento)
+	}
+
+	var violaciones []violacion
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				campo := v.Field
+				if c, ok := camposREST[campo]; ok {
+					campo = c
+				}
+				violaciones = append(violaciones, violacion{Field: campo, Description: v.Description})
+			}
+		}
+	}
+
+	// El mensaje de gRPC usa los nombres de los campos del proto y los errores
+	// internos no se exponen, así que se responde con un mensaje fijo por estado
+	mensajes := map[int]string{
+		http.StatusUnprocessableEntity: "Datos inválidos",
+		http.StatusNotFound:            "No encontrado",
+		http.StatusForbidden:           "País no permitido para este cliente",
+		http.StatusTooManyRequests:     "Demasiadas solicitudes, intente de nuevo más tarde",
+		http.StatusServiceUnavailable:  "Servidor gRPC no disponible, intente de nuevo",
+	}
+	mensaje, ok := mensajes[estado]
+	if !ok {
+		mensaje = "Error al procesar datos"
+	}
+	responderError(w, estado, mensaje, violaciones)
+}
+
+func responderError(w http.ResponseWriter, estado int, mensaje string, violaciones []violacion) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(estado)
+	json.NewEncoder(w).Encode(respuestaError{Status: "error", Message: mensaje, Violations: violaciones})
+}
+
 // Health check para Kubernetes
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
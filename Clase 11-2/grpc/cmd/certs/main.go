@@ -0,0 +1,164 @@
+// Comando certs genera una CA y certificados de servidor y cliente para
+// probar TLS y mTLS en desarrollo (no usar en producción):
+//
+//	go run ./cmd/certs -dir certs -hosts localhost,127.0.0.1,grpc-server-service,grpc-server-headless
+//
+// Crea ca.pem, ca-key.pem, server.pem, server-key.pem, client.pem y
+// client-key.pem. Si ca.pem ya existe en -dir se reutiliza la CA, así se
+// pueden rotar los certificados sin cambiar la CA de los pods.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", "certs", "carpeta de salida")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,grpc-server-service,grpc-server-headless", "nombres e IPs del servidor, separados por coma")
+	cliente := flag.String("cliente", "api-go", "nombre (CN) del certificado de cliente")
+	validez := flag.Duration("validez", 90*24*time.Hour, "validez de los certificados de servidor y cliente")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatalf("Error creando %s: %v", *dir, err)
+	}
+
+	ca, caKey, err := cargarCA(*dir)
+	if errors.Is(err, os.ErrNotExist) {
+		ca, caKey, err = crearCA(*dir)
+	}
+	if err != nil {
+		log.Fatalf("Error con la CA: %v", err)
+	}
+
+	servidor := plantilla("grpc-server", *validez)
+	servidor.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, h := range strings.Split(*hosts, ",") {
+		h = strings.TrimSpace(h)
+		if ip := net.ParseIP(h); ip != nil {
+			servidor.IPAddresses = append(servidor.IPAddresses, ip)
+		} else if h != "" {
+			servidor.DNSNames = append(servidor.DNSNames, h)
+		}
+	}
+	if err := firmar(*dir, "server", servidor, ca, caKey); err != nil {
+		log.Fatalf("Error creando el certificado del servidor: %v", err)
+	}
+
+	cli := plantilla(*cliente, *validez)
+	cli.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	if err := firmar(*dir, "client", cli, ca, caKey); err != nil {
+		log.Fatalf("Error creando el certificado del cliente: %v", err)
+	}
+
+	log.Printf("Certificados creados en %s (servidor: %s; cliente: %s)", *dir, *hosts, *cliente)
+}
+
+func plantilla(nombre string, validez time.Duration) *x509.Certificate {
+	serie, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	ahora := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serie,
+		Subject:      pkix.Name{CommonName: nombre, Organization: []string{"SO1 desarrollo"}},
+		NotBefore:    ahora.Add(-time.Minute),
+		NotAfter:     ahora.Add(validez),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
+
+func crearCA(dir string) (*x509.Certificate, crypto.Signer, error) {
+	ca := plantilla("SO1 CA de desarrollo", 10*365*24*time.Hour)
+	ca.IsCA = true
+	ca.BasicConstraintsValid = true
+	ca.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, ca, ca, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := guardar(dir, "ca", der, key); err != nil {
+		return nil, nil, err
+	}
+	ca, err = x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+func cargarCA(dir string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, "ca-key.pem"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bloque, _ := pem.Decode(certPEM)
+	if bloque == nil {
+		return nil, nil, fmt.Errorf("ca.pem no es PEM")
+	}
+	ca, err := x509.ParseCertificate(bloque.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	bloque, _ = pem.Decode(keyPEM)
+	if bloque == nil {
+		return nil, nil, fmt.Errorf("ca-key.pem no es PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(bloque.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("ca-key.pem no sirve para firmar")
+	}
+	log.Printf("Usando la CA existente de %s", dir)
+	return ca, signer, nil
+}
+
+func firmar(dir, nombre string, cert, ca *x509.Certificate, caKey crypto.Signer) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, cert, ca, key.Public(), caKey)
+	if err != nil {
+		return err
+	}
+	return guardar(dir, nombre, der, key)
+}
+
+// guardar escribe <nombre>.pem y <nombre>-key.pem (la llave solo legible por el dueño)
+func guardar(dir, nombre string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, nombre+".pem"), certPEM, 0o644); err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return os.WriteFile(filepath.Join(dir, nombre+"-key.pem"), keyPEM, 0o600)
+}
@@ -0,0 +1,131 @@
+// Comando token crea llaves y JWT para probar la autenticación de
+// TweetService (no usar en producción):
+//
+//	go run ./cmd/token -generar -kid hs1 > jwks.json          # JWKS con un secreto HS256 nuevo
+//	go run ./cmd/token -generar -kid rs1 -rsa llave.pem        # JWK público de una llave RSA
+//	go run ./cmd/token -jwks jwks.json -kid hs1 -sub api-go    # JWT HS256
+//	go run ./cmd/token -rsa llave.pem -kid rs1 -sub dashboard  # JWT RS256
+//
+// La llave RSA se puede crear con: openssl genrsa -out llave.pem 2048
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"grpc/autenticacion"
+)
+
+func main() {
+	kid := flag.String("kid", "", "id de la llave")
+	generar := flag.Bool("generar", false, "escribir un JWKS en lugar de un JWT")
+	jwks := flag.String("jwks", "", "JWKS con el secreto HS256 para firmar")
+	rsaPEM := flag.String("rsa", "", "llave privada RSA (PEM) para firmar con RS256")
+	sub := flag.String("sub", "", "cliente (nombre en la política)")
+	ttl := flag.Duration("ttl", time.Hour, "validez del JWT")
+	iss := flag.String("iss", "", "emisor")
+	aud := flag.String("aud", "", "audiencia")
+	flag.Parse()
+
+	if *kid == "" {
+		log.Fatalf("Falta -kid")
+	}
+
+	var privada any
+	if *rsaPEM != "" {
+		llave, err := leerRSA(*rsaPEM)
+		if err != nil {
+			log.Fatalf("Error leyendo %s: %v", *rsaPEM, err)
+		}
+		privada = llave
+	}
+
+	if *generar {
+		var jwk autenticacion.JWK
+		if llave, ok := privada.(*rsa.PrivateKey); ok {
+			jwk = autenticacion.JWKPublica(*kid, &llave.PublicKey)
+		} else {
+			secreto := make([]byte, 32)
+			rand.Read(secreto)
+			jwk = autenticacion.JWK{Kty: "oct", Kid: *kid, Alg: autenticacion.HS256, K: base64.RawURLEncoding.EncodeToString(secreto)}
+		}
+		salida, _ := json.MarshalIndent(autenticacion.JWKS{Keys: []autenticacion.JWK{jwk}}, "", "  ")
+		fmt.Println(string(salida))
+		return
+	}
+
+	if privada == nil {
+		if *jwks == "" {
+			log.Fatalf("Falta -jwks o -rsa para firmar")
+		}
+		secreto, err := leerSecreto(*jwks, *kid)
+		if err != nil {
+			log.Fatalf("Error leyendo %s: %v", *jwks, err)
+		}
+		privada = secreto
+	}
+	if *sub == "" {
+		log.Fatalf("Falta -sub")
+	}
+
+	ahora := time.Now()
+	reclamos := autenticacion.Reclamos{Sub: *sub, Iss: *iss, Iat: ahora.Unix(), Exp: ahora.Add(*ttl).Unix()}
+	if *aud != "" {
+		reclamos.Aud = json.RawMessage(strconv.Quote(*aud))
+	}
+	token, err := autenticacion.FirmarJWT(*kid, privada, reclamos)
+	if err != nil {
+		log.Fatalf("Error firmando el JWT: %v", err)
+	}
+	fmt.Println(token)
+}
+
+func leerRSA(ruta string) (*rsa.PrivateKey, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, err
+	}
+	bloque, _ := pem.Decode(datos)
+	if bloque == nil {
+		return nil, fmt.Errorf("no es PEM")
+	}
+	if llave, err := x509.ParsePKCS1PrivateKey(bloque.Bytes); err == nil {
+		return llave, nil
+	}
+	llave, err := x509.ParsePKCS8PrivateKey(bloque.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privada, ok := llave.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("no es una llave RSA")
+	}
+	return privada, nil
+}
+
+func leerSecreto(ruta, kid string) ([]byte, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, err
+	}
+	var jwks autenticacion.JWKS
+	if err := json.Unmarshal(datos, &jwks); err != nil {
+		return nil, err
+	}
+	for _, k := range jwks.Keys {
+		if k.Kid == kid && k.Kty == "oct" {
+			return base64.RawURLEncoding.DecodeString(k.K)
+		}
+	}
+	return nil, fmt.Errorf("no hay una llave oct con kid %q", kid)
+}
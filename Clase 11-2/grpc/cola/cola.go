@@ -0,0 +1,141 @@
+// Package cola desacopla el POST de Rust del envío a gRPC: el cliente REST
+// encola los datos y responde de inmediato, y un grupo de trabajadores los
+// saca de la cola y los manda al servidor. La cola puede ser un canal en
+// memoria o el topic de Kafka de la Clase 12.
+package cola
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrLlena se devuelve al encolar en una cola en memoria sin espacio
+	ErrLlena = errors.New("la cola está llena")
+	// ErrCerrada se devuelve al encolar después de Cerrar
+	ErrCerrada = errors.New("la cola está cerrada")
+)
+
+// Cola guarda mensajes (JSON) hasta que un trabajador los procesa
+type Cola interface {
+	// Encolar agrega un mensaje sin esperar a que se procese
+	Encolar(ctx context.Context, valor []byte) error
+	// Recibir espera el siguiente mensaje. Devuelve io.EOF cuando la cola
+	// está cerrada y no quedan mensajes para este proceso.
+	Recibir(ctx context.Context) (Mensaje, error)
+	// Cerrar deja de aceptar mensajes; los que ya se recibieron pueden
+	// terminarse después
+	Cerrar() error
+}
+
+// Descartador lo implementan las colas que guardan aparte los mensajes que
+// no se pudieron procesar (un dead-letter topic), para revisarlos o volver a
+// encolarlos a mano en lugar de perderlos
+type Descartador interface {
+	Descartar(ctx context.Context, valor []byte, motivo string) error
+}
+
+// Mensaje es un elemento recibido de la cola
+type Mensaje struct {
+	Valor []byte
+	// terminar avisa a la cola que el mensaje ya no está en proceso; con
+	// procesado en false la cola puede volver a entregarlo
+	terminar func(procesado bool)
+}
+
+// Terminar se llama una vez por mensaje, después de procesarlo o de
+// abandonarlo
+func (m Mensaje) Terminar(procesado bool) {
+	if m.terminar != nil {
+		m.terminar(procesado)
+	}
+}
+
+// Espera máxima para guardar un mensaje descartado
+const esperaDescarte = 10 * time.Second
+
+// Trabajadores son los que arranca Procesar
+type Trabajadores struct {
+	terminados  chan struct{}
+	descartados atomic.Int64
+}
+
+// Terminados se cierra cuando terminan todos los trabajadores
+func (t *Trabajadores) Terminados() <-chan struct{} {
+	return t.terminados
+}
+
+// Descartados es la cantidad de mensajes descartados desde que arrancaron
+func (t *Trabajadores) Descartados() int64 {
+	return t.descartados.Load()
+}
+
+// Procesar arranca trabajadores goroutines que reciben de c y llaman a
+// procesar con cada mensaje hasta que c se cierre o se cancele ctx. Si
+// procesar devuelve nil el mensaje está procesado; si devuelve un error
+// porque se canceló ctx, se abandona para que la cola lo vuelva a entregar;
+// con cualquier otro error se descarta: se cuenta, se registra y, si c es un
+// Descartador, se guarda aparte con el error como motivo.
+func Procesar(ctx context.Context, c Cola, trabajadores int, procesar func(ctx context.Context, valor []byte) error) *Trabajadores {
+	t := &Trabajadores{terminados: make(chan struct{})}
+	var wg sync.WaitGroup
+	for i := 0; i < max(trabajadores, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				m, err := c.Recibir(ctx)
+				if err != nil {
+					if ctx.Err() != nil || errors.Is(err, io.EOF) {
+						return
+					}
+					log.Printf("Error recibiendo de la cola: %v", err)
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(time.Second):
+					}
+					continue
+				}
+
+				err = procesar(ctx, m.Valor)
+				if err != nil && ctx.Err() != nil {
+					m.Terminar(false)
+					continue
+				}
+				if err != nil {
+					t.descartar(ctx, c, m.Valor, err.Error())
+				}
+				m.Terminar(true)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(t.terminados)
+	}()
+	return t
+}
+
+// descartar cuenta un mensaje que no se va a procesar y lo guarda aparte si
+// la cola puede
+func (t *Trabajadores) descartar(ctx context.Context, c Cola, valor []byte, motivo string) {
+	total := t.descartados.Add(1)
+	log.Printf("Mensaje de la cola descartado (%d en total): %s", total, motivo)
+	descartador, ok := c.(Descartador)
+	if !ok {
+		return
+	}
+	// Se guarda aunque el trabajador se esté deteniendo: el offset ya se va a confirmar
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), esperaDescarte)
+	defer cancel()
+	if err := descartador.Descartar(ctx, valor, motivo); err != nil {
+		log.Printf("Error guardando el mensaje descartado: %v", err)
+	}
+}
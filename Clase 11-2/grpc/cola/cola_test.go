@@ -0,0 +1,150 @@
+package cola
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoria(t *testing.T) {
+	ctx := context.Background()
+	m := NuevaMemoria(2)
+	for _, v := range []string{"a", "b"} {
+		if err := m.Encolar(ctx, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Encolar(ctx, []byte("c")); !errors.Is(err, ErrLlena) {
+		t.Fatalf("con la cola llena: err = %v", err)
+	}
+	if n := m.Pendientes(); n != 2 {
+		t.Fatalf("pendientes = %d", n)
+	}
+
+	// Cerrada no acepta más, pero entrega lo que tenía y después io.EOF
+	m.Cerrar()
+	m.Cerrar()
+	if err := m.Encolar(ctx, []byte("d")); !errors.Is(err, ErrCerrada) {
+		t.Fatalf("cerrada: err = %v", err)
+	}
+	for _, desea := range []string{"a", "b"} {
+		msg, err := m.Recibir(ctx)
+		if err != nil || string(msg.Valor) != desea {
+			t.Fatalf("se recibió %q, %v; se esperaba %q", msg.Valor, err, desea)
+		}
+		msg.Terminar(true)
+	}
+	if _, err := m.Recibir(ctx); err != io.EOF {
+		t.Fatalf("vacía y cerrada: err = %v", err)
+	}
+}
+
+func TestMemoriaRecibirCancelado(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NuevaMemoria(1).Recibir(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// colaPrueba es una cola en memoria que guarda los descartes y cómo terminó
+// cada mensaje
+type colaPrueba struct {
+	*Memoria
+
+	mu         sync.Mutex
+	descartes  []string
+	terminados map[string]bool
+}
+
+func nuevaColaPrueba(valores ...string) *colaPrueba {
+	c := &colaPrueba{Memoria: NuevaMemoria(len(valores) + 1), terminados: make(map[string]bool)}
+	for _, v := range valores {
+		c.Encolar(context.Background(), []byte(v))
+	}
+	return c
+}
+
+func (c *colaPrueba) Recibir(ctx context.Context) (Mensaje, error) {
+	m, err := c.Memoria.Recibir(ctx)
+	if err != nil {
+		return m, err
+	}
+	m.terminar = func(procesado bool) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.terminados[string(m.Valor)] = procesado
+	}
+	return m, nil
+}
+
+func (c *colaPrueba) Descartar(ctx context.Context, valor []byte, motivo string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.descartes = append(c.descartes, string(valor)+": "+motivo)
+	return nil
+}
+
+func TestProcesarDrenaYDescarta(t *testing.T) {
+	c := nuevaColaPrueba("bien-1", "mal", "bien-2", "bien-3")
+	c.Cerrar()
+
+	var mu sync.Mutex
+	var enviados []string
+	trabajadores := Procesar(context.Background(), c, 3, func(ctx context.Context, valor []byte) error {
+		if string(valor) == "mal" {
+			return errors.New("rechazado por el servidor")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		enviados = append(enviados, string(valor))
+		return nil
+	})
+
+	select {
+	case <-trabajadores.Terminados():
+	case <-time.After(2 * time.Second):
+		t.Fatal("los trabajadores deberían terminar al vaciar la cola cerrada")
+	}
+	slices.Sort(enviados)
+	if !slices.Equal(enviados, []string{"bien-1", "bien-2", "bien-3"}) {
+		t.Fatalf("enviados %v", enviados)
+	}
+	if trabajadores.Descartados() != 1 || !slices.Equal(c.descartes, []string{"mal: rechazado por el servidor"}) {
+		t.Fatalf("descartados %d: %v", trabajadores.Descartados(), c.descartes)
+	}
+	// Descartado también se confirma: no se vuelve a entregar
+	for valor, procesado := range c.terminados {
+		if !procesado {
+			t.Errorf("%s debería terminar como procesado", valor)
+		}
+	}
+	if len(c.terminados) != 4 {
+		t.Fatalf("terminaron %d mensajes", len(c.terminados))
+	}
+}
+
+func TestProcesarAbandonaAlCancelar(t *testing.T) {
+	c := nuevaColaPrueba("lento")
+	ctx, cancel := context.WithCancel(context.Background())
+	empezo := make(chan struct{})
+	trabajadores := Procesar(ctx, c, 1, func(ctx context.Context, valor []byte) error {
+		close(empezo)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-empezo
+	cancel()
+	<-trabajadores.Terminados()
+
+	if procesado, ok := c.terminados["lento"]; !ok || procesado {
+		t.Fatalf("el mensaje en proceso debería abandonarse: terminado = %v, procesado = %v", ok, procesado)
+	}
+	if trabajadores.Descartados() != 0 || len(c.descartes) != 0 {
+		t.Fatalf("un mensaje abandonado no se descarta: %v", c.descartes)
+	}
+}
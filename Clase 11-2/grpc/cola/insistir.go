@@ -0,0 +1,74 @@
+package cola
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrCircuitoAbierto lo usa Insistir cuando Permitir no deja llamar
+var ErrCircuitoAbierto = errors.New("circuito abierto")
+
+// Insistencia dice cómo reintentar el envío de un mensaje de la cola
+type Insistencia struct {
+	// MaxIntentos es la cantidad de envíos fallidos antes de descartar el
+	// mensaje, para que uno que siempre da error no ocupe un trabajador para
+	// siempre
+	MaxIntentos int
+	// Espera antes del primer reintento; se duplica en cada uno hasta EsperaMaxima
+	Espera       time.Duration
+	EsperaMaxima time.Duration
+	// Reintentable indica si el error es del servidor y vale la pena
+	// reintentar; si no, el servidor rechazó los datos y se descarta
+	Reintentable func(err error) bool
+	// Permitir consulta el circuito antes de cada envío (nil = siempre se
+	// puede). La espera con el circuito abierto no cuenta como intento
+	// porque no se llamó al servidor.
+	Permitir func() (terminar func(err error), espera time.Duration, ok bool)
+}
+
+// Insistir llama a enviar hasta que funcione, con espera exponencial entre
+// intentos. Devuelve nil si se envió, el error de ctx si se canceló antes, y
+// si no el motivo para descartar el mensaje.
+func Insistir(ctx context.Context, in Insistencia, enviar func(ctx context.Context) error) error {
+	espera := in.Espera
+	intentos := 0
+	for {
+		err := ErrCircuitoAbierto
+		if terminar, falta, ok := in.permitir(); ok {
+			intentos++
+			err = enviar(ctx)
+			terminar(err)
+		} else {
+			espera = max(espera, falta)
+		}
+
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != ErrCircuitoAbierto && !in.Reintentable(err):
+			return fmt.Errorf("rechazado por el servidor: %w", err)
+		case err != ErrCircuitoAbierto && intentos >= in.MaxIntentos:
+			return fmt.Errorf("%d intentos fallidos, el último: %w", intentos, err)
+		}
+
+		log.Printf("Error al enviar un mensaje de la cola, reintentando en %s: %v", espera.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(espera):
+		}
+		espera = min(2*espera, in.EsperaMaxima)
+	}
+}
+
+func (in Insistencia) permitir() (terminar func(err error), espera time.Duration, ok bool) {
+	if in.Permitir == nil {
+		return func(error) {}, 0, true
+	}
+	return in.Permitir()
+}
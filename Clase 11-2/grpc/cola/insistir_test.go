@@ -0,0 +1,90 @@
+package cola
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInsistir(t *testing.T) {
+	errServidor := errors.New("servidor caído")
+	errDatos := errors.New("clima inválido")
+	in := Insistencia{
+		MaxIntentos:  3,
+		Espera:       time.Millisecond,
+		EsperaMaxima: 2 * time.Millisecond,
+		Reintentable: func(err error) bool { return err == errServidor },
+	}
+	casos := []struct {
+		nombre   string
+		errores  []error // resultado de cada intento; después de la lista, nil
+		intentos int
+		descarte string
+	}{
+		{"a la primera", nil, 1, ""},
+		{"después de fallar", []error{errServidor, errServidor}, 3, ""},
+		{"datos rechazados", []error{errDatos}, 1, "rechazado por el servidor: clima inválido"},
+		{"intentos agotados", []error{errServidor, errServidor, errServidor, errServidor}, 3, "3 intentos fallidos, el último: servidor caído"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			intentos := 0
+			err := Insistir(context.Background(), in, func(ctx context.Context) error {
+				intentos++
+				if intentos <= len(caso.errores) {
+					return caso.errores[intentos-1]
+				}
+				return nil
+			})
+			if intentos != caso.intentos {
+				t.Errorf("%d intentos, se esperaban %d", intentos, caso.intentos)
+			}
+			if got := fmt.Sprint(err); (err == nil) != (caso.descarte == "") || (err != nil && got != caso.descarte) {
+				t.Errorf("err = %v, se esperaba %q", err, caso.descarte)
+			}
+		})
+	}
+}
+
+func TestInsistirConCircuito(t *testing.T) {
+	// El circuito está abierto las dos primeras veces: esas esperas no
+	// cuentan como intentos
+	consultas := 0
+	var resultados []error
+	in := Insistencia{
+		MaxIntentos:  1,
+		Espera:       time.Millisecond,
+		EsperaMaxima: time.Millisecond,
+		Reintentable: func(error) bool { return true },
+		Permitir: func() (func(error), time.Duration, bool) {
+			consultas++
+			if consultas <= 2 {
+				return nil, time.Millisecond, false
+			}
+			return func(err error) { resultados = append(resultados, err) }, 0, true
+		},
+	}
+	intentos := 0
+	err := Insistir(context.Background(), in, func(ctx context.Context) error {
+		intentos++
+		return nil
+	})
+	if err != nil || intentos != 1 || consultas != 3 || len(resultados) != 1 || resultados[0] != nil {
+		t.Fatalf("err = %v, %d intentos, %d consultas, resultados %v", err, intentos, consultas, resultados)
+	}
+}
+
+func TestInsistirCancelado(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := Insistencia{MaxIntentos: 100, Espera: time.Hour, EsperaMaxima: time.Hour, Reintentable: func(error) bool { return true }}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := Insistir(ctx, in, func(ctx context.Context) error { return errors.New("servidor caído") })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, se esperaba el de ctx", err)
+	}
+}
@@ -0,0 +1,216 @@
+package cola
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka es una cola sobre un topic de Kafka, el mismo que usa el productor
+// de la Clase 12. Los mensajes sobreviven a un reinicio del cliente: el
+// offset se confirma recién cuando el trabajador terminó con el mensaje.
+//
+// Kafka guarda un solo offset por partición y los trabajadores terminan en
+// cualquier orden, así que solo se confirma hasta el último mensaje de la
+// partición que terminó junto con todos los anteriores. Un mensaje en
+// proceso o abandonado frena la confirmación de los siguientes: al volver a
+// conectar se entregan de nuevo, incluidos los que ya se habían enviado.
+type Kafka struct {
+	escritor *kafka.Writer
+	lector   *kafka.Reader
+	// descartes escribe en el topic de descartados; nil si no hay
+	descartes *kafka.Writer
+
+	// recibir se cancela al cerrar para cortar los FetchMessage en curso
+	recibir  context.Context
+	cancelar context.CancelFunc
+
+	mu             sync.Mutex
+	cerrada        bool
+	confirmaciones *confirmaciones
+	enProceso      sync.WaitGroup
+	cerrarUna      sync.Once
+
+	// confirmando ordena los CommitMessages: uno más viejo que llega tarde
+	// no puede pisar el offset de uno más nuevo
+	confirmando sync.Mutex
+	confirmados map[int]int64
+}
+
+// NuevaKafka conecta con brokers. grupo es el consumer group de los
+// trabajadores: con uno distinto al de otros consumidores del topic, cada
+// grupo recibe todos los mensajes. Los mensajes descartados se escriben en
+// topicDescartados (vacío = solo se descartan).
+func NuevaKafka(brokers []string, topic, grupo, topicDescartados string) *Kafka {
+	recibir, cancelar := context.WithCancel(context.Background())
+	k := &Kafka{
+		escritor: escritorKafka(brokers, topic),
+		lector: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			Topic:   topic,
+			GroupID: grupo,
+		}),
+		recibir:        recibir,
+		cancelar:       cancelar,
+		confirmaciones: nuevasConfirmaciones(),
+		confirmados:    make(map[int]int64),
+	}
+	if topicDescartados != "" {
+		k.descartes = escritorKafka(brokers, topicDescartados)
+	}
+	return k
+}
+
+func escritorKafka(brokers []string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Topic:                  topic,
+		RequiredAcks:           kafka.RequireOne,
+		AllowAutoTopicCreation: true,
+		// Por defecto espera hasta 1s para juntar un lote y el POST
+		// quedaría esperando ese segundo
+		BatchTimeout: 10 * time.Millisecond,
+	}
+}
+
+// Encolar espera a que el broker guarde el mensaje
+func (k *Kafka) Encolar(ctx context.Context, valor []byte) error {
+	k.mu.Lock()
+	cerrada := k.cerrada
+	k.mu.Unlock()
+	if cerrada {
+		return ErrCerrada
+	}
+	return k.escritor.WriteMessages(ctx, kafka.Message{Value: valor})
+}
+
+func (k *Kafka) Recibir(ctx context.Context) (Mensaje, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	detener := context.AfterFunc(k.recibir, cancel)
+	defer detener()
+
+	m, err := k.lector.FetchMessage(ctx)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.cerrada {
+		// Sin confirmar: se entrega de nuevo al volver a conectar
+		return Mensaje{}, io.EOF
+	}
+	if err != nil {
+		return Mensaje{}, err
+	}
+
+	k.enProceso.Add(1)
+	k.confirmaciones.recibido(m.Partition, m.Offset)
+	return Mensaje{Valor: m.Value, terminar: func(procesado bool) {
+		defer k.enProceso.Done()
+		k.mu.Lock()
+		hasta, ok := k.confirmaciones.terminado(m.Partition, m.Offset, procesado)
+		k.mu.Unlock()
+		if ok {
+			k.confirmar(m.Topic, m.Partition, hasta)
+		}
+	}}, nil
+}
+
+// confirmar guarda en Kafka que la partición se procesó hasta offset
+func (k *Kafka) confirmar(topic string, particion int, offset int64) {
+	k.confirmando.Lock()
+	defer k.confirmando.Unlock()
+	if anterior, ok := k.confirmados[particion]; ok && anterior >= offset {
+		return
+	}
+	m := kafka.Message{Topic: topic, Partition: particion, Offset: offset}
+	if err := k.lector.CommitMessages(context.Background(), m); err != nil {
+		log.Printf("Error confirmando el offset %d de la partición %d de Kafka: %v", offset, particion, err)
+		return
+	}
+	k.confirmados[particion] = offset
+}
+
+// confirmaciones sigue los mensajes entregados de cada partición, en el
+// orden de sus offsets, hasta que se pueden confirmar
+type confirmaciones struct {
+	particiones map[int][]entregado
+}
+
+type entregado struct {
+	offset    int64
+	procesado bool
+}
+
+func nuevasConfirmaciones() *confirmaciones {
+	return &confirmaciones{particiones: make(map[int][]entregado)}
+}
+
+// recibido registra un mensaje entregado a un trabajador. Kafka entrega los
+// mensajes de cada partición en orden.
+func (c *confirmaciones) recibido(particion int, offset int64) {
+	c.particiones[particion] = append(c.particiones[particion], entregado{offset: offset})
+}
+
+// terminado marca el mensaje y devuelve hasta qué offset se puede confirmar
+// la partición: el último de los primeros mensajes ya procesados. Un mensaje
+// abandonado queda primero para siempre: ni él ni los siguientes se
+// confirman y Kafka los vuelve a entregar después de reiniciar.
+func (c *confirmaciones) terminado(particion int, offset int64, procesado bool) (hasta int64, ok bool) {
+	entregados := c.particiones[particion]
+	for i := range entregados {
+		if entregados[i].offset == offset {
+			entregados[i].procesado = procesado
+			break
+		}
+	}
+	listos := 0
+	for listos < len(entregados) && entregados[listos].procesado {
+		listos++
+	}
+	if listos == 0 {
+		return 0, false
+	}
+	hasta = entregados[listos-1].offset
+	c.particiones[particion] = entregados[listos:]
+	return hasta, true
+}
+
+// Descartar escribe el mensaje en el topic de descartados con el motivo en
+// el header "motivo". No hace nada si no hay topic de descartados.
+func (k *Kafka) Descartar(ctx context.Context, valor []byte, motivo string) error {
+	if k.descartes == nil {
+		return nil
+	}
+	return k.descartes.WriteMessages(ctx, kafka.Message{
+		Value:   valor,
+		Headers: []kafka.Header{{Key: "motivo", Value: []byte(motivo)}},
+	})
+}
+
+// Cerrar deja de encolar y de recibir, espera a que terminen los mensajes
+// en proceso para confirmar sus offsets y cierra las conexiones
+func (k *Kafka) Cerrar() error {
+	var err error
+	k.cerrarUna.Do(func() {
+		k.mu.Lock()
+		k.cerrada = true
+		k.cancelar()
+		k.mu.Unlock()
+
+		err = k.escritor.Close()
+		// Los trabajadores pueden seguir descartando hasta terminar
+		k.enProceso.Wait()
+		if k.descartes != nil {
+			if errDescartes := k.descartes.Close(); err == nil {
+				err = errDescartes
+			}
+		}
+		if errLector := k.lector.Close(); err == nil {
+			err = errLector
+		}
+	})
+	return err
+}
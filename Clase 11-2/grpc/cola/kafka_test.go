@@ -0,0 +1,58 @@
+package cola
+
+import "testing"
+
+func TestConfirmacionesEnOrden(t *testing.T) {
+	c := nuevasConfirmaciones()
+	for offset := int64(10); offset < 15; offset++ {
+		c.recibido(0, offset)
+	}
+	c.recibido(1, 7)
+
+	// Terminan fuera de orden: solo se confirma el prefijo ya procesado
+	pasos := []struct {
+		particion int
+		offset    int64
+		procesado bool
+		hasta     int64
+		ok        bool
+	}{
+		{0, 12, true, 0, false},
+		{0, 11, true, 0, false},
+		{1, 7, true, 7, true},
+		{0, 10, true, 12, true},
+		{0, 14, true, 0, false},
+		{0, 13, true, 14, true},
+	}
+	for _, p := range pasos {
+		hasta, ok := c.terminado(p.particion, p.offset, p.procesado)
+		if hasta != p.hasta || ok != p.ok {
+			t.Fatalf("terminado(%d, %d) = %d, %v; se esperaba %d, %v", p.particion, p.offset, hasta, ok, p.hasta, p.ok)
+		}
+	}
+	for particion, entregados := range c.particiones {
+		if len(entregados) != 0 {
+			t.Errorf("la partición %d todavía sigue %d mensajes", particion, len(entregados))
+		}
+	}
+}
+
+func TestConfirmacionesAbandonado(t *testing.T) {
+	c := nuevasConfirmaciones()
+	for offset := int64(0); offset < 4; offset++ {
+		c.recibido(0, offset)
+	}
+	if hasta, ok := c.terminado(0, 0, true); !ok || hasta != 0 {
+		t.Fatalf("el primero se confirma solo: %d, %v", hasta, ok)
+	}
+	// El 1 se abandona al apagar: ni él ni los siguientes se confirman, así
+	// Kafka lo vuelve a entregar al reiniciar
+	if _, ok := c.terminado(0, 1, false); ok {
+		t.Fatal("un mensaje abandonado no se confirma")
+	}
+	for offset := int64(2); offset < 4; offset++ {
+		if hasta, ok := c.terminado(0, offset, true); ok {
+			t.Fatalf("se confirmó hasta %d pasando por encima del abandonado", hasta)
+		}
+	}
+}
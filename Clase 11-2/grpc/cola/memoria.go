@@ -0,0 +1,67 @@
+package cola
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Memoria es una cola con capacidad fija en un canal. Encolar nunca espera:
+// si está llena devuelve ErrLlena para que el cliente responda 503 en lugar
+// de acumular memoria. Los mensajes que queden al salir del proceso se
+// pierden.
+type Memoria struct {
+	mensajes chan []byte
+
+	mu      sync.RWMutex
+	cerrada bool
+}
+
+// NuevaMemoria crea una cola en memoria para capacidad mensajes
+func NuevaMemoria(capacidad int) *Memoria {
+	return &Memoria{mensajes: make(chan []byte, max(capacidad, 1))}
+}
+
+func (m *Memoria) Encolar(ctx context.Context, valor []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cerrada {
+		return ErrCerrada
+	}
+	select {
+	case m.mensajes <- valor:
+		return nil
+	default:
+		return ErrLlena
+	}
+}
+
+// Recibir devuelve io.EOF cuando la cola está cerrada y vacía
+func (m *Memoria) Recibir(ctx context.Context) (Mensaje, error) {
+	select {
+	case <-ctx.Done():
+		return Mensaje{}, ctx.Err()
+	case valor, ok := <-m.mensajes:
+		if !ok {
+			return Mensaje{}, io.EOF
+		}
+		return Mensaje{Valor: valor}, nil
+	}
+}
+
+// Pendientes es la cantidad de mensajes que esperan un trabajador
+func (m *Memoria) Pendientes() int {
+	return len(m.mensajes)
+}
+
+// Cerrar deja de aceptar mensajes; los que ya están en la cola se siguen
+// entregando
+func (m *Memoria) Cerrar() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.cerrada {
+		m.cerrada = true
+		close(m.mensajes)
+	}
+	return nil
+}
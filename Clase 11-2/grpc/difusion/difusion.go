@@ -0,0 +1,152 @@
+// Package difusion reparte los tweets guardados entre los suscriptores de
+// SubscribeTweets. Cada suscriptor tiene su propio buffer: Publicar nunca se
+// bloquea, si el buffer está lleno el tweet se descarta para ese suscriptor y,
+// si acumula demasiados descartes seguidos, se lo expulsa para que un cliente
+// lento no se quede con memoria del servidor.
+package difusion
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"grpc/repositorio"
+)
+
+// Filtro de una suscripción; una lista vacía no filtra y la comparación no
+// distingue mayúsculas
+type Filtro struct {
+	Countries []string
+	Weathers  []string
+}
+
+func (f Filtro) coincide(t repositorio.Tweet) bool {
+	return contiene(f.Countries, t.Country) && contiene(f.Weathers, t.Weather)
+}
+
+func contiene(lista []string, valor string) bool {
+	if len(lista) == 0 {
+		return true
+	}
+	for _, v := range lista {
+		if strings.EqualFold(v, valor) {
+			return true
+		}
+	}
+	return false
+}
+
+// Suscripcion recibe los tweets que coinciden con su filtro
+type Suscripcion struct {
+	filtro    Filtro
+	tweets    chan repositorio.Tweet
+	expulsada chan struct{}
+	perdidos  atomic.Uint64
+	// descartes sin que el suscriptor haya leído nada; lo protege el mutex del Difusor
+	seguidos int
+
+	difusor *Difusor
+}
+
+// Tweets entrega los tweets en el orden en que se guardaron
+func (s *Suscripcion) Tweets() <-chan repositorio.Tweet {
+	return s.tweets
+}
+
+// Expulsada se cierra cuando el Difusor quita la suscripción por lenta
+func (s *Suscripcion) Expulsada() <-chan struct{} {
+	return s.expulsada
+}
+
+// Perdidos es la cantidad de tweets descartados por tener el buffer lleno
+func (s *Suscripcion) Perdidos() uint64 {
+	return s.perdidos.Load()
+}
+
+// Cancelar quita la suscripción; se puede llamar más de una vez
+func (s *Suscripcion) Cancelar() {
+	s.difusor.mu.Lock()
+	defer s.difusor.mu.Unlock()
+	delete(s.difusor.suscripciones, s)
+}
+
+// Difusor mantiene las suscripciones activas
+type Difusor struct {
+	buffer      int
+	maxPerdidos int
+
+	mu            sync.Mutex
+	suscripciones map[*Suscripcion]struct{}
+
+	cerrado      chan struct{}
+	cerrarUnaVez sync.Once
+}
+
+// Nuevo crea un difusor con buffer tweets por suscriptor. Un suscriptor que
+// pierde maxPerdidos tweets seguidos (con el buffer lleno todo ese tiempo) se
+// expulsa.
+func Nuevo(buffer, maxPerdidos int) *Difusor {
+	return &Difusor{
+		buffer:        max(buffer, 1),
+		maxPerdidos:   max(maxPerdidos, 1),
+		suscripciones: make(map[*Suscripcion]struct{}),
+		cerrado:       make(chan struct{}),
+	}
+}
+
+// Cerrar avisa a los suscriptores que el servidor se apaga (por Cerrado),
+// para que sus streams terminen y GracefulStop no los espere para siempre
+func (d *Difusor) Cerrar() {
+	d.cerrarUnaVez.Do(func() { close(d.cerrado) })
+}
+
+// Cerrado se cierra al llamar a Cerrar
+func (d *Difusor) Cerrado() <-chan struct{} {
+	return d.cerrado
+}
+
+// Suscribir empieza a recibir los tweets publicados desde ahora que
+// coinciden con f; hay que llamar a Cancelar al terminar
+func (d *Difusor) Suscribir(f Filtro) *Suscripcion {
+	s := &Suscripcion{
+		filtro:    f,
+		tweets:    make(chan repositorio.Tweet, d.buffer),
+		expulsada: make(chan struct{}),
+		difusor:   d,
+	}
+	d.mu.Lock()
+	d.suscripciones[s] = struct{}{}
+	d.mu.Unlock()
+	return s
+}
+
+// Suscriptores devuelve la cantidad de suscripciones activas
+func (d *Difusor) Suscriptores() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.suscripciones)
+}
+
+// Publicar entrega t a las suscripciones cuyo filtro coincide, sin esperar
+// a ninguna
+func (d *Difusor) Publicar(t repositorio.Tweet) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for s := range d.suscripciones {
+		if !s.filtro.coincide(t) {
+			continue
+		}
+		select {
+		case s.tweets <- t:
+			s.seguidos = 0
+		default:
+			s.perdidos.Add(1)
+			s.seguidos++
+			if s.seguidos >= d.maxPerdidos {
+				delete(d.suscripciones, s)
+				close(s.expulsada)
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package difusion
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"grpc/repositorio"
+)
+
+func tweet(texto, pais, clima string) repositorio.Tweet {
+	return repositorio.Tweet{Description: texto, Country: pais, Weather: clima}
+}
+
+// recibidos saca sin esperar lo que haya en el buffer de la suscripción
+func recibidos(s *Suscripcion) []string {
+	var textos []string
+	for {
+		select {
+		case t := <-s.Tweets():
+			textos = append(textos, t.Description)
+		default:
+			return textos
+		}
+	}
+}
+
+func expulsada(s *Suscripcion) bool {
+	select {
+	case <-s.Expulsada():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPublicarFiltra(t *testing.T) {
+	d := Nuevo(10, 10)
+	todos := d.Suscribir(Filtro{})
+	guatemala := d.Suscribir(Filtro{Countries: []string{"Guatemala"}})
+	lluvia := d.Suscribir(Filtro{Countries: []string{"guatemala", "México"}, Weathers: []string{"LLUVIOSO"}})
+
+	d.Publicar(tweet("1", "GUATEMALA", "Soleado"))
+	d.Publicar(tweet("2", "México", "Lluvioso"))
+	d.Publicar(tweet("3", "Guatemala", "lluvioso"))
+
+	casos := []struct {
+		nombre string
+		s      *Suscripcion
+		desea  []string
+	}{
+		{"sin filtro", todos, []string{"1", "2", "3"}},
+		{"por país", guatemala, []string{"1", "3"}},
+		{"país y clima", lluvia, []string{"2", "3"}},
+	}
+	for _, caso := range casos {
+		if got := recibidos(caso.s); !slices.Equal(got, caso.desea) {
+			t.Errorf("%s: recibió %v; se esperaba %v", caso.nombre, got, caso.desea)
+		}
+	}
+}
+
+func TestPublicarConBufferLleno(t *testing.T) {
+	d := Nuevo(2, 3)
+	lento := d.Suscribir(Filtro{})
+	rapido := d.Suscribir(Filtro{})
+
+	// Publicar no espera al lento: con su buffer lleno se descarta para él
+	for _, texto := range []string{"a", "b", "c", "d"} {
+		d.Publicar(tweet(texto, "Guatemala", "Soleado"))
+		recibidos(rapido)
+	}
+	if lento.Perdidos() != 2 || rapido.Perdidos() != 0 {
+		t.Fatalf("perdidos: lento %d, rápido %d", lento.Perdidos(), rapido.Perdidos())
+	}
+	if got := recibidos(lento); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("el lento recibió %v", got)
+	}
+	if expulsada(lento) {
+		t.Fatal("dos descartes seguidos no llegan a maxPerdidos")
+	}
+
+	// Leer reinicia la cuenta de descartes seguidos: "e" y "f" entran y se
+	// pierden "g" y "h", así que nunca hay tres seguidos
+	for _, texto := range []string{"e", "f", "g", "h"} {
+		d.Publicar(tweet(texto, "Guatemala", "Soleado"))
+	}
+	if expulsada(lento) || lento.Perdidos() != 4 {
+		t.Fatalf("expulsada %v, perdidos %d", expulsada(lento), lento.Perdidos())
+	}
+}
+
+func TestExpulsarAlLento(t *testing.T) {
+	d := Nuevo(1, 3)
+	lento := d.Suscribir(Filtro{})
+	otro := d.Suscribir(Filtro{Countries: []string{"México"}})
+
+	d.Publicar(tweet("lleno", "Guatemala", "Soleado"))
+	for i := 0; i < 2; i++ {
+		d.Publicar(tweet("perdido", "Guatemala", "Soleado"))
+	}
+	if expulsada(lento) {
+		t.Fatal("se expulsó antes de maxPerdidos descartes seguidos")
+	}
+	d.Publicar(tweet("perdido", "Guatemala", "Soleado"))
+	if !expulsada(lento) {
+		t.Fatal("con maxPerdidos descartes seguidos debería expulsarse")
+	}
+	if n := d.Suscriptores(); n != 1 {
+		t.Fatalf("quedan %d suscriptores", n)
+	}
+
+	// Ya no recibe ni cuenta más descartes, y el otro sigue igual
+	d.Publicar(tweet("después", "Guatemala", "Soleado"))
+	if lento.Perdidos() != 3 {
+		t.Fatalf("perdidos después de expulsar: %d", lento.Perdidos())
+	}
+	if expulsada(otro) || otro.Perdidos() != 0 {
+		t.Fatal("un tweet que no coincide con el filtro no cuenta como descarte")
+	}
+	// Cancelar después de la expulsión no falla
+	lento.Cancelar()
+}
+
+func TestCancelar(t *testing.T) {
+	d := Nuevo(1, 1)
+	s := d.Suscribir(Filtro{})
+	s.Cancelar()
+	s.Cancelar()
+	if n := d.Suscriptores(); n != 0 {
+		t.Fatalf("quedan %d suscriptores", n)
+	}
+	d.Publicar(tweet("a", "Guatemala", "Soleado"))
+	if len(recibidos(s)) != 0 || expulsada(s) {
+		t.Fatal("una suscripción cancelada no recibe nada")
+	}
+}
+
+// TestCerrarTerminaStreams recorre las suscripciones igual que
+// SubscribeTweets: al cerrar el difusor todos los streams terminan, también
+// los que tienen tweets pendientes en el buffer
+func TestCerrarTerminaStreams(t *testing.T) {
+	d := Nuevo(4, 10)
+	var wg sync.WaitGroup
+	listos := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		s := d.Suscribir(Filtro{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.Cancelar()
+			listos <- struct{}{}
+			for {
+				select {
+				case <-d.Cerrado():
+					return
+				case <-s.Expulsada():
+					t.Error("no debería expulsarse")
+					return
+				case <-s.Tweets():
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		<-listos
+	}
+	d.Publicar(tweet("a", "Guatemala", "Soleado"))
+
+	d.Cerrar()
+	d.Cerrar()
+	terminados := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(terminados)
+	}()
+	select {
+	case <-terminados:
+	case <-time.After(2 * time.Second):
+		t.Fatal("los streams deberían terminar al cerrar el difusor")
+	}
+	if n := d.Suscriptores(); n != 0 {
+		t.Fatalf("quedan %d suscriptores", n)
+	}
+}
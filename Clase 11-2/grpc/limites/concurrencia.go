@@ -0,0 +1,113 @@
+package limites
+
+import (
+	"sync"
+	"time"
+)
+
+// Parámetros del límite adaptativo
+const (
+	// Las llamadas son lentas si la latencia reciente pasa de tolerancia
+	// veces la latencia de largo plazo
+	tolerancia = 2.0
+	// Al detectar sobrecarga el límite se multiplica por recorte
+	recorte = 0.9
+	// Peso de cada llamada en la latencia reciente (unas 10 llamadas) y en la
+	// de largo plazo (unas 500): promediar evita que la variación normal de
+	// una llamada a otra cuente como sobrecarga, y la de largo plazo sigue de
+	// a poco los cambios permanentes (por ejemplo SQLite que crece)
+	pesoReciente = 0.1
+	pesoLargo    = 0.002
+	// Llamadas que se miden antes de empezar a ajustar el límite
+	muestrasMinimas = 20
+	// Espera sugerida mínima a los clientes rechazados
+	esperaMinima = 50 * time.Millisecond
+)
+
+// Concurrencia limita las llamadas en curso con AIMD: el límite crece de a
+// uno (cada límite llamadas terminadas a tiempo, con el límite casi usado) y
+// se recorta un 10% cuando la latencia reciente es el doble que la de largo
+// plazo o las llamadas fallan por sobrecarga, como mucho una vez por latencia
+// de largo plazo
+type Concurrencia struct {
+	min, max float64
+
+	mu               sync.Mutex
+	limite           float64
+	enCurso          int
+	muestras         int
+	latenciaReciente float64
+	latenciaLarga    float64
+	ultimoRecorte    time.Time
+}
+
+// NuevaConcurrencia crea el límite entre minimo y maximo, empezando en inicial
+func NuevaConcurrencia(minimo, maximo, inicial int) *Concurrencia {
+	minimo = max(minimo, 1)
+	maximo = max(maximo, minimo)
+	return &Concurrencia{
+		min:    float64(minimo),
+		max:    float64(maximo),
+		limite: float64(clamp(inicial, minimo, maximo)),
+	}
+}
+
+func clamp(v, minimo, maximo int) int {
+	return min(max(v, minimo), maximo)
+}
+
+// Adquirir reserva un lugar. Si no hay, devuelve ok en false y la espera
+// sugerida; si hay, terminar se llama al terminar la llamada con sobrecarga
+// en true si falló por falta de recursos (por ejemplo DeadlineExceeded).
+func (c *Concurrencia) Adquirir() (terminar func(sobrecarga bool), espera time.Duration, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.enCurso >= int(c.limite) {
+		return nil, max(time.Duration(c.latenciaLarga), esperaMinima), false
+	}
+	c.enCurso++
+	inicio := time.Now()
+	return func(sobrecarga bool) {
+		ahora := time.Now()
+		c.registrar(ahora, ahora.Sub(inicio), sobrecarga)
+	}, 0, true
+}
+
+// registrar libera el lugar de una llamada que tardó latencia y ajusta el límite
+func (c *Concurrencia) registrar(ahora time.Time, latencia time.Duration, sobrecarga bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usado := float64(c.enCurso) >= c.limite/2
+	c.enCurso--
+
+	if c.muestras == 0 {
+		c.latenciaReciente, c.latenciaLarga = float64(latencia), float64(latencia)
+	} else {
+		c.latenciaReciente += pesoReciente * (float64(latencia) - c.latenciaReciente)
+		c.latenciaLarga += pesoLargo * (float64(latencia) - c.latenciaLarga)
+	}
+	c.muestras++
+	if c.muestras < muestrasMinimas {
+		return
+	}
+
+	lenta := c.latenciaReciente > tolerancia*c.latenciaLarga
+	switch {
+	case sobrecarga || lenta:
+		if ahora.Sub(c.ultimoRecorte) > time.Duration(tolerancia*c.latenciaLarga) {
+			c.limite = max(c.min, c.limite*recorte)
+			c.ultimoRecorte = ahora
+		}
+	case usado:
+		c.limite = min(c.max, c.limite+1/c.limite)
+	}
+}
+
+// Estado devuelve el límite actual, las llamadas en curso y la latencia de largo plazo
+func (c *Concurrencia) Estado() (limite, enCurso int, latenciaBase time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.limite), c.enCurso, time.Duration(c.latenciaLarga)
+}
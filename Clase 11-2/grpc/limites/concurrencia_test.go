@@ -0,0 +1,120 @@
+package limites
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// simulacion mantiene el límite lleno y termina las llamadas de a una con la
+// latencia que devuelve latencia(enCurso), avanzando un reloj propio
+type simulacion struct {
+	c     *Concurrencia
+	reloj time.Time
+}
+
+func (s *simulacion) llenar() {
+	for {
+		if _, _, ok := s.c.Adquirir(); !ok {
+			return
+		}
+	}
+}
+
+func (s *simulacion) correr(llamadas int, latencia func(enCurso int) time.Duration, sobrecarga bool) {
+	for i := 0; i < llamadas; i++ {
+		s.llenar()
+		_, enCurso, _ := s.c.Estado()
+		l := latencia(enCurso)
+		s.reloj = s.reloj.Add(l / time.Duration(max(enCurso, 1)))
+		s.c.registrar(s.reloj, l, sobrecarga)
+	}
+}
+
+func TestConcurrenciaLatenciaPareja(t *testing.T) {
+	// Llamadas de 1 a 3 ms: la variación normal no es sobrecarga
+	azar := rand.New(rand.NewPCG(1, 2))
+	s := &simulacion{c: NuevaConcurrencia(8, 256, 32), reloj: time.Now()}
+	s.correr(20000, func(int) time.Duration {
+		return time.Millisecond + time.Duration(azar.Int64N(int64(2*time.Millisecond)))
+	}, false)
+	limite, _, base := s.c.Estado()
+	if limite < 32 {
+		t.Fatalf("el límite bajó a %d con latencia pareja (base %s)", limite, base)
+	}
+	if limite < 64 {
+		t.Errorf("el límite debería crecer con latencia pareja: %d", limite)
+	}
+	if base < time.Millisecond || base > 3*time.Millisecond {
+		t.Errorf("latencia de largo plazo %s fuera de 1-3 ms", base)
+	}
+}
+
+func TestConcurrenciaNoRecortaPorPicosAislados(t *testing.T) {
+	// Una llamada de cada 50 tarda 10 veces más (por ejemplo un fsync de SQLite)
+	s := &simulacion{c: NuevaConcurrencia(8, 256, 32), reloj: time.Now()}
+	n := 0
+	s.correr(5000, func(int) time.Duration {
+		n++
+		if n%50 == 0 {
+			return 20 * time.Millisecond
+		}
+		return 2 * time.Millisecond
+	}, false)
+	if limite, _, _ := s.c.Estado(); limite < 32 {
+		t.Fatalf("picos aislados recortaron el límite a %d", limite)
+	}
+}
+
+func TestConcurrenciaRecortaAlSubirLaLatencia(t *testing.T) {
+	s := &simulacion{c: NuevaConcurrencia(8, 256, 100), reloj: time.Now()}
+	s.correr(2000, func(int) time.Duration { return 2 * time.Millisecond }, false)
+	antes, _, _ := s.c.Estado()
+
+	// El servidor se vuelve cinco veces más lento de golpe
+	s.correr(200, func(int) time.Duration { return 10 * time.Millisecond }, false)
+	despues, _, _ := s.c.Estado()
+	if despues >= antes*3/4 {
+		t.Fatalf("el límite debería bajar al subir la latencia: %d -> %d", antes, despues)
+	}
+
+	// Cuando se recupera, el límite vuelve a crecer
+	s.correr(20000, func(int) time.Duration { return 2 * time.Millisecond }, false)
+	if final, _, _ := s.c.Estado(); final <= despues {
+		t.Fatalf("el límite debería recuperarse: %d -> %d", despues, final)
+	}
+}
+
+func TestConcurrenciaRecortaPorSobrecarga(t *testing.T) {
+	s := &simulacion{c: NuevaConcurrencia(8, 256, 100), reloj: time.Now()}
+	s.correr(muestrasMinimas, func(int) time.Duration { return 2 * time.Millisecond }, false)
+	antes, _, _ := s.c.Estado()
+
+	// DeadlineExceeded con latencia normal también recorta, hasta el mínimo
+	s.correr(5000, func(int) time.Duration { return 2 * time.Millisecond }, true)
+	if limite, _, _ := s.c.Estado(); limite != 8 {
+		t.Fatalf("con sobrecarga continua el límite debería llegar al mínimo: %d -> %d", antes, limite)
+	}
+}
+
+func TestConcurrenciaRechazaSinLugar(t *testing.T) {
+	c := NuevaConcurrencia(2, 2, 2)
+	terminar, _, ok := c.Adquirir()
+	if !ok {
+		t.Fatal("el primer lugar debería estar libre")
+	}
+	if _, _, ok := c.Adquirir(); !ok {
+		t.Fatal("el segundo lugar debería estar libre")
+	}
+	_, espera, ok := c.Adquirir()
+	if ok {
+		t.Fatal("con el límite lleno debería rechazar")
+	}
+	if espera < esperaMinima {
+		t.Errorf("espera sugerida %s menor que %s", espera, esperaMinima)
+	}
+	terminar(false)
+	if _, _, ok := c.Adquirir(); !ok {
+		t.Fatal("al terminar una llamada se libera su lugar")
+	}
+}
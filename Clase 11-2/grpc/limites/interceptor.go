@@ -0,0 +1,179 @@
+package limites
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"grpc/autenticacion"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Los health checks de Kubernetes no se limitan
+const prefijoHealth = "/grpc.health.v1.Health/"
+
+// Cada cuánto se registra cuántas llamadas se rechazaron
+const intervaloResumen = 10 * time.Second
+
+// Limitador aplica la tasa por cliente y método y el límite de concurrencia.
+// Cualquiera de los dos puede ser nil.
+type Limitador struct {
+	tasa         *Tasa
+	concurrencia *Concurrencia
+
+	porTasa         atomic.Int64
+	porConcurrencia atomic.Int64
+}
+
+// NuevoLimitador crea el limitador y registra un resumen de los rechazos
+// cada 10 segundos mientras ctx no termine
+func NuevoLimitador(ctx context.Context, tasa *Tasa, concurrencia *Concurrencia) *Limitador {
+	l := &Limitador{tasa: tasa, concurrencia: concurrencia}
+	go l.resumir(ctx)
+	return l
+}
+
+func (l *Limitador) resumir(ctx context.Context) {
+	ticker := time.NewTicker(intervaloResumen)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			porTasa, porConcurrencia := l.porTasa.Swap(0), l.porConcurrencia.Swap(0)
+			if porTasa == 0 && porConcurrencia == 0 {
+				continue
+			}
+			if l.concurrencia != nil {
+				limite, enCurso, base := l.concurrencia.Estado()
+				log.Printf("Llamadas rechazadas: %d por tasa, %d por concurrencia (límite %d, en curso %d, latencia de largo plazo %s)",
+					porTasa, porConcurrencia, limite, enCurso, base.Round(time.Microsecond))
+			} else {
+				log.Printf("Llamadas rechazadas: %d por tasa", porTasa)
+			}
+		}
+	}
+}
+
+// cliente identifica a quien llama: el cliente autenticado o, sin
+// autenticación, la IP de origen
+func cliente(ctx context.Context) string {
+	if c := autenticacion.Identidad(ctx); c != nil {
+		return c.Nombre
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// rechazo arma el error RESOURCE_EXHAUSTED y el trailer con la espera
+// sugerida: retry-after en segundos (como en HTTP) y grpc-retry-pushback-ms,
+// que respeta la política de reintentos de los clientes gRPC
+func rechazo(espera time.Duration, mensaje string) (metadata.MD, error) {
+	trailer := metadata.Pairs(
+		"retry-after", strconv.Itoa(int(math.Ceil(espera.Seconds()))),
+		"grpc-retry-pushback-ms", strconv.FormatInt(espera.Milliseconds(), 10),
+	)
+	return trailer, status.Errorf(codes.ResourceExhausted, "%s; reintente en %s", mensaje, espera.Round(time.Millisecond))
+}
+
+// sobrecarga indica si el error de una llamada muestra que el servidor no da abasto
+func sobrecarga(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.ResourceExhausted, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
+// Unario rechaza las llamadas que superan la tasa del cliente o el límite de concurrencia
+func (l *Limitador) Unario() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, prefijoHealth) {
+			return handler(ctx, req)
+		}
+
+		if l.tasa != nil {
+			if espera := l.tasa.Tomar(cliente(ctx), info.FullMethod, time.Now()); espera > 0 {
+				l.porTasa.Add(1)
+				trailer, err := rechazo(espera, "límite de llamadas por segundo superado")
+				grpc.SetTrailer(ctx, trailer)
+				return nil, err
+			}
+		}
+
+		if l.concurrencia == nil {
+			return handler(ctx, req)
+		}
+		terminar, espera, ok := l.concurrencia.Adquirir()
+		if !ok {
+			l.porConcurrencia.Add(1)
+			trailer, err := rechazo(espera, "servidor sobrecargado")
+			grpc.SetTrailer(ctx, trailer)
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		terminar(sobrecarga(err))
+		return resp, err
+	}
+}
+
+// Stream limita la tasa de los streams: el primer mensaje se rechaza si no
+// hay token, igual que un RPC unario, y los siguientes esperan su token, así
+// un stream rápido se frena en lugar de cortarse. Los streams no ocupan
+// lugar en el límite de concurrencia porque pueden durar mucho.
+func (l *Limitador) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if l.tasa == nil || strings.HasPrefix(info.FullMethod, prefijoHealth) {
+			return handler(srv, ss)
+		}
+		return handler(srv, &streamLimitado{ServerStream: ss, limitador: l, cliente: cliente(ss.Context()), metodo: info.FullMethod})
+	}
+}
+
+type streamLimitado struct {
+	grpc.ServerStream
+	limitador *Limitador
+	cliente   string
+	metodo    string
+	recibidos int
+}
+
+func (s *streamLimitado) RecvMsg(m any) error {
+	// Se cobra el mensaje después de recibirlo para no gastar un token en el
+	// io.EOF del final
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.recibidos++
+
+	tasa := s.limitador.tasa
+	if s.recibidos > 1 {
+		if err := tasa.Esperar(s.Context(), s.cliente, s.metodo); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		return nil
+	}
+	if espera := tasa.Tomar(s.cliente, s.metodo, time.Now()); espera > 0 {
+		s.limitador.porTasa.Add(1)
+		trailer, err := rechazo(espera, "límite de llamadas por segundo superado")
+		s.SetTrailer(trailer)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package limites protege al servidor gRPC de más carga de la que puede
+// atender: una cubeta de tokens por cliente y método limita cuántas llamadas
+// por segundo hace cada uno, y un límite de concurrencia adaptativo rechaza
+// llamadas cuando la latencia empieza a subir. Las llamadas rechazadas
+// terminan con RESOURCE_EXHAUSTED y el trailer retry-after.
+package limites
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cada cuánto se borran las cubetas llenas que nadie usa
+const intervaloLimpieza = time.Minute
+
+// Cuota de un método: Tasa llamadas por segundo con ráfagas de hasta Rafaga
+type Cuota struct {
+	Tasa   float64
+	Rafaga float64
+}
+
+// LeerCuotas interpreta "SendTweet=50,ListTweets=5/20" (tasa o tasa/ráfaga;
+// sin ráfaga se usa el doble de la tasa)
+func LeerCuotas(texto string) (map[string]Cuota, error) {
+	cuotas := make(map[string]Cuota)
+	for _, parte := range strings.Split(texto, ",") {
+		parte = strings.TrimSpace(parte)
+		if parte == "" {
+			continue
+		}
+		metodo, valor, ok := strings.Cut(parte, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q no tiene el formato metodo=tasa[/rafaga]", parte)
+		}
+		tasaTexto, rafagaTexto, conRafaga := strings.Cut(valor, "/")
+		tasa, err := strconv.ParseFloat(tasaTexto, 64)
+		if err != nil || tasa <= 0 {
+			return nil, fmt.Errorf("tasa inválida en %q", parte)
+		}
+		c := Cuota{Tasa: tasa, Rafaga: 2 * tasa}
+		if conRafaga {
+			if c.Rafaga, err = strconv.ParseFloat(rafagaTexto, 64); err != nil || c.Rafaga < 1 {
+				return nil, fmt.Errorf("ráfaga inválida en %q", parte)
+			}
+		}
+		cuotas[strings.TrimSpace(metodo)] = c
+	}
+	return cuotas, nil
+}
+
+type cubeta struct {
+	tokens float64
+	ultimo time.Time
+}
+
+type claveCubeta struct {
+	cliente string
+	metodo  string
+}
+
+// Tasa guarda una cubeta de tokens por cliente y método
+type Tasa struct {
+	defecto Cuota
+	metodos map[string]Cuota
+
+	mu             sync.Mutex
+	cubetas        map[claveCubeta]*cubeta
+	ultimaLimpieza time.Time
+}
+
+// NuevaTasa crea el limitador. defecto se aplica a los métodos que no están
+// en metodos (por nombre corto, "SendTweet"); una tasa 0 no limita.
+func NuevaTasa(defecto Cuota, metodos map[string]Cuota) *Tasa {
+	return &Tasa{
+		defecto:        defecto,
+		metodos:        metodos,
+		cubetas:        make(map[claveCubeta]*cubeta),
+		ultimaLimpieza: time.Now(),
+	}
+}
+
+func (t *Tasa) cuota(metodo string) Cuota {
+	corto := metodo[strings.LastIndex(metodo, "/")+1:]
+	if c, ok := t.metodos[corto]; ok {
+		return c
+	}
+	return t.defecto
+}
+
+// Tomar gasta un token de la cubeta del cliente para el método. Devuelve 0
+// si había token y, si no, cuánto falta para que haya uno.
+func (t *Tasa) Tomar(cliente, metodo string, ahora time.Time) time.Duration {
+	c := t.cuota(metodo)
+	if c.Tasa <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limpiar(ahora)
+
+	clave := claveCubeta{cliente, metodo}
+	b, ok := t.cubetas[clave]
+	if !ok {
+		b = &cubeta{tokens: c.Rafaga, ultimo: ahora}
+		t.cubetas[clave] = b
+	}
+	b.tokens = min(c.Rafaga, b.tokens+ahora.Sub(b.ultimo).Seconds()*c.Tasa)
+	b.ultimo = ahora
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / c.Tasa * float64(time.Second))
+}
+
+// Esperar espera hasta que haya un token (para los mensajes de un stream, que
+// se frenan en lugar de rechazarse)
+func (t *Tasa) Esperar(ctx context.Context, cliente, metodo string) error {
+	for {
+		espera := t.Tomar(cliente, metodo, time.Now())
+		if espera == 0 {
+			return nil
+		}
+		temporizador := time.NewTimer(espera)
+		select {
+		case <-ctx.Done():
+			temporizador.Stop()
+			return ctx.Err()
+		case <-temporizador.C:
+		}
+	}
+}
+
+// limpiar borra las cubetas que ya se llenaron: son iguales a una nueva
+func (t *Tasa) limpiar(ahora time.Time) {
+	if ahora.Sub(t.ultimaLimpieza) < intervaloLimpieza {
+		return
+	}
+	t.ultimaLimpieza = ahora
+	for clave, b := range t.cubetas {
+		c := t.cuota(clave.metodo)
+		if b.tokens+ahora.Sub(b.ultimo).Seconds()*c.Tasa >= c.Rafaga {
+			delete(t.cubetas, clave)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package limites
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLeerCuotas(t *testing.T) {
+	casos := []struct {
+		texto string
+		desea map[string]Cuota
+		error bool
+	}{
+		{texto: "", desea: map[string]Cuota{}},
+		{texto: "SendTweet=50", desea: map[string]Cuota{"SendTweet": {Tasa: 50, Rafaga: 100}}},
+		{texto: " SendTweet=50, ListTweets=5/20,", desea: map[string]Cuota{
+			"SendTweet":  {Tasa: 50, Rafaga: 100},
+			"ListTweets": {Tasa: 5, Rafaga: 20},
+		}},
+		{texto: "GetTweet=0.5", desea: map[string]Cuota{"GetTweet": {Tasa: 0.5, Rafaga: 1}}},
+		{texto: "SendTweet", error: true},
+		{texto: "SendTweet=rapido", error: true},
+		{texto: "SendTweet=0", error: true},
+		{texto: "SendTweet=-1", error: true},
+		{texto: "SendTweet=5/0", error: true},
+		{texto: "SendTweet=5/x", error: true},
+	}
+	for _, caso := range casos {
+		cuotas, err := LeerCuotas(caso.texto)
+		if caso.error {
+			if err == nil {
+				t.Errorf("%q: se esperaba un error y dio %v", caso.texto, cuotas)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", caso.texto, err)
+			continue
+		}
+		if !reflect.DeepEqual(cuotas, caso.desea) {
+			t.Errorf("%q: %v, se esperaba %v", caso.texto, cuotas, caso.desea)
+		}
+	}
+}
+
+const metodoEnviar = "/tweet.TweetService/SendTweet"
+
+func TestTasaRafagaYRecarga(t *testing.T) {
+	tasa := NuevaTasa(Cuota{Tasa: 10, Rafaga: 3}, nil)
+	ahora := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if espera := tasa.Tomar("a", metodoEnviar, ahora); espera != 0 {
+			t.Fatalf("llamada %d de la ráfaga rechazada (espera %s)", i+1, espera)
+		}
+	}
+	espera := tasa.Tomar("a", metodoEnviar, ahora)
+	if espera != 100*time.Millisecond {
+		t.Fatalf("sin tokens la espera debería ser 100ms a 10 llamadas/s: %s", espera)
+	}
+
+	// Otro cliente y otro método tienen su propia cubeta
+	if tasa.Tomar("b", metodoEnviar, ahora) != 0 {
+		t.Error("la cubeta de otro cliente no debería estar vacía")
+	}
+	if tasa.Tomar("a", "/tweet.TweetService/ListTweets", ahora) != 0 {
+		t.Error("la cubeta de otro método no debería estar vacía")
+	}
+
+	// Se recarga a la tasa y no pasa de la ráfaga
+	if tasa.Tomar("a", metodoEnviar, ahora.Add(100*time.Millisecond)) != 0 {
+		t.Error("después de 100ms debería haber un token")
+	}
+	despues := ahora.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		tasa.Tomar("a", metodoEnviar, despues)
+	}
+	if tasa.Tomar("a", metodoEnviar, despues) == 0 {
+		t.Error("la cubeta no debería acumular más que la ráfaga")
+	}
+}
+
+func TestTasaCuotaPorMetodo(t *testing.T) {
+	tasa := NuevaTasa(Cuota{}, map[string]Cuota{"SendTweet": {Tasa: 1, Rafaga: 1}})
+	ahora := time.Now()
+	for i := 0; i < 100; i++ {
+		if tasa.Tomar("a", "/tweet.TweetService/ListTweets", ahora) != 0 {
+			t.Fatal("con tasa 0 el método no se limita")
+		}
+	}
+	if tasa.Tomar("a", metodoEnviar, ahora) != 0 {
+		t.Fatal("el primer SendTweet debería pasar")
+	}
+	if tasa.Tomar("a", metodoEnviar, ahora) != time.Second {
+		t.Fatal("el segundo SendTweet debería esperar un segundo")
+	}
+}
+
+func TestTasaLimpiaCubetasLlenas(t *testing.T) {
+	tasa := NuevaTasa(Cuota{Tasa: 10, Rafaga: 10}, nil)
+	ahora := time.Now()
+	tasa.Tomar("a", metodoEnviar, ahora)
+	tasa.Tomar("b", metodoEnviar, ahora.Add(intervaloLimpieza))
+	tasa.Tomar("c", metodoEnviar, ahora.Add(2*intervaloLimpieza))
+	if n := len(tasa.cubetas); n != 1 {
+		t.Fatalf("deberían quedar solo las cubetas sin llenar: %d", n)
+	}
+}
+
+func TestTasaEsperar(t *testing.T) {
+	tasa := NuevaTasa(Cuota{Tasa: 100, Rafaga: 1}, nil)
+	inicio := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := tasa.Esperar(context.Background(), "a", metodoEnviar); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(inicio); d < 15*time.Millisecond {
+		t.Errorf("tres tokens a 100/s con ráfaga 1 deberían tardar unos 20ms: %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	lenta := NuevaTasa(Cuota{Tasa: 0.001, Rafaga: 1}, nil)
+	lenta.Tomar("a", metodoEnviar, time.Now())
+	if err := lenta.Esperar(ctx, "a", metodoEnviar); err != context.Canceled {
+		t.Fatalf("con el contexto cancelado: %v", err)
+	}
+}
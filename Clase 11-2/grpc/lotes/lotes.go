@@ -0,0 +1,181 @@
+// Package lotes junta los tweets que llegan al puente REST y los envía al
+// servidor en un solo stream SendTweets, en lugar de un SendTweet por cada
+// POST. Un lote se envía cuando junta max tweets o cuando pasa intervalo
+// desde el primero (los parámetros de Nuevo), lo que ocurra antes, así que
+// ningún tweet espera más que intervalo para salir.
+package lotes
+
+import (
+	"context"
+	"io"
+	"time"
+
+	pb "grpc/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Agrupador envía los tweets en lotes
+type Agrupador struct {
+	cliente   pb.TweetServiceClient
+	max       int
+	intervalo time.Duration
+	// timeout de cada stream SendTweets
+	timeout time.Duration
+
+	entrada chan pendiente
+}
+
+// pendiente es un tweet esperando su lote; resultado recibe nil si se guardó.
+// ctx es el de quien llamó a Enviar: si ya terminó nadie espera el resultado.
+type pendiente struct {
+	ctx       context.Context
+	req       *pb.TweetRequest
+	resultado chan error
+}
+
+// Nuevo crea el agrupador y empieza a juntar tweets. timeout es el de cada
+// stream SendTweets, el mismo que tendría un SendTweet suelto.
+func Nuevo(cliente pb.TweetServiceClient, max int, intervalo, timeout time.Duration) *Agrupador {
+	a := &Agrupador{
+		cliente:   cliente,
+		max:       max,
+		intervalo: intervalo,
+		timeout:   timeout,
+		entrada:   make(chan pendiente, max),
+	}
+	go a.agrupar()
+	return a
+}
+
+// Enviar agrega el tweet al lote actual y espera a que el servidor lo
+// confirme. El error es un error de gRPC: el del tweet si el servidor lo
+// rechazó (con sus violaciones) o el del stream si falló el lote completo.
+func (a *Agrupador) Enviar(ctx context.Context, req *pb.TweetRequest) error {
+	p := pendiente{ctx: ctx, req: req, resultado: make(chan error, 1)}
+	select {
+	case a.entrada <- p:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	select {
+	case err := <-p.resultado:
+		return err
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (a *Agrupador) agrupar() {
+	var lote []pendiente
+	var limite <-chan time.Time
+	var temporizador *time.Timer
+
+	enviar := func() {
+		go a.enviar(lote)
+		lote, limite = nil, nil
+	}
+
+	for {
+		select {
+		case p := <-a.entrada:
+			if len(lote) == 0 {
+				// El intervalo se cuenta desde el primer tweet del lote
+				temporizador = time.NewTimer(a.intervalo)
+				limite = temporizador.C
+			}
+			lote = append(lote, p)
+			if len(lote) >= a.max {
+				temporizador.Stop()
+				enviar()
+			}
+		case <-limite:
+			enviar()
+		}
+	}
+}
+
+// enviar manda el lote en un stream y entrega a cada tweet su resultado. Los
+// tweets cuyo POST ya se canceló o venció no se envían: Enviar ya le devolvió
+// el error a quien llamó, así que guardarlos sería un tweet que el cliente
+// cree fallido y puede volver a mandar.
+func (a *Agrupador) enviar(lote []pendiente) {
+	vigentes := make([]pendiente, 0, len(lote))
+	for _, p := range lote {
+		if p.ctx.Err() == nil {
+			vigentes = append(vigentes, p)
+		}
+	}
+	if len(vigentes) == 0 {
+		return
+	}
+	lote = vigentes
+
+	// El stream no depende del contexto de ningún POST en particular: si uno
+	// se cancela, los demás tweets del lote siguen
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	resumen, err := a.enviarStream(ctx, lote)
+	if err != nil {
+		for _, p := range lote {
+			p.resultado <- err
+		}
+		return
+	}
+
+	// SendTweets solo detalla los rechazados, por su posición en el stream
+	rechazos := make(map[uint64]*pb.TweetAck, len(resumen.Rejections))
+	for _, r := range resumen.Rejections {
+		rechazos[r.Sequence] = r
+	}
+	for i, p := range lote {
+		if i >= int(resumen.Received) {
+			// El servidor cerró el stream antes de recibirlo
+			p.resultado <- status.Error(codes.Unavailable, "el tweet no llegó al servidor")
+			continue
+		}
+		p.resultado <- ErrorDeAck(rechazos[uint64(i)])
+	}
+}
+
+func (a *Agrupador) enviarStream(ctx context.Context, lote []pendiente) (*pb.BatchSummary, error) {
+	stream, err := a.cliente.SendTweets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range lote {
+		// Con io.EOF el servidor cerró el stream; el error real lo devuelve CloseAndRecv
+		if err := stream.Send(p.req); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// ErrorDeAck convierte un TweetAck en el error de gRPC equivalente: nil si el
+// tweet se guardó y, si no, su código con las violaciones como BadRequest
+// (igual que respondería SendTweet)
+func ErrorDeAck(ack *pb.TweetAck) error {
+	if ack == nil || codes.Code(ack.Code) == codes.OK {
+		return nil
+	}
+	st := status.New(codes.Code(ack.Code), ack.Message)
+	if len(ack.Violations) == 0 {
+		return st.Err()
+	}
+
+	br := &errdetails.BadRequest{}
+	for _, v := range ack.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description})
+	}
+	if conDetalles, err := st.WithDetails(br); err == nil {
+		return conDetalles.Err()
+	}
+	return st.Err()
+}
@@ -0,0 +1,114 @@
+package lotes
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "grpc/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// servidorPrueba guarda las descripciones que recibe por SendTweets
+type servidorPrueba struct {
+	pb.UnimplementedTweetServiceServer
+	demora time.Duration
+
+	mu        sync.Mutex
+	recibidos []string
+}
+
+func (s *servidorPrueba) SendTweets(stream grpc.ClientStreamingServer[pb.TweetRequest, pb.BatchSummary]) error {
+	var n int32
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.recibidos = append(s.recibidos, req.Description)
+		s.mu.Unlock()
+		n++
+	}
+	select {
+	case <-time.After(s.demora):
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	}
+	return stream.SendAndClose(&pb.BatchSummary{Received: n, Accepted: n})
+}
+
+func (s *servidorPrueba) descripciones() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.recibidos...)
+}
+
+func levantar(t *testing.T, s *servidorPrueba) pb.TweetServiceClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterTweetServiceServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTweetServiceClient(conn)
+}
+
+func tweet(descripcion string) *pb.TweetRequest {
+	return &pb.TweetRequest{Description: descripcion, Country: "Guatemala", Weather: "soleado"}
+}
+
+func TestNoEnviaTweetsCancelados(t *testing.T) {
+	servidor := &servidorPrueba{}
+	a := Nuevo(levantar(t, servidor), 10, 100*time.Millisecond, time.Second)
+
+	// El POST se cancela mientras su tweet espera el lote
+	cancelado, cancelar := context.WithCancel(context.Background())
+	errCancelado := make(chan error, 1)
+	go func() { errCancelado <- a.Enviar(cancelado, tweet("cancelado")) }()
+	time.Sleep(20 * time.Millisecond)
+	cancelar()
+	if err := <-errCancelado; status.Code(err) != codes.Canceled {
+		t.Fatalf("err = %v, se esperaba Canceled", err)
+	}
+
+	if err := a.Enviar(context.Background(), tweet("vigente")); err != nil {
+		t.Fatal(err)
+	}
+	if got := servidor.descripciones(); len(got) != 1 || got[0] != "vigente" {
+		t.Fatalf("el servidor recibió %q, se esperaba solo el tweet vigente", got)
+	}
+}
+
+func TestTimeoutDelStream(t *testing.T) {
+	servidor := &servidorPrueba{demora: time.Second}
+	a := Nuevo(levantar(t, servidor), 1, time.Millisecond, 50*time.Millisecond)
+
+	inicio := time.Now()
+	err := a.Enviar(context.Background(), tweet("lento"))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("err = %v, se esperaba DeadlineExceeded", err)
+	}
+	if transcurrido := time.Since(inicio); transcurrido > 500*time.Millisecond {
+		t.Fatalf("el stream duró %s con un timeout de 50ms", transcurrido)
+	}
+}
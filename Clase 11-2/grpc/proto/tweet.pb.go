@@ -9,6 +9,7 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,12 +22,17 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// Mensaje que se enviará
+// Mensaje que se enviará. Los tres campos son obligatorios; si alguno es
+// inválido SendTweet responde INVALID_ARGUMENT con un google.rpc.BadRequest
+// que tiene una violación por campo.
 type TweetRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Description   string                 `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
-	Country       string                 `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
-	Weather       string                 `protobuf:"bytes,3,opt,name=weather,proto3" json:"weather,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Hasta 280 caracteres
+	Description string `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	// Hasta 64 caracteres
+	Country string `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+	// soleado, nublado, lluvioso o ventoso (sin distinguir mayúsculas)
+	Weather       string `protobuf:"bytes,3,opt,name=weather,proto3" json:"weather,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -84,8 +90,11 @@ func (x *TweetRequest) GetWeather() string {
 
 // Respuesta del servidor
 type TweetResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Id y fecha que el servidor asignó al tweet guardado
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -127,19 +136,734 @@ func (x *TweetResponse) GetStatus() string {
 	return ""
 }
 
+func (x *TweetResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TweetResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Tweet guardado en el servidor
+type Tweet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Country       string                 `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	Weather       string                 `protobuf:"bytes,4,opt,name=weather,proto3" json:"weather,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tweet) Reset() {
+	*x = Tweet{}
+	mi := &file_tweet_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tweet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tweet) ProtoMessage() {}
+
+func (x *Tweet) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tweet.ProtoReflect.Descriptor instead.
+func (*Tweet) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Tweet) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Tweet) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tweet) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Tweet) GetWeather() string {
+	if x != nil {
+		return x.Weather
+	}
+	return ""
+}
+
+func (x *Tweet) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type GetTweetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTweetRequest) Reset() {
+	*x = GetTweetRequest{}
+	mi := &file_tweet_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTweetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTweetRequest) ProtoMessage() {}
+
+func (x *GetTweetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTweetRequest.ProtoReflect.Descriptor instead.
+func (*GetTweetRequest) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetTweetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Los filtros vacíos no filtran; country y weather no distinguen mayúsculas
+type ListTweetsRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Country string                 `protobuf:"bytes,1,opt,name=country,proto3" json:"country,omitempty"`
+	Weather string                 `protobuf:"bytes,2,opt,name=weather,proto3" json:"weather,omitempty"`
+	// Tweets por página (por defecto 20, máximo 100)
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token de la respuesta anterior; vacío para la primera página
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTweetsRequest) Reset() {
+	*x = ListTweetsRequest{}
+	mi := &file_tweet_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTweetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTweetsRequest) ProtoMessage() {}
+
+func (x *ListTweetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTweetsRequest.ProtoReflect.Descriptor instead.
+func (*ListTweetsRequest) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListTweetsRequest) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *ListTweetsRequest) GetWeather() string {
+	if x != nil {
+		return x.Weather
+	}
+	return ""
+}
+
+func (x *ListTweetsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTweetsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Tweets del más nuevo al más viejo
+type ListTweetsResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tweets []*Tweet               `protobuf:"bytes,1,rep,name=tweets,proto3" json:"tweets,omitempty"`
+	// Vacío cuando no hay más páginas
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTweetsResponse) Reset() {
+	*x = ListTweetsResponse{}
+	mi := &file_tweet_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTweetsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTweetsResponse) ProtoMessage() {}
+
+func (x *ListTweetsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTweetsResponse.ProtoReflect.Descriptor instead.
+func (*ListTweetsResponse) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListTweetsResponse) GetTweets() []*Tweet {
+	if x != nil {
+		return x.Tweets
+	}
+	return nil
+}
+
+func (x *ListTweetsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type DeleteTweetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTweetRequest) Reset() {
+	*x = DeleteTweetRequest{}
+	mi := &file_tweet_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTweetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTweetRequest) ProtoMessage() {}
+
+func (x *DeleteTweetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTweetRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTweetRequest) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteTweetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteTweetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTweetResponse) Reset() {
+	*x = DeleteTweetResponse{}
+	mi := &file_tweet_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTweetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTweetResponse) ProtoMessage() {}
+
+func (x *DeleteTweetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTweetResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTweetResponse) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{7}
+}
+
+// Violación de un campo de TweetRequest (igual que google.rpc.BadRequest.FieldViolation)
+type FieldViolation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldViolation) Reset() {
+	*x = FieldViolation{}
+	mi := &file_tweet_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldViolation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldViolation) ProtoMessage() {}
+
+func (x *FieldViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldViolation.ProtoReflect.Descriptor instead.
+func (*FieldViolation) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FieldViolation) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldViolation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// Resultado de un tweet enviado en un stream
+type TweetAck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// sequence del TweetStreamRequest, o la posición (desde 0) en SendTweets
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Código de google.rpc.Code: 0 (OK) si se guardó
+	Code       int32             `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message    string            `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Violations []*FieldViolation `protobuf:"bytes,4,rep,name=violations,proto3" json:"violations,omitempty"`
+	// Id y fecha asignados si se guardó
+	Id            string                 `protobuf:"bytes,5,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TweetAck) Reset() {
+	*x = TweetAck{}
+	mi := &file_tweet_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TweetAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TweetAck) ProtoMessage() {}
+
+func (x *TweetAck) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TweetAck.ProtoReflect.Descriptor instead.
+func (*TweetAck) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TweetAck) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *TweetAck) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *TweetAck) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TweetAck) GetViolations() []*FieldViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *TweetAck) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TweetAck) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Resumen de SendTweets; solo trae el detalle de los tweets rechazados
+type BatchSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Received      int32                  `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	Accepted      int32                  `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      int32                  `protobuf:"varint,3,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Rejections    []*TweetAck            `protobuf:"bytes,4,rep,name=rejections,proto3" json:"rejections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSummary) Reset() {
+	*x = BatchSummary{}
+	mi := &file_tweet_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSummary) ProtoMessage() {}
+
+func (x *BatchSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSummary.ProtoReflect.Descriptor instead.
+func (*BatchSummary) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BatchSummary) GetReceived() int32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *BatchSummary) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *BatchSummary) GetRejected() int32 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *BatchSummary) GetRejections() []*TweetAck {
+	if x != nil {
+		return x.Rejections
+	}
+	return nil
+}
+
+type TweetStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Número elegido por el cliente para relacionar cada TweetAck con su tweet
+	Sequence      uint64        `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Tweet         *TweetRequest `protobuf:"bytes,2,opt,name=tweet,proto3" json:"tweet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TweetStreamRequest) Reset() {
+	*x = TweetStreamRequest{}
+	mi := &file_tweet_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TweetStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TweetStreamRequest) ProtoMessage() {}
+
+func (x *TweetStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TweetStreamRequest.ProtoReflect.Descriptor instead.
+func (*TweetStreamRequest) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TweetStreamRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *TweetStreamRequest) GetTweet() *TweetRequest {
+	if x != nil {
+		return x.Tweet
+	}
+	return nil
+}
+
+// Filtros de SubscribeTweets: una lista vacía no filtra. Se comparan sin
+// distinguir mayúsculas; weathers solo acepta los climas de TweetRequest.
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Countries     []string               `protobuf:"bytes,1,rep,name=countries,proto3" json:"countries,omitempty"`
+	Weathers      []string               `protobuf:"bytes,2,rep,name=weathers,proto3" json:"weathers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_tweet_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tweet_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_tweet_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SubscribeRequest) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetWeathers() []string {
+	if x != nil {
+		return x.Weathers
+	}
+	return nil
+}
+
 var File_tweet_proto protoreflect.FileDescriptor
 
 const file_tweet_proto_rawDesc = "" +
 	"\n" +
-	"\vtweet.proto\x12\x05tweet\"d\n" +
+	"\vtweet.proto\x12\x05tweet\x1a\x1fgoogle/protobuf/timestamp.proto\"d\n" +
 	"\fTweetRequest\x12 \n" +
 	"\vdescription\x18\x01 \x01(\tR\vdescription\x12\x18\n" +
 	"\acountry\x18\x02 \x01(\tR\acountry\x12\x18\n" +
-	"\aweather\x18\x03 \x01(\tR\aweather\"'\n" +
+	"\aweather\x18\x03 \x01(\tR\aweather\"r\n" +
 	"\rTweetResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status2F\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xa8\x01\n" +
+	"\x05Tweet\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x18\n" +
+	"\acountry\x18\x03 \x01(\tR\acountry\x12\x18\n" +
+	"\aweather\x18\x04 \x01(\tR\aweather\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"!\n" +
+	"\x0fGetTweetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x83\x01\n" +
+	"\x11ListTweetsRequest\x12\x18\n" +
+	"\acountry\x18\x01 \x01(\tR\acountry\x12\x18\n" +
+	"\aweather\x18\x02 \x01(\tR\aweather\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"b\n" +
+	"\x12ListTweetsResponse\x12$\n" +
+	"\x06tweets\x18\x01 \x03(\v2\f.tweet.TweetR\x06tweets\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"$\n" +
+	"\x12DeleteTweetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x15\n" +
+	"\x13DeleteTweetResponse\"H\n" +
+	"\x0eFieldViolation\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\xd6\x01\n" +
+	"\bTweetAck\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x125\n" +
+	"\n" +
+	"violations\x18\x04 \x03(\v2\x15.tweet.FieldViolationR\n" +
+	"violations\x12\x0e\n" +
+	"\x02id\x18\x05 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x93\x01\n" +
+	"\fBatchSummary\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\x05R\breceived\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\x05R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x03 \x01(\x05R\brejected\x12/\n" +
+	"\n" +
+	"rejections\x18\x04 \x03(\v2\x0f.tweet.TweetAckR\n" +
+	"rejections\"[\n" +
+	"\x12TweetStreamRequest\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12)\n" +
+	"\x05tweet\x18\x02 \x01(\v2\x13.tweet.TweetRequestR\x05tweet\"L\n" +
+	"\x10SubscribeRequest\x12\x1c\n" +
+	"\tcountries\x18\x01 \x03(\tR\tcountries\x12\x1a\n" +
+	"\bweathers\x18\x02 \x03(\tR\bweathers2\xb6\x03\n" +
 	"\fTweetService\x126\n" +
-	"\tSendTweet\x12\x13.tweet.TweetRequest\x1a\x14.tweet.TweetResponseB\tZ\a./protob\x06proto3"
+	"\tSendTweet\x12\x13.tweet.TweetRequest\x1a\x14.tweet.TweetResponse\x120\n" +
+	"\bGetTweet\x12\x16.tweet.GetTweetRequest\x1a\f.tweet.Tweet\x12A\n" +
+	"\n" +
+	"ListTweets\x12\x18.tweet.ListTweetsRequest\x1a\x19.tweet.ListTweetsResponse\x12D\n" +
+	"\vDeleteTweet\x12\x19.tweet.DeleteTweetRequest\x1a\x1a.tweet.DeleteTweetResponse\x128\n" +
+	"\n" +
+	"SendTweets\x12\x13.tweet.TweetRequest\x1a\x13.tweet.BatchSummary(\x01\x12=\n" +
+	"\vTweetStream\x12\x19.tweet.TweetStreamRequest\x1a\x0f.tweet.TweetAck(\x010\x01\x12:\n" +
+	"\x0fSubscribeTweets\x12\x17.tweet.SubscribeRequest\x1a\f.tweet.Tweet0\x01B\tZ\a./protob\x06proto3"
 
 var (
 	file_tweet_proto_rawDescOnce sync.Once
@@ -153,19 +877,50 @@ func file_tweet_proto_rawDescGZIP() []byte {
 	return file_tweet_proto_rawDescData
 }
 
-var file_tweet_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_tweet_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_tweet_proto_goTypes = []any{
-	(*TweetRequest)(nil),  // 0: tweet.TweetRequest
-	(*TweetResponse)(nil), // 1: tweet.TweetResponse
+	(*TweetRequest)(nil),          // 0: tweet.TweetRequest
+	(*TweetResponse)(nil),         // 1: tweet.TweetResponse
+	(*Tweet)(nil),                 // 2: tweet.Tweet
+	(*GetTweetRequest)(nil),       // 3: tweet.GetTweetRequest
+	(*ListTweetsRequest)(nil),     // 4: tweet.ListTweetsRequest
+	(*ListTweetsResponse)(nil),    // 5: tweet.ListTweetsResponse
+	(*DeleteTweetRequest)(nil),    // 6: tweet.DeleteTweetRequest
+	(*DeleteTweetResponse)(nil),   // 7: tweet.DeleteTweetResponse
+	(*FieldViolation)(nil),        // 8: tweet.FieldViolation
+	(*TweetAck)(nil),              // 9: tweet.TweetAck
+	(*BatchSummary)(nil),          // 10: tweet.BatchSummary
+	(*TweetStreamRequest)(nil),    // 11: tweet.TweetStreamRequest
+	(*SubscribeRequest)(nil),      // 12: tweet.SubscribeRequest
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
 }
 var file_tweet_proto_depIdxs = []int32{
-	0, // 0: tweet.TweetService.SendTweet:input_type -> tweet.TweetRequest
-	1, // 1: tweet.TweetService.SendTweet:output_type -> tweet.TweetResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	13, // 0: tweet.TweetResponse.created_at:type_name -> google.protobuf.Timestamp
+	13, // 1: tweet.Tweet.created_at:type_name -> google.protobuf.Timestamp
+	2,  // 2: tweet.ListTweetsResponse.tweets:type_name -> tweet.Tweet
+	8,  // 3: tweet.TweetAck.violations:type_name -> tweet.FieldViolation
+	13, // 4: tweet.TweetAck.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 5: tweet.BatchSummary.rejections:type_name -> tweet.TweetAck
+	0,  // 6: tweet.TweetStreamRequest.tweet:type_name -> tweet.TweetRequest
+	0,  // 7: tweet.TweetService.SendTweet:input_type -> tweet.TweetRequest
+	3,  // 8: tweet.TweetService.GetTweet:input_type -> tweet.GetTweetRequest
+	4,  // 9: tweet.TweetService.ListTweets:input_type -> tweet.ListTweetsRequest
+	6,  // 10: tweet.TweetService.DeleteTweet:input_type -> tweet.DeleteTweetRequest
+	0,  // 11: tweet.TweetService.SendTweets:input_type -> tweet.TweetRequest
+	11, // 12: tweet.TweetService.TweetStream:input_type -> tweet.TweetStreamRequest
+	12, // 13: tweet.TweetService.SubscribeTweets:input_type -> tweet.SubscribeRequest
+	1,  // 14: tweet.TweetService.SendTweet:output_type -> tweet.TweetResponse
+	2,  // 15: tweet.TweetService.GetTweet:output_type -> tweet.Tweet
+	5,  // 16: tweet.TweetService.ListTweets:output_type -> tweet.ListTweetsResponse
+	7,  // 17: tweet.TweetService.DeleteTweet:output_type -> tweet.DeleteTweetResponse
+	10, // 18: tweet.TweetService.SendTweets:output_type -> tweet.BatchSummary
+	9,  // 19: tweet.TweetService.TweetStream:output_type -> tweet.TweetAck
+	2,  // 20: tweet.TweetService.SubscribeTweets:output_type -> tweet.Tweet
+	14, // [14:21] is the sub-list for method output_type
+	7,  // [7:14] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_tweet_proto_init() }
@@ -179,7 +934,7 @@ func file_tweet_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tweet_proto_rawDesc), len(file_tweet_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
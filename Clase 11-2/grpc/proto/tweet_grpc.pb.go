@@ -19,7 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TweetService_SendTweet_FullMethodName = "/tweet.TweetService/SendTweet"
+	TweetService_SendTweet_FullMethodName       = "/tweet.TweetService/SendTweet"
+	TweetService_GetTweet_FullMethodName        = "/tweet.TweetService/GetTweet"
+	TweetService_ListTweets_FullMethodName      = "/tweet.TweetService/ListTweets"
+	TweetService_DeleteTweet_FullMethodName     = "/tweet.TweetService/DeleteTweet"
+	TweetService_SendTweets_FullMethodName      = "/tweet.TweetService/SendTweets"
+	TweetService_TweetStream_FullMethodName     = "/tweet.TweetService/TweetStream"
+	TweetService_SubscribeTweets_FullMethodName = "/tweet.TweetService/SubscribeTweets"
 )
 
 // TweetServiceClient is the client API for TweetService service.
@@ -29,6 +35,19 @@ const (
 // Servicio gRPC
 type TweetServiceClient interface {
 	SendTweet(ctx context.Context, in *TweetRequest, opts ...grpc.CallOption) (*TweetResponse, error)
+	GetTweet(ctx context.Context, in *GetTweetRequest, opts ...grpc.CallOption) (*Tweet, error)
+	ListTweets(ctx context.Context, in *ListTweetsRequest, opts ...grpc.CallOption) (*ListTweetsResponse, error)
+	DeleteTweet(ctx context.Context, in *DeleteTweetRequest, opts ...grpc.CallOption) (*DeleteTweetResponse, error)
+	// Recibe muchos tweets en un stream y responde un resumen al cerrarlo.
+	// Un tweet inválido no corta el stream: se cuenta en rejections.
+	SendTweets(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[TweetRequest, BatchSummary], error)
+	// Responde un TweetAck por cada tweet, en el mismo orden en que llegan
+	TweetStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[TweetStreamRequest, TweetAck], error)
+	// Envía en vivo cada tweet guardado desde que empieza la suscripción que
+	// coincide con los filtros. Si el suscriptor no lee a tiempo se descartan
+	// tweets; si sigue atrasado el servidor cierra el stream con
+	// RESOURCE_EXHAUSTED.
+	SubscribeTweets(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Tweet], error)
 }
 
 type tweetServiceClient struct {
@@ -49,6 +68,81 @@ func (c *tweetServiceClient) SendTweet(ctx context.Context, in *TweetRequest, op
 	return out, nil
 }
 
+func (c *tweetServiceClient) GetTweet(ctx context.Context, in *GetTweetRequest, opts ...grpc.CallOption) (*Tweet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tweet)
+	err := c.cc.Invoke(ctx, TweetService_GetTweet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tweetServiceClient) ListTweets(ctx context.Context, in *ListTweetsRequest, opts ...grpc.CallOption) (*ListTweetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTweetsResponse)
+	err := c.cc.Invoke(ctx, TweetService_ListTweets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tweetServiceClient) DeleteTweet(ctx context.Context, in *DeleteTweetRequest, opts ...grpc.CallOption) (*DeleteTweetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteTweetResponse)
+	err := c.cc.Invoke(ctx, TweetService_DeleteTweet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tweetServiceClient) SendTweets(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[TweetRequest, BatchSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TweetService_ServiceDesc.Streams[0], TweetService_SendTweets_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TweetRequest, BatchSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TweetService_SendTweetsClient = grpc.ClientStreamingClient[TweetRequest, BatchSummary]
+
+func (c *tweetServiceClient) TweetStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[TweetStreamRequest, TweetAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TweetService_ServiceDesc.Streams[1], TweetService_TweetStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TweetStreamRequest, TweetAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TweetService_TweetStreamClient = grpc.BidiStreamingClient[TweetStreamRequest, TweetAck]
+
+func (c *tweetServiceClient) SubscribeTweets(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Tweet], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TweetService_ServiceDesc.Streams[2], TweetService_SubscribeTweets_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Tweet]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TweetService_SubscribeTweetsClient = grpc.ServerStreamingClient[Tweet]
+
 // TweetServiceServer is the server API for TweetService service.
 // All implementations must embed UnimplementedTweetServiceServer
 // for forward compatibility.
@@ -56,6 +150,19 @@ func (c *tweetServiceClient) SendTweet(ctx context.Context, in *TweetRequest, op
 // Servicio gRPC
 type TweetServiceServer interface {
 	SendTweet(context.Context, *TweetRequest) (*TweetResponse, error)
+	GetTweet(context.Context, *GetTweetRequest) (*Tweet, error)
+	ListTweets(context.Context, *ListTweetsRequest) (*ListTweetsResponse, error)
+	DeleteTweet(context.Context, *DeleteTweetRequest) (*DeleteTweetResponse, error)
+	// Recibe muchos tweets en un stream y responde un resumen al cerrarlo.
+	// Un tweet inválido no corta el stream: se cuenta en rejections.
+	SendTweets(grpc.ClientStreamingServer[TweetRequest, BatchSummary]) error
+	// Responde un TweetAck por cada tweet, en el mismo orden en que llegan
+	TweetStream(grpc.BidiStreamingServer[TweetStreamRequest, TweetAck]) error
+	// Envía en vivo cada tweet guardado desde que empieza la suscripción que
+	// coincide con los filtros. Si el suscriptor no lee a tiempo se descartan
+	// tweets; si sigue atrasado el servidor cierra el stream con
+	// RESOURCE_EXHAUSTED.
+	SubscribeTweets(*SubscribeRequest, grpc.ServerStreamingServer[Tweet]) error
 	mustEmbedUnimplementedTweetServiceServer()
 }
 
@@ -69,6 +176,24 @@ type UnimplementedTweetServiceServer struct{}
 func (UnimplementedTweetServiceServer) SendTweet(context.Context, *TweetRequest) (*TweetResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SendTweet not implemented")
 }
+func (UnimplementedTweetServiceServer) GetTweet(context.Context, *GetTweetRequest) (*Tweet, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTweet not implemented")
+}
+func (UnimplementedTweetServiceServer) ListTweets(context.Context, *ListTweetsRequest) (*ListTweetsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTweets not implemented")
+}
+func (UnimplementedTweetServiceServer) DeleteTweet(context.Context, *DeleteTweetRequest) (*DeleteTweetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteTweet not implemented")
+}
+func (UnimplementedTweetServiceServer) SendTweets(grpc.ClientStreamingServer[TweetRequest, BatchSummary]) error {
+	return status.Error(codes.Unimplemented, "method SendTweets not implemented")
+}
+func (UnimplementedTweetServiceServer) TweetStream(grpc.BidiStreamingServer[TweetStreamRequest, TweetAck]) error {
+	return status.Error(codes.Unimplemented, "method TweetStream not implemented")
+}
+func (UnimplementedTweetServiceServer) SubscribeTweets(*SubscribeRequest, grpc.ServerStreamingServer[Tweet]) error {
+	return status.Error(codes.Unimplemented, "method SubscribeTweets not implemented")
+}
 func (UnimplementedTweetServiceServer) mustEmbedUnimplementedTweetServiceServer() {}
 func (UnimplementedTweetServiceServer) testEmbeddedByValue()                      {}
 
@@ -108,6 +233,85 @@ func _TweetService_SendTweet_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TweetService_GetTweet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTweetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TweetServiceServer).GetTweet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TweetService_GetTweet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TweetServiceServer).GetTweet(ctx, req.(*GetTweetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TweetService_ListTweets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTweetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TweetServiceServer).ListTweets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TweetService_ListTweets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TweetServiceServer).ListTweets(ctx, req.(*ListTweetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TweetService_DeleteTweet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTweetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TweetServiceServer).DeleteTweet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TweetService_DeleteTweet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TweetServiceServer).DeleteTweet(ctx, req.(*DeleteTweetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TweetService_SendTweets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TweetServiceServer).SendTweets(&grpc.GenericServerStream[TweetRequest, BatchSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TweetService_SendTweetsServer = grpc.ClientStreamingServer[TweetRequest, BatchSummary]
+
+func _TweetService_TweetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TweetServiceServer).TweetStream(&grpc.GenericServerStream[TweetStreamRequest, TweetAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TweetService_TweetStreamServer = grpc.BidiStreamingServer[TweetStreamRequest, TweetAck]
+
+func _TweetService_SubscribeTweets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TweetServiceServer).SubscribeTweets(m, &grpc.GenericServerStream[SubscribeRequest, Tweet]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TweetService_SubscribeTweetsServer = grpc.ServerStreamingServer[Tweet]
+
 // TweetService_ServiceDesc is the grpc.ServiceDesc for TweetService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -119,7 +323,36 @@ var TweetService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendTweet",
 			Handler:    _TweetService_SendTweet_Handler,
 		},
+		{
+			MethodName: "GetTweet",
+			Handler:    _TweetService_GetTweet_Handler,
+		},
+		{
+			MethodName: "ListTweets",
+			Handler:    _TweetService_ListTweets_Handler,
+		},
+		{
+			MethodName: "DeleteTweet",
+			Handler:    _TweetService_DeleteTweet_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendTweets",
+			Handler:       _TweetService_SendTweets_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "TweetStream",
+			Handler:       _TweetService_TweetStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SubscribeTweets",
+			Handler:       _TweetService_SubscribeTweets_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "tweet.proto",
 }
@@ -0,0 +1,106 @@
+package repositorio
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Memoria guarda los tweets en un slice ordenado por id. Con capacidad
+// solo conserva los más nuevos (entre el 90% y el 100% de capacidad): el pod tiene un límite de memoria y sin
+// cota el repositorio crece hasta que lo matan.
+type Memoria struct {
+	mu        sync.RWMutex
+	tweets    []Tweet
+	capacidad int
+}
+
+// NuevaMemoria crea un repositorio vacío que guarda hasta capacidad tweets
+// (0 = sin límite)
+func NuevaMemoria(capacidad int) *Memoria {
+	return &Memoria{capacidad: max(capacidad, 0)}
+}
+
+// Guardar agrega el tweet; si se pasa de la capacidad se borran los más
+// viejos (el id empieza con la hora, así que son los primeros del slice).
+// Se borra un décimo de la capacidad de una vez: borrar del principio mueve
+// todo el slice, y hacerlo en cada tweet frenaría las escrituras y las
+// lecturas que esperan el candado.
+func (m *Memoria) Guardar(ctx context.Context, t Tweet) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, _ := slices.BinarySearchFunc(m.tweets, t.ID, porID)
+	m.tweets = slices.Insert(m.tweets, i, t)
+	if sobran := len(m.tweets) - m.capacidad; m.capacidad > 0 && sobran > 0 {
+		m.tweets = slices.Delete(m.tweets, 0, sobran+m.capacidad/10)
+	}
+	return nil
+}
+
+func (m *Memoria) Obtener(ctx context.Context, id string) (Tweet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, ok := slices.BinarySearchFunc(m.tweets, id, porID)
+	if !ok {
+		return Tweet{}, ErrNoEncontrado
+	}
+	return m.tweets[i], nil
+}
+
+func (m *Memoria) Listar(ctx context.Context, f Filtro, p Pagina) ([]Tweet, string, error) {
+	p, err := p.normalizar()
+	if err != nil {
+		return nil, "", err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Se recorre desde el final (el más nuevo) o desde el anterior al token
+	fin := len(m.tweets)
+	if p.Token != "" {
+		fin, _ = slices.BinarySearchFunc(m.tweets, p.Token, porID)
+	}
+
+	var pagina []Tweet
+	for i := fin - 1; i >= 0; i-- {
+		t := m.tweets[i]
+		if !coincide(t, f) {
+			continue
+		}
+		if len(pagina) == p.Tamano {
+			// Hay al menos uno más: la página siguiente empieza después del último devuelto
+			return pagina, pagina[len(pagina)-1].ID, nil
+		}
+		pagina = append(pagina, t)
+	}
+	return pagina, "", nil
+}
+
+func (m *Memoria) Eliminar(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := slices.BinarySearchFunc(m.tweets, id, porID)
+	if !ok {
+		return ErrNoEncontrado
+	}
+	m.tweets = slices.Delete(m.tweets, i, i+1)
+	return nil
+}
+
+func (m *Memoria) Close() error {
+	return nil
+}
+
+func porID(t Tweet, id string) int {
+	return strings.Compare(t.ID, id)
+}
+
+func coincide(t Tweet, f Filtro) bool {
+	return (f.Country == "" || strings.EqualFold(t.Country, f.Country)) &&
+		(f.Weather == "" || strings.EqualFold(t.Weather, f.Weather))
+}
@@ -0,0 +1,75 @@
+package repositorio
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoriaCapacidad(t *testing.T) {
+	ctx := context.Background()
+	m := NuevaMemoria(3)
+
+	var ids []string
+	inicio := time.Now()
+	for i := 0; i < 5; i++ {
+		tw := Tweet{ID: NuevoID(inicio.Add(time.Duration(i) * time.Millisecond)), Description: "prueba", Country: "Guatemala", Weather: "soleado"}
+		if err := m.Guardar(ctx, tw); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, tw.ID)
+	}
+
+	// Se conservan los tres más nuevos
+	pagina, siguiente, err := m.Listar(ctx, Filtro{}, Pagina{Tamano: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina) != 3 || siguiente != "" {
+		t.Fatalf("se listaron %d tweets (siguiente %q), se esperaban 3", len(pagina), siguiente)
+	}
+	for i, tw := range pagina {
+		if tw.ID != ids[4-i] {
+			t.Errorf("tweet %d = %s, se esperaba %s", i, tw.ID, ids[4-i])
+		}
+	}
+	if _, err := m.Obtener(ctx, ids[0]); !errors.Is(err, ErrNoEncontrado) {
+		t.Fatalf("el más viejo debería haberse borrado, err = %v", err)
+	}
+}
+
+func TestMemoriaSinLimite(t *testing.T) {
+	ctx := context.Background()
+	m := NuevaMemoria(0)
+	for i := 0; i < 50; i++ {
+		m.Guardar(ctx, Tweet{ID: NuevoID(time.Now()), Description: "prueba", Country: "Guatemala", Weather: "soleado"})
+	}
+	pagina, _, _ := m.Listar(ctx, Filtro{}, Pagina{Tamano: 100})
+	if len(pagina) != 50 {
+		t.Fatalf("se listaron %d tweets, se esperaban 50", len(pagina))
+	}
+}
+
+func TestMemoriaBorraDeAUnDecimo(t *testing.T) {
+	ctx := context.Background()
+	m := NuevaMemoria(100)
+	for i := 0; i < 1000; i++ {
+		m.Guardar(ctx, Tweet{ID: NuevoID(time.Now()), Description: "prueba", Country: "Guatemala", Weather: "soleado"})
+		if n := len(m.tweets); n > 100 || (i >= 100 && n < 90) {
+			t.Fatalf("después de %d tweets quedan %d, se esperaban entre 90 y 100", i+1, n)
+		}
+	}
+}
+
+func BenchmarkMemoriaLlena(b *testing.B) {
+	ctx := context.Background()
+	m := NuevaMemoria(100000)
+	for i := 0; i < 100000; i++ {
+		m.Guardar(ctx, Tweet{ID: NuevoID(time.Now()), Description: "prueba", Country: "Guatemala", Weather: "soleado"})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Guardar(ctx, Tweet{ID: NuevoID(time.Now()), Description: "prueba", Country: "Guatemala", Weather: "soleado"})
+	}
+}
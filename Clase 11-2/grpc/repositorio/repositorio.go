@@ -0,0 +1,110 @@
+// Package repositorio guarda los tweets que recibe el servidor gRPC. Hay dos
+// implementaciones de Repositorio: en memoria (se pierde al reiniciar el pod)
+// y SQLite (un archivo en un volumen).
+package repositorio
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Tweet es un tweet guardado
+type Tweet struct {
+	ID          string
+	Description string
+	Country     string
+	Weather     string
+	CreatedAt   time.Time
+}
+
+// Filtro de ListTweets; los campos vacíos no filtran y la comparación no
+// distingue mayúsculas
+type Filtro struct {
+	Country string
+	Weather string
+}
+
+// Pagina pide hasta Tamano tweets anteriores a Token (el id del último tweet
+// de la página anterior; vacío para empezar por el más nuevo)
+type Pagina struct {
+	Tamano int
+	Token  string
+}
+
+// Repositorio guarda y consulta tweets. Listar devuelve del más nuevo al más
+// viejo y el token de la página siguiente (vacío si no hay más).
+type Repositorio interface {
+	Guardar(ctx context.Context, t Tweet) error
+	Obtener(ctx context.Context, id string) (Tweet, error)
+	Listar(ctx context.Context, f Filtro, p Pagina) ([]Tweet, string, error)
+	Eliminar(ctx context.Context, id string) error
+	Close() error
+}
+
+var (
+	// ErrNoEncontrado lo devuelven Obtener y Eliminar si el id no existe
+	ErrNoEncontrado = errors.New("tweet no encontrado")
+	// ErrTokenInvalido lo devuelve Listar si el token no es de una página anterior
+	ErrTokenInvalido = errors.New("page_token inválido")
+)
+
+// Estado de NuevoID para que los ids del mismo milisegundo sigan ordenados
+var (
+	muID         sync.Mutex
+	ultimoMS     int64
+	ultimoSufijo uint64
+)
+
+// NuevoID genera un id de 28 caracteres hexadecimales: los milisegundos de t
+// seguidos de un sufijo aleatorio de 8 bytes. Dentro del mismo milisegundo el
+// sufijo se incrementa, así que los ids se ordenan igual que la fecha de
+// creación y sirven como token de página.
+func NuevoID(t time.Time) string {
+	muID.Lock()
+	defer muID.Unlock()
+
+	ms := t.UnixMilli()
+	if ms <= ultimoMS {
+		ms = ultimoMS
+		ultimoSufijo++
+	} else {
+		var aleatorio [8]byte
+		rand.Read(aleatorio[:])
+		// Con el bit alto en 0 el sufijo no se desborda al incrementarlo
+		ultimoMS, ultimoSufijo = ms, binary.BigEndian.Uint64(aleatorio[:])>>1
+	}
+	return fmt.Sprintf("%012x%016x", ms, ultimoSufijo)
+}
+
+// IDValido indica si id tiene el formato de NuevoID
+func IDValido(id string) bool {
+	if len(id) != 28 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// Tamaños de página
+const (
+	TamanoPorDefecto = 20
+	TamanoMaximo     = 100
+)
+
+// normalizar aplica los límites de tamaño y valida el token
+func (p Pagina) normalizar() (Pagina, error) {
+	if p.Tamano <= 0 {
+		p.Tamano = TamanoPorDefecto
+	}
+	p.Tamano = min(p.Tamano, TamanoMaximo)
+	if p.Token != "" && !IDValido(p.Token) {
+		return p, ErrTokenInvalido
+	}
+	return p, nil
+}
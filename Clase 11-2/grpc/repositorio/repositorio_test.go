@@ -0,0 +1,201 @@
+package repositorio
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// implementaciones devuelve un repositorio vacío de cada tipo
+func implementaciones(t *testing.T) map[string]Repositorio {
+	t.Helper()
+	sqlite, err := NuevoSQLite(filepath.Join(t.TempDir(), "tweets.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	return map[string]Repositorio{"memoria": NuevaMemoria(0), "sqlite": sqlite}
+}
+
+// cargar guarda los tweets con ids crecientes y devuelve los ids en orden
+func cargar(t *testing.T, r Repositorio, tweets []Tweet) []string {
+	t.Helper()
+	inicio := time.Now()
+	var ids []string
+	for i, tw := range tweets {
+		tw.CreatedAt = inicio.Add(time.Duration(i) * time.Millisecond)
+		tw.ID = NuevoID(tw.CreatedAt)
+		if err := r.Guardar(context.Background(), tw); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, tw.ID)
+	}
+	return ids
+}
+
+var muestra = []Tweet{
+	{Description: "uno", Country: "Guatemala", Weather: "soleado"},
+	{Description: "dos", Country: "México", Weather: "lluvioso"},
+	{Description: "tres", Country: "guatemala", Weather: "Nublado"},
+	{Description: "cuatro", Country: "Guatemala", Weather: "lluvioso"},
+	{Description: "cinco", Country: "El Salvador", Weather: "soleado"},
+	{Description: "seis", Country: "GUATEMALA", Weather: "soleado"},
+}
+
+func TestListar(t *testing.T) {
+	casos := []struct {
+		nombre string
+		filtro Filtro
+		desea  []string // descripciones, del más nuevo al más viejo
+	}{
+		{"sin filtro", Filtro{}, []string{"seis", "cinco", "cuatro", "tres", "dos", "uno"}},
+		{"país sin distinguir mayúsculas", Filtro{Country: "guatemala"}, []string{"seis", "cuatro", "tres", "uno"}},
+		{"clima", Filtro{Weather: "LLUVIOSO"}, []string{"cuatro", "dos"}},
+		{"país y clima", Filtro{Country: "Guatemala", Weather: "soleado"}, []string{"seis", "uno"}},
+		{"sin resultados", Filtro{Country: "Honduras"}, nil},
+	}
+	for nombre, r := range implementaciones(t) {
+		cargar(t, r, muestra)
+		for _, caso := range casos {
+			t.Run(nombre+"/"+caso.nombre, func(t *testing.T) {
+				pagina, siguiente, err := r.Listar(context.Background(), caso.filtro, Pagina{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if siguiente != "" {
+					t.Errorf("no debería haber página siguiente: %q", siguiente)
+				}
+				if got := descripciones(pagina); !slices.Equal(got, caso.desea) {
+					t.Errorf("se listó %v, se esperaba %v", got, caso.desea)
+				}
+			})
+		}
+	}
+}
+
+func TestListarPaginas(t *testing.T) {
+	for nombre, r := range implementaciones(t) {
+		t.Run(nombre, func(t *testing.T) {
+			cargar(t, r, muestra)
+			ctx := context.Background()
+
+			// Páginas de dos tweets de Guatemala: seis, cuatro | tres, uno
+			filtro := Filtro{Country: "Guatemala"}
+			pagina, token, err := r.Listar(ctx, filtro, Pagina{Tamano: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := descripciones(pagina); !slices.Equal(got, []string{"seis", "cuatro"}) || token != pagina[1].ID {
+				t.Fatalf("primera página %v, token %q", got, token)
+			}
+			pagina, token, err = r.Listar(ctx, filtro, Pagina{Tamano: 2, Token: token})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := descripciones(pagina); !slices.Equal(got, []string{"tres", "uno"}) || token != "" {
+				t.Fatalf("segunda página %v, token %q", got, token)
+			}
+
+			// El token sigue sirviendo aunque se haya borrado ese tweet
+			todos, _, _ := r.Listar(ctx, Filtro{}, Pagina{Tamano: 3})
+			if err := r.Eliminar(ctx, todos[2].ID); err != nil {
+				t.Fatal(err)
+			}
+			pagina, _, err = r.Listar(ctx, Filtro{}, Pagina{Tamano: 10, Token: todos[2].ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := descripciones(pagina); !slices.Equal(got, []string{"tres", "dos", "uno"}) {
+				t.Fatalf("después de un token borrado se listó %v", got)
+			}
+
+			if _, _, err := r.Listar(ctx, Filtro{}, Pagina{Token: "no-es-un-id"}); !errors.Is(err, ErrTokenInvalido) {
+				t.Fatalf("token inválido: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestTamanoDePagina(t *testing.T) {
+	for nombre, r := range implementaciones(t) {
+		t.Run(nombre, func(t *testing.T) {
+			cargar(t, r, make([]Tweet, TamanoMaximo+5))
+			casos := []struct{ pedido, desea int }{
+				{0, TamanoPorDefecto},
+				{-1, TamanoPorDefecto},
+				{7, 7},
+				{TamanoMaximo + 50, TamanoMaximo},
+			}
+			for _, caso := range casos {
+				pagina, token, err := r.Listar(context.Background(), Filtro{}, Pagina{Tamano: caso.pedido})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(pagina) != caso.desea || token == "" {
+					t.Errorf("tamaño %d: %d tweets, token %q; se esperaban %d y token", caso.pedido, len(pagina), token, caso.desea)
+				}
+			}
+		})
+	}
+}
+
+func TestObtenerYEliminar(t *testing.T) {
+	for nombre, r := range implementaciones(t) {
+		t.Run(nombre, func(t *testing.T) {
+			ctx := context.Background()
+			ids := cargar(t, r, muestra)
+
+			tw, err := r.Obtener(ctx, ids[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			desea := muestra[1]
+			if tw.ID != ids[1] || tw.Description != desea.Description || tw.Country != desea.Country || tw.Weather != desea.Weather || tw.CreatedAt.IsZero() {
+				t.Fatalf("se obtuvo %+v", tw)
+			}
+
+			if err := r.Eliminar(ctx, ids[1]); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Obtener(ctx, ids[1]); !errors.Is(err, ErrNoEncontrado) {
+				t.Fatalf("obtener un tweet borrado: err = %v", err)
+			}
+			if err := r.Eliminar(ctx, ids[1]); !errors.Is(err, ErrNoEncontrado) {
+				t.Fatalf("borrar dos veces: err = %v", err)
+			}
+			if _, err := r.Obtener(ctx, NuevoID(time.Now().Add(time.Hour))); !errors.Is(err, ErrNoEncontrado) {
+				t.Fatalf("obtener un id que no existe: err = %v", err)
+			}
+			pagina, _, _ := r.Listar(ctx, Filtro{}, Pagina{})
+			if len(pagina) != len(muestra)-1 {
+				t.Fatalf("quedaron %d tweets, se esperaban %d", len(pagina), len(muestra)-1)
+			}
+		})
+	}
+}
+
+func TestIDsOrdenados(t *testing.T) {
+	ahora := time.Now()
+	anterior := NuevoID(ahora)
+	for i := 0; i < 1000; i++ {
+		id := NuevoID(ahora)
+		if !IDValido(id) || id <= anterior {
+			t.Fatalf("id %q inválido o no mayor que %q", id, anterior)
+		}
+		anterior = id
+	}
+	if IDValido("123") || IDValido("zzzzzzzzzzzzzzzzzzzzzzzzzzzz") {
+		t.Fatal("se aceptó un id con formato inválido")
+	}
+}
+
+func descripciones(tweets []Tweet) []string {
+	var d []string
+	for _, tw := range tweets {
+		d = append(d, tw.Description)
+	}
+	return d
+}
@@ -0,0 +1,144 @@
+package repositorio
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite guarda los tweets en la tabla tweets de un archivo SQLite
+type SQLite struct {
+	db *sql.DB
+}
+
+// NuevoSQLite abre (o crea) la base en ruta. WAL y busy_timeout permiten
+// leer mientras otro RPC escribe.
+func NuevoSQLite(ruta string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", ruta+"?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL")
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo base de datos %s: %v", ruta, err)
+	}
+
+	// country y weather con NOCASE para que los filtros no distingan mayúsculas
+	// y usen los índices
+	createTable := `
+	CREATE TABLE IF NOT EXISTS tweets (
+		id TEXT PRIMARY KEY,
+		description TEXT NOT NULL,
+		country TEXT NOT NULL COLLATE NOCASE,
+		weather TEXT NOT NULL COLLATE NOCASE,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_tweets_country ON tweets (country, id);
+	CREATE INDEX IF NOT EXISTS idx_tweets_weather ON tweets (weather, id);`
+	if _, err := db.Exec(createTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creando tabla tweets: %v", err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Guardar(ctx context.Context, t Tweet) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO tweets (id, description, country, weather, created_at) VALUES (?, ?, ?, ?, ?)",
+		t.ID, t.Description, t.Country, t.Weather, t.CreatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("error guardando tweet: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLite) Obtener(ctx context.Context, id string) (Tweet, error) {
+	fila := s.db.QueryRowContext(ctx,
+		"SELECT id, description, country, weather, created_at FROM tweets WHERE id = ?", id)
+	t, err := escanear(fila)
+	if err == sql.ErrNoRows {
+		return Tweet{}, ErrNoEncontrado
+	}
+	if err != nil {
+		return Tweet{}, fmt.Errorf("error leyendo tweet %s: %v", id, err)
+	}
+	return t, nil
+}
+
+func (s *SQLite) Listar(ctx context.Context, f Filtro, p Pagina) ([]Tweet, string, error) {
+	p, err := p.normalizar()
+	if err != nil {
+		return nil, "", err
+	}
+
+	consulta := "SELECT id, description, country, weather, created_at FROM tweets WHERE 1 = 1"
+	var args []any
+	if f.Country != "" {
+		consulta += " AND country = ?"
+		args = append(args, f.Country)
+	}
+	if f.Weather != "" {
+		consulta += " AND weather = ?"
+		args = append(args, f.Weather)
+	}
+	if p.Token != "" {
+		consulta += " AND id < ?"
+		args = append(args, p.Token)
+	}
+	// Se pide uno más para saber si hay página siguiente
+	consulta += " ORDER BY id DESC LIMIT ?"
+	args = append(args, p.Tamano+1)
+
+	filas, err := s.db.QueryContext(ctx, consulta, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("error listando tweets: %v", err)
+	}
+	defer filas.Close()
+
+	var pagina []Tweet
+	for filas.Next() {
+		t, err := escanear(filas)
+		if err != nil {
+			return nil, "", fmt.Errorf("error listando tweets: %v", err)
+		}
+		pagina = append(pagina, t)
+	}
+	if err := filas.Err(); err != nil {
+		return nil, "", fmt.Errorf("error listando tweets: %v", err)
+	}
+
+	if len(pagina) > p.Tamano {
+		pagina = pagina[:p.Tamano]
+		return pagina, pagina[len(pagina)-1].ID, nil
+	}
+	return pagina, "", nil
+}
+
+func (s *SQLite) Eliminar(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM tweets WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error eliminando tweet %s: %v", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoEncontrado
+	}
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// escaneable es *sql.Row o *sql.Rows
+type escaneable interface {
+	Scan(dest ...any) error
+}
+
+func escanear(e escaneable) (Tweet, error) {
+	var t Tweet
+	var creado int64
+	if err := e.Scan(&t.ID, &t.Description, &t.Country, &t.Weather, &creado); err != nil {
+		return Tweet{}, err
+	}
+	t.CreatedAt = time.UnixMilli(creado)
+	return t, nil
+}
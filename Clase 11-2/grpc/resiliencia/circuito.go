@@ -0,0 +1,93 @@
+package resiliencia
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Circuito deja de llamar al servidor gRPC cuando falla seguido. Después de
+// fallas errores seguidos se abre por espera: las llamadas se rechazan sin
+// intentar. Pasada la espera deja pasar una sola llamada de prueba; si
+// funciona se cierra y si no se vuelve a abrir.
+type Circuito struct {
+	fallas int
+	espera time.Duration
+
+	mu           sync.Mutex
+	seguidas     int
+	abiertoHasta time.Time
+	probando     bool
+}
+
+// NuevoCircuito crea un circuito cerrado
+func NuevoCircuito(fallas int, espera time.Duration) *Circuito {
+	return &Circuito{fallas: max(fallas, 1), espera: espera}
+}
+
+// Permitir indica si se puede llamar al servidor. Si se puede, terminar se
+// llama con el resultado de la llamada; si no, espera es cuánto falta para
+// que el circuito vuelva a probar.
+func (c *Circuito) Permitir() (terminar func(err error), espera time.Duration, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.abiertoHasta.IsZero() {
+		return func(err error) { c.terminar(err, false) }, 0, true
+	}
+	if falta := time.Until(c.abiertoHasta); falta > 0 {
+		return nil, falta, false
+	}
+	if c.probando {
+		// Ya hay una llamada de prueba en curso
+		return nil, 0, false
+	}
+	c.probando = true
+	return func(err error) { c.terminar(err, true) }, 0, true
+}
+
+func (c *Circuito) terminar(err error, prueba bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prueba {
+		c.probando = false
+		if Falla(err) {
+			c.abiertoHasta = time.Now().Add(c.espera)
+			log.Printf("Circuito abierto de nuevo: la llamada de prueba falló (%v); se prueba otra vez en %s", status.Code(err), c.espera)
+			return
+		}
+		c.abiertoHasta, c.seguidas = time.Time{}, 0
+		log.Printf("Circuito cerrado: el servidor gRPC volvió a responder")
+		return
+	}
+
+	// Las llamadas que empezaron antes de abrir el circuito no lo cambian
+	if !c.abiertoHasta.IsZero() {
+		return
+	}
+	if !Falla(err) {
+		c.seguidas = 0
+		return
+	}
+	c.seguidas++
+	if c.seguidas >= c.fallas {
+		c.abiertoHasta = time.Now().Add(c.espera)
+		log.Printf("Circuito abierto: %d fallas seguidas del servidor gRPC (la última %v); se prueba otra vez en %s",
+			c.seguidas, status.Code(err), c.espera)
+	}
+}
+
+// Falla indica si err muestra que el servidor no está sano. Los errores de
+// los datos (INVALID_ARGUMENT, PERMISSION_DENIED...) son respuestas normales
+// y CANCELED es del cliente HTTP que se fue.
+func Falla(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
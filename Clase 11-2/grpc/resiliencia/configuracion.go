@@ -0,0 +1,79 @@
+// Package resiliencia hace que el puente REST→gRPC aguante servidores lentos
+// o caídos: reintentos en UNAVAILABLE con la service config de gRPC, hedging
+// opcional (otra copia de la llamada si la primera tarda) y un circuito que
+// deja de llamar al servidor mientras falla.
+package resiliencia
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Reintentos de la service config; solo se reintenta UNAVAILABLE, que es
+// cuando el tweet no llegó a procesarse (servidor caído o conexión rechazada)
+type Reintentos struct {
+	Intentos int
+	// Espera antes del primer reintento; se duplica en cada uno hasta EsperaMaxima
+	Espera       time.Duration
+	EsperaMaxima time.Duration
+}
+
+type servicioJSON struct {
+	Service string `json:"service"`
+}
+
+type politicaReintentosJSON struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type metodoJSON struct {
+	Name        []servicioJSON          `json:"name"`
+	RetryPolicy *politicaReintentosJSON `json:"retryPolicy,omitempty"`
+}
+
+type limiteReintentosJSON struct {
+	MaxTokens  float64 `json:"maxTokens"`
+	TokenRatio float64 `json:"tokenRatio"`
+}
+
+type configuracionJSON struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig,omitempty"`
+	MethodConfig        []metodoJSON          `json:"methodConfig"`
+	RetryThrottling     *limiteReintentosJSON `json:"retryThrottling,omitempty"`
+}
+
+// ConfiguracionServicio arma la service config de TweetService para
+// grpc.WithDefaultServiceConfig. Con un solo intento no hay reintentos.
+// retryThrottling deja de reintentar si más del 10% de las llamadas fallan,
+// para no multiplicar la carga de un servidor que ya no da abasto.
+// balanceo es la política de balanceo de gRPC (round_robin, pick_first);
+// vacío deja la de gRPC, que manda todo a la primera dirección.
+func ConfiguracionServicio(r Reintentos, balanceo string) string {
+	metodo := metodoJSON{Name: []servicioJSON{{Service: "tweet.TweetService"}}}
+	config := configuracionJSON{MethodConfig: []metodoJSON{metodo}}
+	if balanceo != "" {
+		config.LoadBalancingConfig = []map[string]struct{}{{balanceo: {}}}
+	}
+	if r.Intentos > 1 {
+		config.MethodConfig[0].RetryPolicy = &politicaReintentosJSON{
+			MaxAttempts:          r.Intentos,
+			InitialBackoff:       segundos(r.Espera),
+			MaxBackoff:           segundos(r.EsperaMaxima),
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []string{"UNAVAILABLE"},
+		}
+		config.RetryThrottling = &limiteReintentosJSON{MaxTokens: maxTokens, TokenRatio: tokensExito}
+	}
+	texto, _ := json.Marshal(config)
+	return string(texto)
+}
+
+// segundos escribe una duración como la espera la service config ("0.1s")
+func segundos(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
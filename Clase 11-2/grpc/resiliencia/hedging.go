@@ -0,0 +1,137 @@
+package resiliencia
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Hedging devuelve un interceptor unario que manda otra copia de la llamada
+// si la anterior no respondió en demora, hasta intentos copias, y se queda
+// con la primera respuesta. grpc-go no implementa la hedgingPolicy de la
+// service config, por eso se hace aquí.
+//
+// Cada copia tiene su propio deadline de porIntento (0 para usar solo el de
+// la llamada). Una copia que falla con UNAVAILABLE o agota su deadline no
+// termina la llamada: se manda la siguiente de inmediato. Cualquier otro
+// error se devuelve tal cual.
+//
+// Las copias que pierden se cancelan, pero el servidor puede haberlas
+// procesado: con SendTweet eso guarda el tweet repetido.
+func Hedging(intentos int, demora, porIntento time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, metodo string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		respuesta, ok := reply.(proto.Message)
+		if !ok || intentos <= 1 {
+			return invoker(ctx, metodo, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		resultados := make(chan *copia, intentos)
+		lanzadas, pendientes := 0, 0
+		lanzar := func() {
+			lanzadas++
+			pendientes++
+			c := nuevaCopia(respuesta, opts)
+			go func() {
+				ctxCopia := ctx
+				if porIntento > 0 {
+					var cancelCopia context.CancelFunc
+					ctxCopia, cancelCopia = context.WithTimeout(ctx, porIntento)
+					defer cancelCopia()
+				}
+				c.err = invoker(ctxCopia, metodo, req, c.reply, cc, c.opts...)
+				resultados <- c
+			}()
+		}
+
+		lanzar()
+		temporizador := time.NewTimer(demora)
+		defer temporizador.Stop()
+
+		var ultimoErr error
+		for {
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-temporizador.C:
+				if lanzadas < intentos {
+					lanzar()
+					temporizador.Reset(demora)
+				}
+			case c := <-resultados:
+				pendientes--
+				if c.err == nil || !reintentable(ctx, c.err) {
+					c.entregar(respuesta, opts)
+					return c.err
+				}
+				ultimoErr = c.err
+				if lanzadas < intentos {
+					lanzar()
+					temporizador.Reset(demora)
+				} else if pendientes == 0 {
+					return ultimoErr
+				}
+			}
+		}
+	}
+}
+
+// reintentable indica si vale la pena mandar otra copia después de err
+func reintentable(ctx context.Context, err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.DeadlineExceeded:
+		// Solo si venció el deadline de la copia y no el de la llamada
+		return ctx.Err() == nil
+	}
+	return false
+}
+
+// copia es un intento con su propia respuesta y sus propios header y
+// trailer, para que las copias no escriban a la vez en los de la llamada
+type copia struct {
+	reply   proto.Message
+	opts    []grpc.CallOption
+	header  metadata.MD
+	trailer metadata.MD
+	err     error
+}
+
+func nuevaCopia(respuesta proto.Message, opts []grpc.CallOption) *copia {
+	c := &copia{reply: respuesta.ProtoReflect().New().Interface()}
+	for _, o := range opts {
+		switch o.(type) {
+		case grpc.HeaderCallOption:
+			c.opts = append(c.opts, grpc.Header(&c.header))
+		case grpc.TrailerCallOption:
+			c.opts = append(c.opts, grpc.Trailer(&c.trailer))
+		default:
+			c.opts = append(c.opts, o)
+		}
+	}
+	return c
+}
+
+// entregar copia la respuesta, el header y el trailer de la copia ganadora
+// a los de la llamada
+func (c *copia) entregar(respuesta proto.Message, opts []grpc.CallOption) {
+	if c.err == nil {
+		proto.Merge(respuesta, c.reply)
+	}
+	for _, o := range opts {
+		switch o := o.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = c.header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = c.trailer
+		}
+	}
+}
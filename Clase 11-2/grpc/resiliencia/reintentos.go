@@ -0,0 +1,77 @@
+package resiliencia
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Reintentar devuelve un interceptor unario que hace hasta r.Intentos
+// intentos, cada uno con su propio deadline de porIntento dentro del de la
+// llamada. La service config de gRPC no tiene deadline por intento: un
+// intento colgado gastaría todo el deadline de la llamada y DEADLINE_EXCEEDED
+// no se reintenta. Aquí se reintenta UNAVAILABLE y el vencimiento del
+// deadline del intento, con la misma espera exponencial (con jitter) y el
+// mismo retryThrottling que la service config, que entonces debe ir sin
+// reintentos.
+//
+// Un intento que venció puede haber llegado al servidor: con SendTweet eso
+// guarda el tweet repetido, igual que con hedging.
+func Reintentar(r Reintentos, porIntento time.Duration) grpc.UnaryClientInterceptor {
+	limite := &limiteReintentos{tokens: maxTokens}
+	return func(ctx context.Context, metodo string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		espera := r.Espera
+		for intento := 1; ; intento++ {
+			ctxIntento, cancel := context.WithTimeout(ctx, porIntento)
+			err := invoker(ctxIntento, metodo, req, reply, cc, opts...)
+			cancel()
+			if err == nil || !reintentable(ctx, err) {
+				limite.exito()
+				return err
+			}
+			if !limite.falla() || intento >= r.Intentos {
+				return err
+			}
+
+			temporizador := time.NewTimer(time.Duration(rand.Int64N(int64(espera) + 1)))
+			select {
+			case <-ctx.Done():
+				temporizador.Stop()
+				return status.FromContextError(ctx.Err()).Err()
+			case <-temporizador.C:
+			}
+			espera = min(2*espera, r.EsperaMaxima)
+		}
+	}
+}
+
+// Mismos valores que el retryThrottling de ConfiguracionServicio
+const (
+	maxTokens   = 10
+	tokensExito = 0.1
+)
+
+// limiteReintentos es el retryThrottling de gRPC: cada falla gasta un token,
+// cada éxito devuelve una décima y no se reintenta con la mitad o menos
+type limiteReintentos struct {
+	mu     sync.Mutex
+	tokens float64
+}
+
+func (l *limiteReintentos) exito() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(maxTokens, l.tokens+tokensExito)
+}
+
+// falla descuenta la falla e indica si todavía se puede reintentar
+func (l *limiteReintentos) falla() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = max(0, l.tokens-1)
+	return l.tokens > maxTokens/2
+}
@@ -0,0 +1,327 @@
+package resiliencia
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "grpc/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errCaido    = status.Error(codes.Unavailable, "servidor caído")
+	errInvalido = status.Error(codes.InvalidArgument, "tweet inválido")
+)
+
+func TestCircuito(t *testing.T) {
+	c := NuevoCircuito(3, 50*time.Millisecond)
+	llamar := func(err error) bool {
+		terminar, _, ok := c.Permitir()
+		if ok {
+			terminar(err)
+		}
+		return ok
+	}
+
+	// Los errores de los datos son respuestas normales: reinician la cuenta
+	// igual que un éxito
+	for _, err := range []error{errCaido, errCaido, nil, errCaido, errCaido, errInvalido, errCaido, errCaido} {
+		if !llamar(err) {
+			t.Fatal("el circuito no debería abrirse sin 3 fallas seguidas")
+		}
+	}
+	llamar(errCaido)
+	_, espera, ok := c.Permitir()
+	if ok || espera <= 0 || espera > 50*time.Millisecond {
+		t.Fatalf("con 3 fallas seguidas debería abrirse: ok = %v, espera = %s", ok, espera)
+	}
+
+	// Medio abierto: una sola llamada de prueba; si falla se vuelve a abrir
+	time.Sleep(60 * time.Millisecond)
+	terminar, _, ok := c.Permitir()
+	if !ok {
+		t.Fatal("pasada la espera debería dejar pasar una llamada de prueba")
+	}
+	if _, _, ok := c.Permitir(); ok {
+		t.Fatal("con una prueba en curso no debería dejar pasar otra")
+	}
+	terminar(status.Error(codes.DeadlineExceeded, "lento"))
+	if _, _, ok := c.Permitir(); ok {
+		t.Fatal("si la prueba falla el circuito debería abrirse de nuevo")
+	}
+
+	// Si la prueba funciona se cierra
+	time.Sleep(60 * time.Millisecond)
+	if !llamar(nil) {
+		t.Fatal("debería dejar pasar la prueba")
+	}
+	for i := 0; i < 2; i++ {
+		if !llamar(errCaido) {
+			t.Fatal("cerrado, la cuenta de fallas empieza de cero")
+		}
+	}
+}
+
+func TestCircuitoIgnoraLlamadasViejas(t *testing.T) {
+	c := NuevoCircuito(1, time.Hour)
+	vieja, _, _ := c.Permitir()
+	nueva, _, _ := c.Permitir()
+	nueva(errCaido)
+	// Una llamada que empezó antes de abrir no lo cierra al terminar bien
+	vieja(nil)
+	if _, _, ok := c.Permitir(); ok {
+		t.Fatal("el circuito debería seguir abierto")
+	}
+}
+
+func TestFalla(t *testing.T) {
+	casos := map[codes.Code]bool{
+		codes.Unavailable:       true,
+		codes.DeadlineExceeded:  true,
+		codes.ResourceExhausted: true,
+		codes.Internal:          true,
+		codes.Unknown:           true,
+		codes.OK:                false,
+		codes.InvalidArgument:   false,
+		codes.PermissionDenied:  false,
+		codes.NotFound:          false,
+		codes.Canceled:          false,
+	}
+	for codigo, desea := range casos {
+		var err error
+		if codigo != codes.OK {
+			err = status.Error(codigo, "prueba")
+		}
+		if got := Falla(err); got != desea {
+			t.Errorf("%s: %v, se esperaba %v", codigo, got, desea)
+		}
+	}
+}
+
+func TestConfiguracionServicio(t *testing.T) {
+	r := Reintentos{Intentos: 3, Espera: 100 * time.Millisecond, EsperaMaxima: time.Second}
+	var config map[string]any
+	if err := json.Unmarshal([]byte(ConfiguracionServicio(r, "round_robin")), &config); err != nil {
+		t.Fatal(err)
+	}
+	desea := map[string]any{
+		"loadBalancingConfig": []any{map[string]any{"round_robin": map[string]any{}}},
+		"methodConfig": []any{map[string]any{
+			"name": []any{map[string]any{"service": "tweet.TweetService"}},
+			"retryPolicy": map[string]any{
+				"maxAttempts":          3.0,
+				"initialBackoff":       "0.1s",
+				"maxBackoff":           "1s",
+				"backoffMultiplier":    2.0,
+				"retryableStatusCodes": []any{"UNAVAILABLE"},
+			},
+		}},
+		"retryThrottling": map[string]any{"maxTokens": 10.0, "tokenRatio": 0.1},
+	}
+	if !reflect.DeepEqual(config, desea) {
+		t.Fatalf("service config:\n%v\nse esperaba\n%v", config, desea)
+	}
+
+	// Con un intento no hay reintentos y sin balanceo se deja el de gRPC
+	config = nil
+	json.Unmarshal([]byte(ConfiguracionServicio(Reintentos{Intentos: 1}, "")), &config)
+	desea = map[string]any{"methodConfig": []any{map[string]any{
+		"name": []any{map[string]any{"service": "tweet.TweetService"}},
+	}}}
+	if !reflect.DeepEqual(config, desea) {
+		t.Fatalf("service config sin reintentos: %v", config)
+	}
+
+	// gRPC acepta la service config
+	if _, err := grpc.NewClient("localhost:1", grpc.WithDefaultServiceConfig(ConfiguracionServicio(r, "round_robin")),
+		grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+		t.Fatalf("gRPC rechazó la service config: %v", err)
+	}
+}
+
+// invocador simula al servidor: cada intento llama a responder con su número
+func invocador(responder func(ctx context.Context, intento int, reply *pb.TweetResponse) error) (grpc.UnaryInvoker, *atomic.Int64) {
+	var intentos atomic.Int64
+	return func(ctx context.Context, metodo string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return responder(ctx, int(intentos.Add(1)), reply.(*pb.TweetResponse))
+	}, &intentos
+}
+
+func llamar(ctx context.Context, interceptor grpc.UnaryClientInterceptor, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (*pb.TweetResponse, error) {
+	respuesta := &pb.TweetResponse{}
+	err := interceptor(ctx, "/tweet.TweetService/SendTweet", &pb.TweetRequest{}, respuesta, nil, invoker, opts...)
+	return respuesta, err
+}
+
+func TestHedgingGanaLaPrimeraRespuesta(t *testing.T) {
+	canceladas := make(chan int, 3)
+	invoker, intentos := invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error {
+		if intento == 2 {
+			reply.Status = "copia 2"
+			return nil
+		}
+		// La primera copia no responde hasta que la cancelan
+		<-ctx.Done()
+		canceladas <- intento
+		return status.FromContextError(ctx.Err()).Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r, err := llamar(ctx, Hedging(3, 20*time.Millisecond, 0), invoker)
+	if err != nil || r.Status != "copia 2" {
+		t.Fatalf("respuesta %q, err = %v; se esperaba la de la copia 2", r.Status, err)
+	}
+	select {
+	case n := <-canceladas:
+		if n != 1 {
+			t.Fatalf("se canceló la copia %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("la copia que perdió debería cancelarse")
+	}
+	if n := intentos.Load(); n != 2 {
+		t.Fatalf("se mandaron %d copias, se esperaban 2", n)
+	}
+}
+
+func TestHedgingCopiaTrasUnavailable(t *testing.T) {
+	invoker, intentos := invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error {
+		if intento < 3 {
+			return errCaido
+		}
+		reply.Status = "ok"
+		return nil
+	})
+	// Con una demora larga las copias salen solo porque las anteriores fallaron
+	r, err := llamar(context.Background(), Hedging(3, time.Hour, 0), invoker)
+	if err != nil || r.Status != "ok" || intentos.Load() != 3 {
+		t.Fatalf("respuesta %q, err = %v, %d copias", r.Status, err, intentos.Load())
+	}
+}
+
+func TestHedgingNoReintentaErroresDeDatos(t *testing.T) {
+	invoker, intentos := invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error {
+		return errInvalido
+	})
+	if _, err := llamar(context.Background(), Hedging(3, time.Hour, 0), invoker); status.Code(err) != codes.InvalidArgument || intentos.Load() != 1 {
+		t.Fatalf("err = %v después de %d copias", err, intentos.Load())
+	}
+}
+
+func TestHedgingDeadlinePorCopia(t *testing.T) {
+	invoker, intentos := invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error {
+		if intento == 1 {
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.Status = "ok"
+		return nil
+	})
+	inicio := time.Now()
+	r, err := llamar(context.Background(), Hedging(2, time.Hour, 20*time.Millisecond), invoker)
+	if err != nil || r.Status != "ok" || intentos.Load() != 2 {
+		t.Fatalf("respuesta %q, err = %v, %d copias", r.Status, err, intentos.Load())
+	}
+	if d := time.Since(inicio); d > 500*time.Millisecond {
+		t.Fatalf("la segunda copia debería salir al vencer el deadline de la primera, tardó %s", d)
+	}
+}
+
+func TestHedgingEntregaElTrailerDeLaGanadora(t *testing.T) {
+	// Cada copia escribe su número en su propio trailer; la segunda gana
+	var intentos atomic.Int64
+	invoker := func(ctx context.Context, metodo string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		intento := intentos.Add(1)
+		for _, o := range opts {
+			if t, ok := o.(grpc.TrailerCallOption); ok {
+				*t.TrailerAddr = metadata.Pairs("copia", strconv.FormatInt(intento, 10))
+			}
+		}
+		if intento == 1 {
+			return errCaido
+		}
+		return nil
+	}
+	var trailer metadata.MD
+	if _, err := llamar(context.Background(), Hedging(2, time.Hour, 0), invoker, grpc.Trailer(&trailer)); err != nil {
+		t.Fatal(err)
+	}
+	if got := trailer.Get("copia"); len(got) != 1 || got[0] != "2" {
+		t.Fatalf("trailer %v, se esperaba el de la copia 2", trailer)
+	}
+}
+
+func TestReintentarConDeadlinePorIntento(t *testing.T) {
+	invoker, intentos := invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error {
+		switch intento {
+		case 1:
+			// Réplica colgada: solo responde cuando vence el deadline del intento
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		case 2:
+			return errCaido
+		}
+		reply.Status = "ok"
+		return nil
+	})
+	r := Reintentos{Intentos: 3, Espera: time.Millisecond, EsperaMaxima: 10 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	inicio := time.Now()
+	respuesta, err := llamar(ctx, Reintentar(r, 20*time.Millisecond), invoker)
+	if err != nil || respuesta.Status != "ok" || intentos.Load() != 3 {
+		t.Fatalf("respuesta %q, err = %v, %d intentos", respuesta.Status, err, intentos.Load())
+	}
+	if d := time.Since(inicio); d > time.Second {
+		t.Fatalf("el intento colgado debería cortarse a los 20ms, la llamada tardó %s", d)
+	}
+}
+
+func TestReintentarRespetaLosLimites(t *testing.T) {
+	r := Reintentos{Intentos: 3, Espera: time.Millisecond, EsperaMaxima: time.Millisecond}
+
+	// Los errores de los datos no se reintentan
+	invoker, intentos := invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error { return errInvalido })
+	if _, err := llamar(context.Background(), Reintentar(r, time.Second), invoker); status.Code(err) != codes.InvalidArgument || intentos.Load() != 1 {
+		t.Fatalf("err = %v después de %d intentos", err, intentos.Load())
+	}
+
+	// Como mucho r.Intentos
+	invoker, intentos = invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error { return errCaido })
+	reintentar := Reintentar(r, time.Second)
+	if _, err := llamar(context.Background(), reintentar, invoker); status.Code(err) != codes.Unavailable || intentos.Load() != 3 {
+		t.Fatalf("err = %v después de %d intentos", err, intentos.Load())
+	}
+
+	// Con muchas fallas se deja de reintentar (retryThrottling)
+	for i := 0; i < 10; i++ {
+		llamar(context.Background(), reintentar, invoker)
+	}
+	intentos.Store(0)
+	llamar(context.Background(), reintentar, invoker)
+	if n := intentos.Load(); n != 1 {
+		t.Fatalf("con el límite agotado se hicieron %d intentos", n)
+	}
+
+	// Si vence el deadline de la llamada no se reintenta
+	invoker, intentos = invocador(func(ctx context.Context, intento int, reply *pb.TweetResponse) error {
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := llamar(ctx, Reintentar(r, time.Second), invoker); status.Code(err) != codes.DeadlineExceeded || intentos.Load() != 1 {
+		t.Fatalf("err = %v después de %d intentos", err, intentos.Load())
+	}
+}
@@ -0,0 +1,278 @@
+// Package seguridad arma las credenciales TLS del servidor y del cliente gRPC
+// a partir de archivos PEM. Los archivos se vuelven a leer cuando cambian
+// (por ejemplo cuando Kubernetes actualiza el Secret montado), así que los
+// certificados se pueden rotar sin reiniciar los pods: las conexiones nuevas
+// usan los certificados nuevos y las abiertas siguen con los anteriores.
+package seguridad
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// Archivos son las rutas de los PEM. En el servidor Cert y Key son
+// obligatorios y CA activa mTLS (exige certificado de cliente firmado por esa
+// CA). En el cliente CA verifica al servidor (sin CA se usan las del sistema)
+// y Cert/Key son el certificado del cliente para mTLS.
+type Archivos struct {
+	Cert string
+	Key  string
+	CA   string
+}
+
+// ArchivosDeEntorno lee TLS_CERT, TLS_KEY y TLS_CA
+func ArchivosDeEntorno() Archivos {
+	return Archivos{
+		Cert: os.Getenv("TLS_CERT"),
+		Key:  os.Getenv("TLS_KEY"),
+		CA:   os.Getenv("TLS_CA"),
+	}
+}
+
+// Activo indica si se configuró algún archivo; si no, se usa texto plano
+func (a Archivos) Activo() bool {
+	return a.Cert != "" || a.Key != "" || a.CA != ""
+}
+
+func (a Archivos) validar() error {
+	if (a.Cert == "") != (a.Key == "") {
+		return errors.New("TLS_CERT y TLS_KEY van juntos")
+	}
+	return nil
+}
+
+// DeEntorno lee los archivos de TLS_CERT, TLS_KEY y TLS_CA y los revisa cada
+// TLS_RECARGA (30s por defecto) hasta que ctx termine. Devuelve nil, nil si
+// no hay ninguno configurado.
+func DeEntorno(ctx context.Context) (*Recargador, error) {
+	archivos := ArchivosDeEntorno()
+	if !archivos.Activo() {
+		return nil, nil
+	}
+	r, err := NuevoRecargador(archivos)
+	if err != nil {
+		return nil, err
+	}
+	intervalo, err := time.ParseDuration(os.Getenv("TLS_RECARGA"))
+	if err != nil || intervalo <= 0 {
+		intervalo = 30 * time.Second
+	}
+	go r.Vigilar(ctx, intervalo)
+	return r, nil
+}
+
+// Recargador guarda el certificado y la CA vigentes y los vuelve a leer
+// cuando cambian los archivos
+type Recargador struct {
+	archivos Archivos
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	ca      *x509.CertPool
+	version map[string]time.Time
+}
+
+// NuevoRecargador lee los archivos por primera vez
+func NuevoRecargador(a Archivos) (*Recargador, error) {
+	if err := a.validar(); err != nil {
+		return nil, err
+	}
+	r := &Recargador{archivos: a}
+	if err := r.cargar(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// cargar lee los PEM y, si todos son válidos, reemplaza los vigentes
+func (r *Recargador) cargar() error {
+	version, err := r.versionArchivos()
+	if err != nil {
+		return err
+	}
+
+	var cert *tls.Certificate
+	if r.archivos.Cert != "" {
+		c, err := tls.LoadX509KeyPair(r.archivos.Cert, r.archivos.Key)
+		if err != nil {
+			return fmt.Errorf("cargando certificado: %w", err)
+		}
+		cert = &c
+	}
+
+	var ca *x509.CertPool
+	if r.archivos.CA != "" {
+		pem, err := os.ReadFile(r.archivos.CA)
+		if err != nil {
+			return fmt.Errorf("leyendo CA: %w", err)
+		}
+		ca = x509.NewCertPool()
+		if !ca.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s no tiene certificados PEM", r.archivos.CA)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.ca, r.version = cert, ca, version
+	r.mu.Unlock()
+	return nil
+}
+
+// versionArchivos devuelve la fecha de modificación de cada archivo
+func (r *Recargador) versionArchivos() (map[string]time.Time, error) {
+	version := make(map[string]time.Time)
+	for _, ruta := range []string{r.archivos.Cert, r.archivos.Key, r.archivos.CA} {
+		if ruta == "" {
+			continue
+		}
+		info, err := os.Stat(ruta)
+		if err != nil {
+			return nil, err
+		}
+		version[ruta] = info.ModTime()
+	}
+	return version, nil
+}
+
+func (r *Recargador) marcarVistos() {
+	if version, err := r.versionArchivos(); err == nil {
+		r.mu.Lock()
+		r.version = version
+		r.mu.Unlock()
+	}
+}
+
+func (r *Recargador) cambiaron() bool {
+	version, err := r.versionArchivos()
+	if err != nil {
+		// Durante la rotación un archivo puede faltar un momento; se reintenta
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for ruta, t := range version {
+		if !t.Equal(r.version[ruta]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Vigilar revisa los archivos cada intervalo hasta que ctx termine. Si los
+// archivos nuevos son inválidos se siguen usando los anteriores.
+func (r *Recargador) Vigilar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.cambiaron() {
+				continue
+			}
+			if err := r.cargar(); err != nil {
+				log.Printf("Certificados nuevos inválidos, se siguen usando los anteriores: %v", err)
+				// No se reintenta hasta que los archivos vuelvan a cambiar
+				r.marcarVistos()
+				continue
+			}
+			log.Printf("Certificados TLS recargados")
+		}
+	}
+}
+
+// MTLS indica si el servidor exige certificado de cliente
+func (r *Recargador) MTLS() bool {
+	return r.archivos.Cert != "" && r.archivos.CA != ""
+}
+
+// ConCertificado indica si el cliente presenta un certificado propio
+func (r *Recargador) ConCertificado() bool {
+	return r.archivos.Cert != ""
+}
+
+func (r *Recargador) vigentes() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.ca
+}
+
+// Servidor devuelve las credenciales del servidor gRPC. Cada conexión nueva
+// usa el certificado y la CA de clientes vigentes.
+func (r *Recargador) Servidor() (credentials.TransportCredentials, error) {
+	if r.archivos.Cert == "" {
+		return nil, errors.New("el servidor necesita TLS_CERT y TLS_KEY")
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, ca := r.vigentes()
+			c := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				NextProtos:   []string{"h2"},
+			}
+			if ca != nil {
+				c.ClientAuth = tls.RequireAndVerifyClientCert
+				c.ClientCAs = ca
+			}
+			return c, nil
+		},
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// Cliente devuelve las credenciales del cliente gRPC. nombre reemplaza al
+// host de la dirección al verificar el certificado del servidor (vacío para
+// usar el host).
+func (r *Recargador) Cliente(nombre string) credentials.TransportCredentials {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: nombre,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.vigentes()
+			if cert == nil {
+				// Sin certificado propio; el servidor decide si lo acepta
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+	}
+	if r.archivos.CA != "" {
+		// crypto/tls no permite cambiar RootCAs después de crear las
+		// credenciales, así que la verificación se hace a mano con la CA
+		// vigente en cada conexión
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = r.verificarServidor
+	}
+	return credentials.NewTLS(config)
+}
+
+// verificarServidor hace la misma verificación que crypto/tls (cadena y
+// nombre) pero con la CA vigente
+func (r *Recargador) verificarServidor(estado tls.ConnectionState) error {
+	if len(estado.PeerCertificates) == 0 {
+		return errors.New("el servidor no envió certificado")
+	}
+	_, ca := r.vigentes()
+	intermedios := x509.NewCertPool()
+	for _, c := range estado.PeerCertificates[1:] {
+		intermedios.AddCert(c)
+	}
+	_, err := estado.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       estado.ServerName,
+		Roots:         ca,
+		Intermediates: intermedios,
+	})
+	return err
+}
@@ -0,0 +1,274 @@
+package seguridad
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ca es una CA de prueba que firma certificados para localhost
+type ca struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func nuevaCA(t *testing.T, nombre string) *ca {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plantilla := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: nombre},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, plantilla, plantilla, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &ca{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// firmar devuelve el certificado y la clave (PEM) de un servidor o cliente
+func (c *ca) firmar(t *testing.T, nombre string, uso x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plantilla := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: nombre},
+		DNSNames:     []string{nombre},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{uso},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, plantilla, c.cert, &key.PublicKey, c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// escrituras adelanta la fecha de cada archivo escrito un segundo más
+var escrituras atomic.Int64
+
+// escribir reemplaza el archivo y adelanta su fecha de modificación para que
+// el Recargador vea el cambio aunque el sistema de archivos tenga poca resolución
+func escribir(t *testing.T, ruta string, contenido []byte) {
+	t.Helper()
+	if err := os.WriteFile(ruta, contenido, 0600); err != nil {
+		t.Fatal(err)
+	}
+	futuro := time.Now().Add(time.Duration(escrituras.Add(1)) * time.Second)
+	if err := os.Chtimes(ruta, futuro, futuro); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// archivosServidor escribe el certificado de localhost firmado por c y, con
+// clientes, la CA que exige a los clientes (mTLS)
+func archivosServidor(t *testing.T, c *ca, clientes *ca) Archivos {
+	dir := t.TempDir()
+	a := Archivos{Cert: filepath.Join(dir, "tls.crt"), Key: filepath.Join(dir, "tls.key")}
+	cert, key := c.firmar(t, "localhost", x509.ExtKeyUsageServerAuth)
+	escribir(t, a.Cert, cert)
+	escribir(t, a.Key, key)
+	if clientes != nil {
+		a.CA = filepath.Join(dir, "ca.crt")
+		escribir(t, a.CA, clientes.pem)
+	}
+	return a
+}
+
+// archivosCliente escribe la CA del servidor y, con firmante, el certificado del cliente
+func archivosCliente(t *testing.T, servidor *ca, firmante *ca) Archivos {
+	dir := t.TempDir()
+	a := Archivos{CA: filepath.Join(dir, "ca.crt")}
+	escribir(t, a.CA, servidor.pem)
+	if firmante != nil {
+		a.Cert, a.Key = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+		cert, key := firmante.firmar(t, "grpc-client", x509.ExtKeyUsageClientAuth)
+		escribir(t, a.Cert, cert)
+		escribir(t, a.Key, key)
+	}
+	return a
+}
+
+// levantar arranca un servidor gRPC con el servicio de health en localhost
+func levantar(t *testing.T, r *Recargador) string {
+	t.Helper()
+	creds, err := r.Servidor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.Creds(creds))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// llamar abre una conexión nueva (un handshake nuevo) y hace un Check
+func llamar(direccion string, creds credentials.TransportCredentials) error {
+	conn, err := grpc.NewClient(direccion, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func recargador(t *testing.T, a Archivos) *Recargador {
+	t.Helper()
+	r, err := NuevoRecargador(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// esperar reintenta la llamada hasta que el resultado sea el esperado
+func esperar(t *testing.T, direccion string, cliente *Recargador, debeFuncionar bool) {
+	t.Helper()
+	var err error
+	for limite := time.Now().Add(5 * time.Second); time.Now().Before(limite); time.Sleep(20 * time.Millisecond) {
+		if err = llamar(direccion, cliente.Cliente("localhost")); (err == nil) == debeFuncionar {
+			return
+		}
+	}
+	t.Fatalf("después de recargar: err = %v, se esperaba que funcione = %v", err, debeFuncionar)
+}
+
+func TestTLS(t *testing.T) {
+	autoridad := nuevaCA(t, "ca")
+	servidor := recargador(t, archivosServidor(t, autoridad, nil))
+	if servidor.MTLS() {
+		t.Fatal("sin CA de clientes no es mTLS")
+	}
+	direccion := levantar(t, servidor)
+
+	if err := llamar(direccion, recargador(t, archivosCliente(t, autoridad, nil)).Cliente("localhost")); err != nil {
+		t.Fatalf("cliente con la CA del servidor: %v", err)
+	}
+	if err := llamar(direccion, recargador(t, archivosCliente(t, nuevaCA(t, "otra"), nil)).Cliente("localhost")); err == nil {
+		t.Fatal("un cliente que no confía en la CA del servidor no debería conectar")
+	}
+	if err := llamar(direccion, recargador(t, archivosCliente(t, autoridad, nil)).Cliente("grpc-server")); err == nil {
+		t.Fatal("un nombre que no está en el certificado no debería verificar")
+	}
+}
+
+func TestMTLS(t *testing.T) {
+	autoridad := nuevaCA(t, "ca")
+	servidor := recargador(t, archivosServidor(t, autoridad, autoridad))
+	if !servidor.MTLS() {
+		t.Fatal("con CA de clientes debería ser mTLS")
+	}
+	direccion := levantar(t, servidor)
+
+	conCert := recargador(t, archivosCliente(t, autoridad, autoridad))
+	if !conCert.ConCertificado() {
+		t.Fatal("el cliente debería presentar certificado")
+	}
+	if err := llamar(direccion, conCert.Cliente("localhost")); err != nil {
+		t.Fatalf("cliente con certificado: %v", err)
+	}
+	if err := llamar(direccion, recargador(t, archivosCliente(t, autoridad, nil)).Cliente("localhost")); err == nil {
+		t.Fatal("un cliente sin certificado no debería conectar")
+	}
+	if err := llamar(direccion, recargador(t, archivosCliente(t, autoridad, nuevaCA(t, "otra"))).Cliente("localhost")); err == nil {
+		t.Fatal("un certificado de cliente firmado por otra CA no debería aceptarse")
+	}
+}
+
+func TestRecargaEnCaliente(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vieja, nueva := nuevaCA(t, "vieja"), nuevaCA(t, "nueva")
+	archivosSrv := archivosServidor(t, vieja, nil)
+	servidor := recargador(t, archivosSrv)
+	go servidor.Vigilar(ctx, 10*time.Millisecond)
+	direccion := levantar(t, servidor)
+
+	archivosCli := archivosCliente(t, vieja, nil)
+	cliente := recargador(t, archivosCli)
+	go cliente.Vigilar(ctx, 10*time.Millisecond)
+	if err := llamar(direccion, cliente.Cliente("localhost")); err != nil {
+		t.Fatal(err)
+	}
+	// Credenciales creadas antes de la rotación: deben tomar la CA nueva
+	credenciales := cliente.Cliente("localhost")
+
+	// El servidor rota a un certificado de la CA nueva: el cliente todavía no confía en ella
+	cert, key := nueva.firmar(t, "localhost", x509.ExtKeyUsageServerAuth)
+	escribir(t, archivosSrv.Key, key)
+	escribir(t, archivosSrv.Cert, cert)
+	esperar(t, direccion, cliente, false)
+
+	// El cliente recibe la CA nueva sin reiniciarse
+	escribir(t, archivosCli.CA, nueva.pem)
+	esperar(t, direccion, cliente, true)
+	if err := llamar(direccion, credenciales); err != nil {
+		t.Fatalf("las credenciales existentes deberían usar la CA recargada: %v", err)
+	}
+
+	// Un certificado inválido no reemplaza al vigente
+	escribir(t, archivosSrv.Cert, []byte("no es un certificado"))
+	time.Sleep(50 * time.Millisecond)
+	if err := llamar(direccion, cliente.Cliente("localhost")); err != nil {
+		t.Fatalf("con un certificado inválido se debería seguir usando el anterior: %v", err)
+	}
+}
+
+func TestArchivosIncompletos(t *testing.T) {
+	if _, err := NuevoRecargador(Archivos{Cert: "tls.crt"}); err == nil {
+		t.Fatal("TLS_CERT sin TLS_KEY debería fallar")
+	}
+	if _, err := NuevoRecargador(Archivos{CA: filepath.Join(t.TempDir(), "no-existe.crt")}); err == nil {
+		t.Fatal("una CA que no existe debería fallar")
+	}
+	soloCA := recargador(t, archivosCliente(t, nuevaCA(t, "ca"), nil))
+	if _, err := soloCA.Servidor(); err == nil {
+		t.Fatal("el servidor sin certificado debería fallar")
+	}
+}
@@ -2,34 +2,124 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
+	"grpc/autenticacion"
+	"grpc/difusion"
+	"grpc/limites"
 	pb "grpc/proto"
+	"grpc/repositorio"
+	"grpc/seguridad"
+	"grpc/validacion"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Implementación del servicio TweetService
 type tweetServer struct {
 	pb.UnimplementedTweetServiceServer
+	repo    repositorio.Repositorio
+	difusor *difusion.Difusor
 }
 
 // Método para manejar la solicitud SendTweet
 func (s *tweetServer) SendTweet(ctx context.Context, req *pb.TweetRequest) (*pb.TweetResponse, error) {
-	// Validar que los datos no estén vacíos
-	if req.Description == "" || req.Country == "" || req.Weather == "" {
-		log.Printf("Datos incompletos recibidos")
-		return &pb.TweetResponse{
-			Status: "Error: Datos incompletos",
-		}, nil
+	tweet, err := s.recibirTweet(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Respuesta al cliente con un mensaje de confirmación
+	responseMessage := fmt.Sprintf("Tweet de %s recibido y procesado correctamente", req.Country)
+	
+	return &pb.TweetResponse{
+		Status:    responseMessage,
+		Id:        tweet.ID,
+		CreatedAt: timestamppb.New(tweet.CreatedAt),
+	}, nil
+}
+
+// Método para recibir muchos tweets en un stream; al cerrarlo el cliente
+// recibe cuántos se guardaron y el detalle de los rechazados
+func (s *tweetServer) SendTweets(stream grpc.ClientStreamingServer[pb.TweetRequest, pb.BatchSummary]) error {
+	resumen := &pb.BatchSummary{}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			log.Printf("Lote recibido: %d tweets, %d guardados, %d rechazados",
+				resumen.Received, resumen.Accepted, resumen.Rejected)
+			return stream.SendAndClose(resumen)
+		}
+		if err != nil {
+			return err
+		}
+
+		posicion := uint64(resumen.Received)
+		resumen.Received++
+		if _, err := s.recibirTweet(stream.Context(), req); err != nil {
+			resumen.Rejected++
+			resumen.Rejections = append(resumen.Rejections, ackError(posicion, err))
+			continue
+		}
+		resumen.Accepted++
+	}
+}
+
+// Método para recibir tweets en un stream bidireccional: cada tweet se
+// confirma con un TweetAck apenas se guarda
+func (s *tweetServer) TweetStream(stream grpc.BidiStreamingServer[pb.TweetStreamRequest, pb.TweetAck]) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ack := &pb.TweetAck{Sequence: req.Sequence}
+		tweet, err := s.recibirTweet(stream.Context(), req.GetTweet())
+		if err != nil {
+			ack = ackError(req.Sequence, err)
+		} else {
+			ack.Id = tweet.ID
+			ack.CreatedAt = timestamppb.New(tweet.CreatedAt)
+		}
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+// recibirTweet valida, registra y guarda un tweet; lo usan el RPC unario y los streams
+func (s *tweetServer) recibirTweet(ctx context.Context, req *pb.TweetRequest) (repositorio.Tweet, error) {
+	// Validar campos obligatorios, largos y clima; el error lleva una violación por campo
+	if err := validacion.Tweet(req); err != nil {
+		log.Printf("Tweet inválido recibido: %v", err)
+		return repositorio.Tweet{}, err
+	}
+	// El cliente debe tener permiso para el país del tweet
+	if err := autenticacion.PaisesPermitidos(ctx, req.Country); err != nil {
+		log.Printf("Tweet rechazado: %v", err)
+		return repositorio.Tweet{}, err
 	}
 
 	// Log detallado de la información recibida
@@ -41,13 +131,218 @@ func (s *tweetServer) SendTweet(ctx context.Context, req *pb.TweetRequest) (*pb.
 	log.Printf("Descripción: %s", req.Description)
 	log.Printf("========================================\n")
 
+	// El servidor asigna el id y la fecha de creación
+	ahora := time.Now()
+	tweet := repositorio.Tweet{
+		ID:          repositorio.NuevoID(ahora),
+		Description: req.Description,
+		Country:     req.Country,
+		Weather:     req.Weather,
+		CreatedAt:   ahora,
+	}
+	if err := s.repo.Guardar(ctx, tweet); err != nil {
+		log.Printf("Error guardando tweet: %v", err)
+		return repositorio.Tweet{}, status.Error(codes.Internal, "no se pudo guardar el tweet")
+	}
 
-	// Respuesta al cliente con un mensaje de confirmación
-	responseMessage := fmt.Sprintf("Tweet de %s recibido y procesado correctamente", req.Country)
-	
-	return &pb.TweetResponse{
-		Status: responseMessage,
-	}, nil
+	// Solo los tweets guardados llegan a los suscriptores de SubscribeTweets
+	s.difusor.Publicar(tweet)
+	return tweet, nil
+}
+
+// Método para recibir en vivo los tweets que coinciden con los filtros
+func (s *tweetServer) SubscribeTweets(req *pb.SubscribeRequest, stream grpc.ServerStreamingServer[pb.Tweet]) error {
+	if err := validacion.Suscripcion(req); err != nil {
+		return err
+	}
+	if err := autenticacion.PaisesPermitidos(stream.Context(), req.Countries...); err != nil {
+		return err
+	}
+
+	suscripcion := s.difusor.Suscribir(difusion.Filtro{Countries: req.Countries, Weathers: req.Weathers})
+	defer suscripcion.Cancelar()
+	log.Printf("Nuevo suscriptor (países: %v, climas: %v); suscriptores activos: %d",
+		req.Countries, req.Weathers, s.difusor.Suscriptores())
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Printf("Suscriptor desconectado; tweets perdidos: %d", suscripcion.Perdidos())
+			return nil
+		case <-s.difusor.Cerrado():
+			return status.Error(codes.Unavailable, "el servidor se está apagando")
+		case <-suscripcion.Expulsada():
+			log.Printf("Suscriptor expulsado por lento; tweets perdidos: %d", suscripcion.Perdidos())
+			return status.Errorf(codes.ResourceExhausted,
+				"el suscriptor no lee a tiempo; se perdieron %d tweets", suscripcion.Perdidos())
+		case tweet := <-suscripcion.Tweets():
+			if err := stream.Send(tweetPB(tweet)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ackError convierte el error de un tweet en un TweetAck con su código y las
+// violaciones del BadRequest, para informarlo sin cortar el stream
+func ackError(secuencia uint64, err error) *pb.TweetAck {
+	st := status.Convert(err)
+	ack := &pb.TweetAck{Sequence: secuencia, Code: int32(st.Code()), Message: st.Message()}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				ack.Violations = append(ack.Violations, &pb.FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		}
+	}
+	return ack
+}
+
+// Método para obtener un tweet por id
+func (s *tweetServer) GetTweet(ctx context.Context, req *pb.GetTweetRequest) (*pb.Tweet, error) {
+	if err := validacion.ID(req.Id); err != nil {
+		return nil, err
+	}
+	tweet, err := s.repo.Obtener(ctx, req.Id)
+	if err != nil {
+		return nil, errorRepositorio(err)
+	}
+	if err := autenticacion.PaisesPermitidos(ctx, tweet.Country); err != nil {
+		return nil, err
+	}
+	return tweetPB(tweet), nil
+}
+
+// Método para listar tweets del más nuevo al más viejo, filtrados por país y clima
+func (s *tweetServer) ListTweets(ctx context.Context, req *pb.ListTweetsRequest) (*pb.ListTweetsResponse, error) {
+	if err := validacion.Listado(req); err != nil {
+		return nil, err
+	}
+	// Un cliente limitado a ciertos países debe filtrar por uno de ellos
+	var paises []string
+	if req.Country != "" {
+		paises = append(paises, req.Country)
+	}
+	if err := autenticacion.PaisesPermitidos(ctx, paises...); err != nil {
+		return nil, err
+	}
+	filtro := repositorio.Filtro{Country: req.Country, Weather: req.Weather}
+	pagina := repositorio.Pagina{Tamano: int(req.PageSize), Token: req.PageToken}
+
+	tweets, siguiente, err := s.repo.Listar(ctx, filtro, pagina)
+	if err != nil {
+		return nil, errorRepositorio(err)
+	}
+
+	resp := &pb.ListTweetsResponse{NextPageToken: siguiente}
+	for _, t := range tweets {
+		resp.Tweets = append(resp.Tweets, tweetPB(t))
+	}
+	return resp, nil
+}
+
+// Método para eliminar un tweet por id
+func (s *tweetServer) DeleteTweet(ctx context.Context, req *pb.DeleteTweetRequest) (*pb.DeleteTweetResponse, error) {
+	if err := validacion.ID(req.Id); err != nil {
+		return nil, err
+	}
+	tweet, err := s.repo.Obtener(ctx, req.Id)
+	if err != nil {
+		return nil, errorRepositorio(err)
+	}
+	if err := autenticacion.PaisesPermitidos(ctx, tweet.Country); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Eliminar(ctx, req.Id); err != nil {
+		return nil, errorRepositorio(err)
+	}
+	log.Printf("Tweet %s eliminado", req.Id)
+	return &pb.DeleteTweetResponse{}, nil
+}
+
+func tweetPB(t repositorio.Tweet) *pb.Tweet {
+	return &pb.Tweet{
+		Id:          t.ID,
+		Description: t.Description,
+		Country:     t.Country,
+		Weather:     t.Weather,
+		CreatedAt:   timestamppb.New(t.CreatedAt),
+	}
+}
+
+// errorRepositorio convierte los errores del repositorio en códigos de gRPC
+func errorRepositorio(err error) error {
+	switch {
+	case errors.Is(err, repositorio.ErrNoEncontrado):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repositorio.ErrTokenInvalido):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		log.Printf("Error del repositorio: %v", err)
+		return status.Error(codes.Internal, "error consultando los tweets")
+	}
+}
+
+// abrirRepositorio usa SQLite si TWEETS_DB tiene la ruta del archivo y, si no,
+// guarda en memoria los últimos TWEETS_MAX tweets
+func abrirRepositorio() (repositorio.Repositorio, error) {
+	ruta := os.Getenv("TWEETS_DB")
+	if ruta == "" {
+		capacidad := enteroEnv("TWEETS_MAX", 100000)
+		log.Printf("Tweets en memoria, hasta %d (TWEETS_DB no está definida)", capacidad)
+		return repositorio.NuevaMemoria(capacidad), nil
+	}
+	log.Printf("Tweets en SQLite: %s", ruta)
+	return repositorio.NuevoSQLite(ruta)
+}
+
+// enteroEnv lee una variable de entorno entera positiva o devuelve defecto
+func enteroEnv(nombre string, defecto int) int {
+	if n, err := strconv.Atoi(os.Getenv(nombre)); err == nil && n > 0 {
+		return n
+	}
+	return defecto
+}
+
+// abrirLimitador lee los límites de las variables de entorno:
+//   - LIMITE_TASA y LIMITE_RAFAGA: llamadas por segundo de cada cliente en cada
+//     método y ráfaga permitida (sin LIMITE_TASA no se limita la tasa)
+//   - LIMITE_TASA_METODOS: cuotas de métodos puntuales, "SendTweet=50,ListTweets=5/20"
+//   - CONCURRENCIA_MAX, CONCURRENCIA_MIN y CONCURRENCIA_INICIAL: rango del límite
+//     adaptativo de llamadas en curso (sin CONCURRENCIA_MAX no se limita)
+//
+// Devuelve nil si no hay ningún límite.
+func abrirLimitador() (*limites.Limitador, error) {
+	var tasa *limites.Tasa
+	defecto := limites.Cuota{}
+	if texto := os.Getenv("LIMITE_TASA"); texto != "" {
+		var err error
+		if defecto.Tasa, err = strconv.ParseFloat(texto, 64); err != nil || defecto.Tasa < 0 {
+			return nil, fmt.Errorf("LIMITE_TASA inválido: %q", texto)
+		}
+		defecto.Rafaga = float64(enteroEnv("LIMITE_RAFAGA", int(math.Ceil(2*defecto.Tasa))))
+	}
+	metodos, err := limites.LeerCuotas(os.Getenv("LIMITE_TASA_METODOS"))
+	if err != nil {
+		return nil, fmt.Errorf("LIMITE_TASA_METODOS: %w", err)
+	}
+	if defecto.Tasa > 0 || len(metodos) > 0 {
+		tasa = limites.NuevaTasa(defecto, metodos)
+		log.Printf("Límite de tasa por cliente: %.0f llamadas/s (ráfaga %.0f) y %d métodos con cuota propia",
+			defecto.Tasa, defecto.Rafaga, len(metodos))
+	}
+
+	var concurrencia *limites.Concurrencia
+	if maximo := enteroEnv("CONCURRENCIA_MAX", 0); maximo > 0 {
+		minimo := enteroEnv("CONCURRENCIA_MIN", 8)
+		concurrencia = limites.NuevaConcurrencia(minimo, maximo, enteroEnv("CONCURRENCIA_INICIAL", 32))
+		log.Printf("Límite de concurrencia adaptativo entre %d y %d llamadas", minimo, maximo)
+	}
+
+	if tasa == nil && concurrencia == nil {
+		return nil, nil
+	}
+	return limites.NuevoLimitador(context.Background(), tasa, concurrencia), nil
 }
 
 func main() {
@@ -65,13 +360,74 @@ func main() {
 	}
 
 	// Crear un servidor gRPC con opciones
-	grpcServer := grpc.NewServer(
+	opciones := []grpc.ServerOption{
 		grpc.MaxRecvMsgSize(10 * 1024 * 1024), // Tamaño máximo de mensajes recibidos = 10MB
 		grpc.MaxSendMsgSize(10 * 1024 * 1024), // Tamaño máximo de mensajes enviados = 10MB
-	)
+	}
+
+	// TLS_CERT y TLS_KEY activan TLS; con TLS_CA además se exige certificado de cliente (mTLS)
+	certificados, err := seguridad.DeEntorno(context.Background())
+	if err != nil {
+		log.Fatalf("Error cargando los certificados TLS: %v", err)
+	}
+	if certificados != nil {
+		creds, err := certificados.Servidor()
+		if err != nil {
+			log.Fatalf("Error configurando TLS: %v", err)
+		}
+		opciones = append(opciones, grpc.Creds(creds))
+	}
+
+	// Con GRPC_MAX_EDAD_CONEXION se cierran las conexiones viejas para que los
+	// clientes reconecten y se repartan entre las réplicas nuevas; las llamadas
+	// en curso (también las suscripciones) terminan en la conexión vieja
+	if edad, err := time.ParseDuration(os.Getenv("GRPC_MAX_EDAD_CONEXION")); err == nil && edad > 0 {
+		opciones = append(opciones, grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionAge: edad}))
+		log.Printf("Las conexiones se renuevan cada %s", edad)
+	}
+
+	// AUTH_POLITICA es el archivo con los clientes, sus API keys y permisos
+	if ruta := os.Getenv("AUTH_POLITICA"); ruta != "" {
+		politica, err := autenticacion.CargarPolitica(ruta)
+		if err != nil {
+			log.Fatalf("Error cargando la política de autenticación: %v", err)
+		}
+		autenticador := autenticacion.NuevoAutenticador(politica)
+		opciones = append(opciones,
+			grpc.ChainUnaryInterceptor(autenticador.Unario()),
+			grpc.ChainStreamInterceptor(autenticador.Stream()),
+		)
+		log.Printf("Autenticación habilitada: %d clientes en %s", len(politica.Clientes), ruta)
+	} else {
+		log.Printf("ADVERTENCIA: cualquiera puede llamar al servicio (AUTH_POLITICA no está definida)")
+	}
+
+	// Los límites van después de la autenticación para limitar por cliente
+	limitador, err := abrirLimitador()
+	if err != nil {
+		log.Fatalf("Error en la configuración de límites: %v", err)
+	}
+	if limitador != nil {
+		opciones = append(opciones,
+			grpc.ChainUnaryInterceptor(limitador.Unario()),
+			grpc.ChainStreamInterceptor(limitador.Stream()),
+		)
+	}
+	grpcServer := grpc.NewServer(opciones...)
+
+	// Repositorio donde se guardan los tweets
+	repo, err := abrirRepositorio()
+	if err != nil {
+		log.Fatalf("Error abriendo el repositorio de tweets: %v", err)
+	}
+	defer repo.Close()
+
+	// Cada suscriptor guarda hasta SUSCRIPCION_BUFFER tweets sin leer y se
+	// expulsa si pierde SUSCRIPCION_MAX_PERDIDOS seguidos
+	difusor := difusion.Nuevo(enteroEnv("SUSCRIPCION_BUFFER", 256), enteroEnv("SUSCRIPCION_MAX_PERDIDOS", 1000))
 
 	// Registrar el servicio TweetService en el servidor
-	pb.RegisterTweetServiceServer(grpcServer, &tweetServer{})
+	pb.RegisterTweetServiceServer(grpcServer, &tweetServer{repo: repo, difusor: difusor})
 
 	// Registrar health check service para Kubernetes
 	healthServer := health.NewServer()
@@ -84,6 +440,14 @@ func main() {
 	// Log de inicio
 	log.Printf("Servidor gRPC iniciado")
 	log.Printf("Escuchando en: %s", address)
+	switch {
+	case certificados == nil:
+		log.Printf("ADVERTENCIA: conexiones sin cifrar (TLS_CERT no está definida)")
+	case certificados.MTLS():
+		log.Printf("mTLS habilitado: se exige certificado de cliente")
+	default:
+		log.Printf("TLS habilitado")
+	}
 	log.Printf("Health checks habilitados")
 	log.Printf("Reflection habilitado para debugging")
 	log.Println("Esperando conexiones...")
@@ -93,8 +457,9 @@ func main() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
-		
+
 		log.Println("\nSeñal de apagado recibida, cerrando servidor...")
+		difusor.Cerrar()
 		grpcServer.GracefulStop()
 		log.Println("Servidor cerrado correctamente")
 	}()
@@ -103,4 +468,4 @@ func main() {
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("Error en el servidor: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,29 @@
+package validacion
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// EstadoHTTP convierte el código gRPC en el estado HTTP que el cliente le
+// devuelve a Rust: 422 si el servidor rechazó los datos, 403 si el cliente no
+// tiene permiso para ese país, 429 si el servidor está limitando las llamadas,
+// 503 si no está disponible (en ambos casos Rust puede reintentar) y 500 para
+// el resto (también si el token del cliente es inválido: no es culpa de Rust)
+func EstadoHTTP(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument:
+		return http.StatusUnprocessableEntity
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
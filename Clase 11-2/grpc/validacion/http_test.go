@@ -0,0 +1,28 @@
+package validacion
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestEstadoHTTP(t *testing.T) {
+	casos := map[codes.Code]int{
+		codes.InvalidArgument:   http.StatusUnprocessableEntity,
+		codes.NotFound:          http.StatusNotFound,
+		codes.PermissionDenied:  http.StatusForbidden,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.Unavailable:       http.StatusServiceUnavailable,
+		codes.DeadlineExceeded:  http.StatusServiceUnavailable,
+		// Un token inválido es un problema del cliente, no de Rust
+		codes.Unauthenticated: http.StatusInternalServerError,
+		codes.Internal:        http.StatusInternalServerError,
+		codes.Unknown:         http.StatusInternalServerError,
+	}
+	for code, desea := range casos {
+		if got := EstadoHTTP(code); got != desea {
+			t.Errorf("%s: %d; se esperaba %d", code, got, desea)
+		}
+	}
+}
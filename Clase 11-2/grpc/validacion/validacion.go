@@ -0,0 +1,132 @@
+// Package validacion revisa los mensajes de TweetService. Los errores se
+// devuelven como codes.InvalidArgument con un errdetails.BadRequest que tiene
+// una violación por campo, así el cliente puede decir exactamente qué corregir.
+// EstadoHTTP hace el camino inverso en el cliente REST: convierte el código
+// gRPC en el estado HTTP que se le responde a Rust.
+package validacion
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	pb "grpc/proto"
+	"grpc/repositorio"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Límites de los campos de TweetRequest (en caracteres, no en bytes)
+const (
+	MaxDescripcion = 280
+	MaxPais        = 64
+)
+
+// MaxFiltros es la cantidad máxima de países o climas de una suscripción
+const MaxFiltros = 50
+
+// ClimasPermitidos son los valores de weather que acepta el servidor (los que
+// envían el generador de carga y el productor de Kafka de la Clase 12); se
+// comparan sin distinguir mayúsculas
+var ClimasPermitidos = []string{"soleado", "nublado", "lluvioso", "ventoso"}
+
+// Violaciones acumula los campos inválidos de un mensaje
+type Violaciones []*errdetails.BadRequest_FieldViolation
+
+func (v *Violaciones) agregar(campo, descripcion string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: campo, Description: descripcion})
+}
+
+// Err devuelve nil si no hay violaciones y, si hay, el error InvalidArgument
+// con los detalles
+func (v Violaciones) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	campos := make([]string, len(v))
+	for i, f := range v {
+		campos[i] = f.Field
+	}
+	st := status.New(codes.InvalidArgument, "campos inválidos: "+strings.Join(campos, ", "))
+	conDetalles, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return st.Err()
+	}
+	return conDetalles.Err()
+}
+
+// Tweet valida un TweetRequest
+func Tweet(req *pb.TweetRequest) error {
+	var v Violaciones
+	texto(&v, "description", req.GetDescription(), MaxDescripcion)
+	texto(&v, "country", req.GetCountry(), MaxPais)
+	clima(&v, "weather", req.GetWeather(), true)
+	return v.Err()
+}
+
+// ID valida el id de GetTweet y DeleteTweet
+func ID(id string) error {
+	var v Violaciones
+	if !repositorio.IDValido(id) {
+		v.agregar("id", "debe ser un id devuelto por SendTweet (28 caracteres hexadecimales)")
+	}
+	return v.Err()
+}
+
+// Listado valida los filtros y la paginación de ListTweets
+func Listado(req *pb.ListTweetsRequest) error {
+	var v Violaciones
+	if utf8.RuneCountInString(req.GetCountry()) > MaxPais {
+		v.agregar("country", fmt.Sprintf("debe tener como máximo %d caracteres", MaxPais))
+	}
+	clima(&v, "weather", req.GetWeather(), false)
+	if req.GetPageSize() < 0 {
+		v.agregar("page_size", "no puede ser negativo")
+	}
+	if req.GetPageToken() != "" && !repositorio.IDValido(req.GetPageToken()) {
+		v.agregar("page_token", "debe ser el next_page_token de una respuesta anterior")
+	}
+	return v.Err()
+}
+
+// Suscripcion valida los filtros de SubscribeTweets
+func Suscripcion(req *pb.SubscribeRequest) error {
+	var v Violaciones
+	if len(req.GetCountries()) > MaxFiltros {
+		v.agregar("countries", fmt.Sprintf("debe tener como máximo %d países", MaxFiltros))
+	}
+	for i, pais := range req.GetCountries() {
+		texto(&v, fmt.Sprintf("countries[%d]", i), pais, MaxPais)
+	}
+	if len(req.GetWeathers()) > MaxFiltros {
+		v.agregar("weathers", fmt.Sprintf("debe tener como máximo %d climas", MaxFiltros))
+	}
+	for i, c := range req.GetWeathers() {
+		clima(&v, fmt.Sprintf("weathers[%d]", i), c, true)
+	}
+	return v.Err()
+}
+
+func texto(v *Violaciones, campo, valor string, maximo int) {
+	switch {
+	case strings.TrimSpace(valor) == "":
+		v.agregar(campo, "es obligatorio")
+	case utf8.RuneCountInString(valor) > maximo:
+		v.agregar(campo, fmt.Sprintf("debe tener como máximo %d caracteres", maximo))
+	}
+}
+
+func clima(v *Violaciones, campo, valor string, obligatorio bool) {
+	if valor == "" {
+		if obligatorio {
+			v.agregar(campo, "es obligatorio")
+		}
+		return
+	}
+	if !slices.Contains(ClimasPermitidos, strings.ToLower(valor)) {
+		v.agregar(campo, fmt.Sprintf("debe ser uno de %s", strings.Join(ClimasPermitidos, ", ")))
+	}
+}
@@ -0,0 +1,132 @@
+package validacion
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "grpc/proto"
+	"grpc/repositorio"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// campos devuelve los campos de las violaciones del error en orden; nil si
+// el error es nil
+func campos(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("código %s; se esperaba InvalidArgument", st.Code())
+	}
+	var lista []string
+	for _, d := range st.Details() {
+		br, ok := d.(*errdetails.BadRequest)
+		if !ok {
+			t.Fatalf("detalle inesperado %T", d)
+		}
+		for _, v := range br.FieldViolations {
+			if v.Description == "" {
+				t.Errorf("la violación de %s no tiene descripción", v.Field)
+			}
+			lista = append(lista, v.Field)
+		}
+	}
+	if len(lista) == 0 {
+		t.Fatal("InvalidArgument sin violaciones por campo")
+	}
+	return lista
+}
+
+func TestTweet(t *testing.T) {
+	// ñ y á ocupan dos bytes: el límite cuenta caracteres
+	casos := []struct {
+		nombre string
+		req    *pb.TweetRequest
+		desea  []string
+	}{
+		{"válido", &pb.TweetRequest{Description: "hola", Country: "Guatemala", Weather: "Soleado"}, nil},
+		{"clima en mayúsculas", &pb.TweetRequest{Description: "hola", Country: "Guatemala", Weather: "LLUVIOSO"}, nil},
+		{"280 caracteres", &pb.TweetRequest{Description: strings.Repeat("ñ", MaxDescripcion), Country: strings.Repeat("á", MaxPais), Weather: "nublado"}, nil},
+		{"281 caracteres", &pb.TweetRequest{Description: strings.Repeat("ñ", MaxDescripcion+1), Country: "Guatemala", Weather: "nublado"}, []string{"description"}},
+		{"país largo", &pb.TweetRequest{Description: "hola", Country: strings.Repeat("á", MaxPais+1), Weather: "nublado"}, []string{"country"}},
+		{"solo espacios", &pb.TweetRequest{Description: "   ", Country: "\t", Weather: "ventoso"}, []string{"description", "country"}},
+		{"clima desconocido", &pb.TweetRequest{Description: "hola", Country: "Guatemala", Weather: "granizo"}, []string{"weather"}},
+		{"vacío", &pb.TweetRequest{}, []string{"description", "country", "weather"}},
+	}
+	for _, caso := range casos {
+		if got := campos(t, Tweet(caso.req)); !slices.Equal(got, caso.desea) {
+			t.Errorf("%s: violaciones %v; se esperaba %v", caso.nombre, got, caso.desea)
+		}
+	}
+}
+
+func TestMensajeConLosCampos(t *testing.T) {
+	st := status.Convert(Tweet(&pb.TweetRequest{Country: "Guatemala", Weather: "granizo"}))
+	if st.Message() != "campos inválidos: description, weather" {
+		t.Fatalf("mensaje %q", st.Message())
+	}
+	if Violaciones(nil).Err() != nil {
+		t.Fatal("sin violaciones no hay error")
+	}
+}
+
+func TestID(t *testing.T) {
+	if err := ID(repositorio.NuevoID(time.Now())); err != nil {
+		t.Fatalf("un id generado debería ser válido: %v", err)
+	}
+	for _, id := range []string{"", "123", strings.Repeat("z", 28), strings.Repeat("a", 29)} {
+		if got := campos(t, ID(id)); !slices.Equal(got, []string{"id"}) {
+			t.Errorf("%q: violaciones %v", id, got)
+		}
+	}
+}
+
+func TestListado(t *testing.T) {
+	casos := []struct {
+		nombre string
+		req    *pb.ListTweetsRequest
+		desea  []string
+	}{
+		{"sin filtros", &pb.ListTweetsRequest{}, nil},
+		{"con filtros", &pb.ListTweetsRequest{Country: "Guatemala", Weather: "Ventoso", PageSize: 10, PageToken: repositorio.NuevoID(time.Now())}, nil},
+		{"país largo", &pb.ListTweetsRequest{Country: strings.Repeat("ñ", MaxPais+1)}, []string{"country"}},
+		{"clima desconocido", &pb.ListTweetsRequest{Weather: "granizo"}, []string{"weather"}},
+		{"página negativa", &pb.ListTweetsRequest{PageSize: -1}, []string{"page_size"}},
+		{"token inventado", &pb.ListTweetsRequest{PageToken: "pagina-2"}, []string{"page_token"}},
+	}
+	for _, caso := range casos {
+		if got := campos(t, Listado(caso.req)); !slices.Equal(got, caso.desea) {
+			t.Errorf("%s: violaciones %v; se esperaba %v", caso.nombre, got, caso.desea)
+		}
+	}
+}
+
+func TestSuscripcion(t *testing.T) {
+	muchos := make([]string, MaxFiltros+1)
+	for i := range muchos {
+		muchos[i] = "Guatemala"
+	}
+	casos := []struct {
+		nombre string
+		req    *pb.SubscribeRequest
+		desea  []string
+	}{
+		{"sin filtros", &pb.SubscribeRequest{}, nil},
+		{"con filtros", &pb.SubscribeRequest{Countries: []string{"Guatemala", "México"}, Weathers: []string{"soleado", "Nublado"}}, nil},
+		{"país vacío", &pb.SubscribeRequest{Countries: []string{"Guatemala", " "}}, []string{"countries[1]"}},
+		{"clima desconocido", &pb.SubscribeRequest{Weathers: []string{"soleado", "granizo", ""}}, []string{"weathers[1]", "weathers[2]"}},
+		{"demasiados países", &pb.SubscribeRequest{Countries: muchos}, []string{"countries"}},
+	}
+	for _, caso := range casos {
+		if got := campos(t, Suscripcion(caso.req)); !slices.Equal(got, caso.desea) {
+			t.Errorf("%s: violaciones %v; se esperaba %v", caso.nombre, got, caso.desea)
+		}
+	}
+}
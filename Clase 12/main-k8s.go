@@ -20,7 +20,7 @@ type Clima struct {
 }
 
 var municipios = []string{"Mixco", "Guatemala", "Villa Nueva", "Amatitlán", "Antigua"}
-var climas = []string{"Soleado", "Nubslado", "Lluvioso", "Ventoso"}
+var climas = []string{"Soleado", "Nublado", "Lluvioso", "Ventoso"}
 
 func generarClima() Clima {
 	return Clima{
@@ -0,0 +1,212 @@
+// cronctl administra el bloque de entradas propias dentro del crontab.
+//
+// Uso:
+//
+//	cronctl [-marca M] [-dry-run] add -nombre N -cron "* * * * *" -- comando args...
+//	cronctl [-marca M] [-dry-run] remove -nombre N | -todas
+//	cronctl [-marca M] list [-todo]
+//	cronctl [-marca M] [-dry-run] sync -archivo entradas.txt
+//
+// En add cada argumento después de -- se cita por separado, así
+// "-- sh -c 'a b'" llega a cron como los mismos tres argumentos.
+//
+// El archivo de sync tiene una entrada por línea con el formato
+// "nombre expresión comando" (las líneas vacías y las que empiezan con # se ignoran).
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"cronjob/crontab"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	marca := flag.String("marca", crontab.MarcaPorDefecto, "marca del bloque propio en el crontab")
+	dryRun := flag.Bool("dry-run", false, "mostrar la diferencia sin modificar el crontab")
+	flag.Usage = uso
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		uso()
+		os.Exit(2)
+	}
+
+	admin := crontab.Nuevo(*marca)
+	admin.DryRun = *dryRun
+
+	subcomando, args := flag.Arg(0), flag.Args()[1:]
+	var err error
+	switch subcomando {
+	case "add":
+		err = agregar(admin, args)
+	case "remove":
+		err = quitar(admin, args)
+	case "list":
+		err = listar(admin, args)
+	case "sync":
+		err = sincronizar(admin, args)
+	default:
+		uso()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+func uso() {
+	fmt.Fprintln(os.Stderr, `Uso: cronctl [-marca M] [-dry-run] <add|remove|list|sync> [opciones]
+
+  add    -nombre N -cron "EXPR" -- comando args...
+  remove -nombre N | -todas
+  list   [-todo]
+  sync   -archivo ARCHIVO (o "-" para leer de stdin)`)
+	flag.PrintDefaults()
+}
+
+func agregar(admin *crontab.Administrador, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	nombre := fs.String("nombre", "", "nombre de la entrada")
+	expresion := fs.String("cron", "", "expresión cron (5 campos o macro @hourly, @daily...)")
+	fs.Parse(args)
+
+	entrada := crontab.Entrada{
+		Nombre:    *nombre,
+		Expresion: *expresion,
+		Comando:   crontab.ComandoCitado(fs.Args()),
+	}
+	res, err := admin.Agregar(entrada)
+	if err != nil {
+		return err
+	}
+	mostrar(admin, res, fmt.Sprintf("Entrada %s instalada", entrada.Nombre))
+	return nil
+}
+
+func quitar(admin *crontab.Administrador, args []string) error {
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	nombre := fs.String("nombre", "", "nombre de la entrada a eliminar")
+	todas := fs.Bool("todas", false, "eliminar el bloque completo")
+	fs.Parse(args)
+
+	var res crontab.Resultado
+	var err error
+	switch {
+	case *todas:
+		res, err = admin.QuitarTodas()
+	case *nombre != "":
+		res, err = admin.Quitar(*nombre)
+	default:
+		return fmt.Errorf("indique -nombre o -todas")
+	}
+	if err != nil {
+		return err
+	}
+	mostrar(admin, res, "Entradas eliminadas")
+	return nil
+}
+
+func listar(admin *crontab.Administrador, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	todo := fs.Bool("todo", false, "mostrar el crontab completo, no solo el bloque propio")
+	fs.Parse(args)
+
+	if *todo {
+		contenido, err := admin.Contenido()
+		if err != nil {
+			return err
+		}
+		fmt.Print(contenido)
+		return nil
+	}
+
+	entradas, err := admin.Listar()
+	if err != nil {
+		return err
+	}
+	for _, e := range entradas {
+		fmt.Printf("%-20s %-15s %s\n", e.Nombre, e.Expresion, e.Comando)
+	}
+	return nil
+}
+
+func sincronizar(admin *crontab.Administrador, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	ruta := fs.String("archivo", "", `archivo con las entradas ("-" para stdin)`)
+	fs.Parse(args)
+
+	var r io.Reader
+	switch *ruta {
+	case "":
+		return fmt.Errorf("indique -archivo")
+	case "-":
+		r = os.Stdin
+	default:
+		f, err := os.Open(*ruta)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	entradas, err := leerEntradas(r)
+	if err != nil {
+		return err
+	}
+	res, err := admin.Sincronizar(entradas)
+	if err != nil {
+		return err
+	}
+	mostrar(admin, res, fmt.Sprintf("Bloque sincronizado con %d entradas", len(entradas)))
+	return nil
+}
+
+// leerEntradas interpreta líneas "nombre expresión comando"
+func leerEntradas(r io.Reader) ([]crontab.Entrada, error) {
+	var entradas []crontab.Entrada
+	scanner := bufio.NewScanner(r)
+	numero := 0
+	for scanner.Scan() {
+		numero++
+		linea := strings.TrimSpace(scanner.Text())
+		if linea == "" || strings.HasPrefix(linea, "#") {
+			continue
+		}
+
+		// El nombre se separa con espacios o tabs, igual que los campos de la expresión
+		nombre := strings.Fields(linea)[0]
+		resto := strings.TrimSpace(linea[len(nombre):])
+		if resto == "" {
+			return nil, fmt.Errorf("línea %d: falta la expresión cron", numero)
+		}
+		expresion, comando, err := crontab.ParsearLinea(resto)
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: %v", numero, err)
+		}
+		entradas = append(entradas, crontab.Entrada{Nombre: nombre, Expresion: expresion, Comando: comando})
+	}
+	return entradas, scanner.Err()
+}
+
+// mostrar imprime la diferencia; en dry-run aclara que no se escribió nada
+func mostrar(admin *crontab.Administrador, res crontab.Resultado, mensaje string) {
+	if !res.Cambio {
+		log.Println("Sin cambios en el crontab")
+		return
+	}
+	fmt.Print(res.Diff())
+	if admin.DryRun {
+		log.Println("(dry-run) El crontab no fue modificado")
+		return
+	}
+	log.Println(mensaje)
+}
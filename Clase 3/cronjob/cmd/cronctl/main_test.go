@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"cronjob/crontab"
+)
+
+func TestLeerEntradas(t *testing.T) {
+	texto := "# entradas de prueba\n" +
+		"\n" +
+		"generador */5 * * * * /opt/generador.sh >> /tmp/g.log 2>&1\n" +
+		"limpieza\t@daily\t/opt/limpieza.sh\n" +
+		"  respaldo \t 0 3 * * *   /opt/respaldo.sh  --todo\n"
+
+	entradas, err := leerEntradas(strings.NewReader(texto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperadas := []crontab.Entrada{
+		{Nombre: "generador", Expresion: "*/5 * * * *", Comando: "/opt/generador.sh >> /tmp/g.log 2>&1"},
+		{Nombre: "limpieza", Expresion: "@daily", Comando: "/opt/limpieza.sh"},
+		{Nombre: "respaldo", Expresion: "0 3 * * *", Comando: "/opt/respaldo.sh  --todo"},
+	}
+	if len(entradas) != len(esperadas) {
+		t.Fatalf("entradas = %+v", entradas)
+	}
+	for i, e := range esperadas {
+		if entradas[i] != e {
+			t.Errorf("entrada %d = %+v, se esperaba %+v", i, entradas[i], e)
+		}
+	}
+}
+
+func TestLeerEntradasInvalidas(t *testing.T) {
+	for _, linea := range []string{"solo-nombre", "mala * * *", "mala\t* * * * * "} {
+		if _, err := leerEntradas(strings.NewReader(linea + "\n")); err == nil {
+			t.Errorf("%q: se esperaba un error", linea)
+		}
+	}
+}
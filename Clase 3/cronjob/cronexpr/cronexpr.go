@@ -0,0 +1,274 @@
+// Package cronexpr interpreta expresiones cron estándar de 5 campos
+// (minuto, hora, día del mes, mes y día de la semana) y las macros
+// que acepta crontab (@hourly, @daily, @reboot, ...).
+package cronexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expresion es una expresión cron ya validada. Cada campo guarda como bits
+// los valores permitidos (bit n = valor n).
+type Expresion struct {
+	Texto string
+
+	Minutos    uint64
+	Horas      uint64
+	Dias       uint64
+	Meses      uint64
+	DiasSemana uint64
+
+	// Reboot indica la macro @reboot, que no tiene horario sino que se ejecuta al iniciar
+	Reboot bool
+
+	// Si alguno de los campos de día es "*" el otro es el que manda; si los dos
+	// tienen restricción basta con que se cumpla cualquiera (comportamiento de cron)
+	diaConRestriccion       bool
+	diaSemanaConRestriccion bool
+}
+
+// campo describe los límites de cada posición de la expresión
+type campo struct {
+	nombre   string
+	min, max int
+	nombres  map[string]int
+}
+
+var campos = []campo{
+	{nombre: "minuto", min: 0, max: 59},
+	{nombre: "hora", min: 0, max: 23},
+	{nombre: "día del mes", min: 1, max: 31},
+	{nombre: "mes", min: 1, max: 12, nombres: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	// El 7 también es domingo, se normaliza a 0 al interpretar
+	{nombre: "día de la semana", min: 0, max: 7, nombres: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parsear interpreta una expresión cron y devuelve un error descriptivo si no es válida
+func Parsear(texto string) (*Expresion, error) {
+	texto = strings.TrimSpace(texto)
+	if texto == "" {
+		return nil, fmt.Errorf("expresión cron vacía")
+	}
+
+	if strings.HasPrefix(texto, "@") {
+		if texto == "@reboot" {
+			return &Expresion{Texto: texto, Reboot: true}, nil
+		}
+		equivalente, ok := macros[texto]
+		if !ok {
+			return nil, fmt.Errorf("macro cron desconocida: %s", texto)
+		}
+		expr, err := Parsear(equivalente)
+		if err != nil {
+			return nil, err
+		}
+		expr.Texto = texto
+		return expr, nil
+	}
+
+	partes := strings.Fields(texto)
+	if len(partes) != len(campos) {
+		return nil, fmt.Errorf("la expresión %q debe tener %d campos, tiene %d", texto, len(campos), len(partes))
+	}
+
+	var bits [5]uint64
+	for i, parte := range partes {
+		b, err := parsearCampo(parte, campos[i])
+		if err != nil {
+			return nil, fmt.Errorf("expresión %q: %v", texto, err)
+		}
+		bits[i] = b
+	}
+
+	// El domingo puede escribirse como 0 o 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = (bits[4] &^ (1 << 7)) | 1
+	}
+
+	return &Expresion{
+		Texto:                   texto,
+		Minutos:                 bits[0],
+		Horas:                   bits[1],
+		Dias:                    bits[2],
+		Meses:                   bits[3],
+		DiasSemana:              bits[4],
+		diaConRestriccion:       !strings.HasPrefix(partes[2], "*"),
+		diaSemanaConRestriccion: !strings.HasPrefix(partes[4], "*"),
+	}, nil
+}
+
+// Validar solo comprueba que la expresión sea válida
+func Validar(texto string) error {
+	_, err := Parsear(texto)
+	return err
+}
+
+// Siguiente devuelve el primer instante posterior a t (con precisión de minuto)
+// que cumple la expresión. Para @reboot, o si no hay coincidencia en los
+// próximos 5 años (por ejemplo "0 0 31 2 *"), devuelve el tiempo cero.
+//
+// Con cambio de horario, lo programado en la hora que se salta al adelantar el
+// reloj no corre ese día, y en la hora que se repite al atrasarlo una tarea
+// con hora fija corre solo la primera vez (las que corren a toda hora siguen
+// el reloj y corren en las dos).
+func (e *Expresion) Siguiente(t time.Time) time.Time {
+	if e.Reboot {
+		return time.Time{}
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limite := t.AddDate(5, 0, 0)
+
+	for t.Before(limite) {
+		if e.Meses&(1<<uint(t.Month())) == 0 {
+			t = enPunto(t.Year(), t.Month()+1, 1, 0, t.Location())
+			continue
+		}
+		if !e.coincideDia(t) {
+			t = enPunto(t.Year(), t.Month(), t.Day()+1, 0, t.Location())
+			continue
+		}
+		if e.Horas&(1<<uint(t.Hour())) == 0 {
+			t = enPunto(t.Year(), t.Month(), t.Day(), t.Hour()+1, t.Location())
+			continue
+		}
+		if e.Minutos&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		if e.Horas != todasLasHoras && horaRepetida(t) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// enPunto es time.Date a la hora en punto. Si esa hora no existe porque el
+// reloj se adelanta, time.Date devuelve una hora anterior al salto (y
+// Siguiente volvería a pasar por ella para siempre); en ese caso se devuelve
+// el primer instante después del salto.
+func enPunto(anio int, mes time.Month, dia, hora int, lugar *time.Location) time.Time {
+	t := time.Date(anio, mes, dia, hora, 0, 0, 0, lugar)
+	pedida := time.Date(anio, mes, dia, hora, 0, 0, 0, time.UTC)
+	obtenida := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	return t.Add(pedida.Sub(obtenida))
+}
+
+// todasLasHoras son los bits del campo hora con "*"
+const todasLasHoras = 1<<24 - 1
+
+// horaRepetida indica si t cae en la segunda pasada de la hora que se repite
+// al atrasar el reloj
+func horaRepetida(t time.Time) bool {
+	return t.Add(-time.Hour).Hour() == t.Hour()
+}
+
+// coincideDia aplica la regla de cron: si ambos campos de día tienen
+// restricción basta con que se cumpla uno de ellos
+func (e *Expresion) coincideDia(t time.Time) bool {
+	dia := e.Dias&(1<<uint(t.Day())) != 0
+	diaSemana := e.DiasSemana&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case e.diaConRestriccion && e.diaSemanaConRestriccion:
+		return dia || diaSemana
+	case e.diaConRestriccion:
+		return dia
+	case e.diaSemanaConRestriccion:
+		return diaSemana
+	default:
+		return true
+	}
+}
+
+// parsearCampo interpreta listas (a,b), rangos (a-b), pasos (*/n, a-b/n) y nombres
+func parsearCampo(texto string, c campo) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(texto, ",") {
+		if item == "" {
+			return 0, fmt.Errorf("%s: elemento vacío en %q", c.nombre, texto)
+		}
+
+		rango, paso := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rango = item[:i]
+			p, err := strconv.Atoi(item[i+1:])
+			if err != nil || p <= 0 {
+				return 0, fmt.Errorf("%s: paso inválido en %q", c.nombre, item)
+			}
+			paso = p
+		}
+
+		var inicio, fin int
+		switch {
+		case rango == "*":
+			inicio, fin = c.min, c.max
+		case strings.Contains(rango, "-"):
+			extremos := strings.SplitN(rango, "-", 2)
+			a, err := valor(extremos[0], c)
+			if err != nil {
+				return 0, err
+			}
+			b, err := valor(extremos[1], c)
+			if err != nil {
+				return 0, err
+			}
+			if a > b {
+				return 0, fmt.Errorf("%s: rango invertido %q", c.nombre, rango)
+			}
+			inicio, fin = a, b
+		default:
+			v, err := valor(rango, c)
+			if err != nil {
+				return 0, err
+			}
+			inicio, fin = v, v
+			// "5/10" significa desde 5 hasta el máximo cada 10
+			if paso > 1 {
+				fin = c.max
+			}
+		}
+
+		for v := inicio; v <= fin; v += paso {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// valor convierte un número o nombre (jan, mon, ...) validando los límites del campo
+func valor(texto string, c campo) (int, error) {
+	if c.nombres != nil {
+		if v, ok := c.nombres[strings.ToLower(texto)]; ok {
+			return v, nil
+		}
+	}
+	v, err := strconv.Atoi(texto)
+	if err != nil {
+		return 0, fmt.Errorf("%s: valor inválido %q", c.nombre, texto)
+	}
+	if v < c.min || v > c.max {
+		return 0, fmt.Errorf("%s: %d fuera de rango (%d-%d)", c.nombre, v, c.min, c.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,147 @@
+package cronexpr
+
+import (
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func fecha(t *testing.T, texto string, lugar *time.Location) time.Time {
+	t.Helper()
+	f, err := time.ParseInLocation("2006-01-02 15:04", texto, lugar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestSiguiente(t *testing.T) {
+	// El 1 de marzo de 2024 fue viernes
+	casos := []struct {
+		expresion string
+		desde     string
+		desea     string
+	}{
+		{"* * * * *", "2024-03-01 10:15", "2024-03-01 10:16"},
+		{"*/15 * * * *", "2024-03-01 10:15", "2024-03-01 10:30"},
+		{"5/20 * * * *", "2024-03-01 10:26", "2024-03-01 10:45"},
+		{"0 9-17/4 * * *", "2024-03-01 13:00", "2024-03-01 17:00"},
+		{"30 2 * * *", "2024-03-01 02:30", "2024-03-02 02:30"},
+		{"0 0 1,15 * *", "2024-03-01 00:00", "2024-03-15 00:00"},
+		{"0 12 * jan,JUL *", "2024-03-01 00:00", "2024-07-01 12:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"@hourly", "2024-03-01 23:59", "2024-03-02 00:00"},
+		{"@weekly", "2024-03-01 00:00", "2024-03-03 00:00"},
+		{"@yearly", "2024-03-01 00:00", "2025-01-01 00:00"},
+		// El domingo es 0 o 7
+		{"0 8 * * 7", "2024-03-01 00:00", "2024-03-03 08:00"},
+		{"0 8 * * mon-wed", "2024-03-01 00:00", "2024-03-04 08:00"},
+
+		// Con los dos campos de día restringidos basta con cualquiera: el 13 o
+		// un viernes
+		{"0 0 13 * 5", "2024-03-01 00:00", "2024-03-08 00:00"},
+		{"0 0 13 * 5", "2024-03-08 00:00", "2024-03-13 00:00"},
+		// Con uno solo en "*" manda el otro
+		{"0 0 13 * *", "2024-03-01 00:00", "2024-03-13 00:00"},
+		{"0 0 * * 5", "2024-03-08 00:00", "2024-03-15 00:00"},
+		// "*/2" empieza con "*": no cuenta como restricción, así que manda el viernes
+		{"0 0 */2 * 5", "2024-03-01 00:00", "2024-03-08 00:00"},
+	}
+	for _, caso := range casos {
+		e, err := Parsear(caso.expresion)
+		if err != nil {
+			t.Fatalf("%s: %v", caso.expresion, err)
+		}
+		got := e.Siguiente(fecha(t, caso.desde, time.UTC))
+		if desea := fecha(t, caso.desea, time.UTC); !got.Equal(desea) {
+			t.Errorf("%s desde %s: %s; se esperaba %s", caso.expresion, caso.desde, got.Format("2006-01-02 15:04 Mon"), caso.desea)
+		}
+	}
+}
+
+func TestSiguienteSinCoincidencia(t *testing.T) {
+	for _, texto := range []string{"0 0 31 2 *", "@reboot"} {
+		e, err := Parsear(texto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e.Siguiente(time.Now()); !got.IsZero() {
+			t.Errorf("%s: %s; se esperaba el tiempo cero", texto, got)
+		}
+	}
+}
+
+// siguientes devuelve las n próximas ejecuciones desde desde, en UTC
+func siguientes(t *testing.T, texto string, desde time.Time, n int) []string {
+	t.Helper()
+	e, err := Parsear(texto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var horas []string
+	for i := 0; i < n; i++ {
+		desde = e.Siguiente(desde)
+		horas = append(horas, desde.UTC().Format("01-02 15:04"))
+	}
+	return horas
+}
+
+func TestSiguienteCambioDeHorario(t *testing.T) {
+	nuevaYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	casos := []struct {
+		nombre    string
+		expresion string
+		lugar     *time.Location
+		desde     string
+		desea     []string
+	}{
+		// 10 de marzo de 2024 en Nueva York: a las 2:00 EST se pasa a las 3:00 EDT
+		{"hora que no existe", "30 2 * * *", nuevaYork, "2024-03-09 12:00", []string{"03-11 06:30", "03-12 06:30"}},
+		{"hora siguiente al salto", "30 3 * * *", nuevaYork, "2024-03-09 12:00", []string{"03-10 07:30", "03-11 07:30"}},
+		{"cada 30 minutos en el salto", "*/30 * * * *", nuevaYork, "2024-03-10 01:00", []string{"03-10 06:30", "03-10 07:00", "03-10 07:30"}},
+		// 8 de septiembre de 2024 en Santiago: la medianoche no existe, el
+		// reloj pasa de las 23:59 a la 1:00
+		{"medianoche que no existe", "0 0 * * *", santiago, "2024-09-07 12:00", []string{"09-09 03:00"}},
+		{"después de la medianoche que no existe", "0 1 * * *", santiago, "2024-09-07 12:00", []string{"09-08 04:00"}},
+		// 3 de noviembre de 2024 en Nueva York: a las 2:00 EDT se vuelve a la 1:00 EST
+		{"hora repetida", "30 1 * * *", nuevaYork, "2024-11-02 12:00", []string{"11-03 05:30", "11-04 06:30"}},
+		{"hora fija con minutos", "0,30 1 * * *", nuevaYork, "2024-11-03 00:00", []string{"11-03 05:00", "11-03 05:30", "11-04 06:00"}},
+		{"a toda hora en la repetida", "30 * * * *", nuevaYork, "2024-11-03 00:00", []string{"11-03 04:30", "11-03 05:30", "11-03 06:30", "11-03 07:30"}},
+	}
+	for _, caso := range casos {
+		got := siguientes(t, caso.expresion, fecha(t, caso.desde, caso.lugar), len(caso.desea))
+		if !slices.Equal(got, caso.desea) {
+			t.Errorf("%s (%s): %v; se esperaba %v (UTC)", caso.nombre, caso.expresion, got, caso.desea)
+		}
+	}
+}
+
+func TestParsearInvalido(t *testing.T) {
+	for _, texto := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"1,,2 * * * *",
+		"* * * foo *",
+		"@cada-rato",
+	} {
+		if err := Validar(texto); err == nil {
+			t.Errorf("%q debería ser inválida", texto)
+		}
+	}
+}
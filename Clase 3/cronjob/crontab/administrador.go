@@ -0,0 +1,143 @@
+package crontab
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Administrador modifica solo el bloque de su marca dentro del crontab
+type Administrador struct {
+	Backend Backend
+	Marca   string
+	// DryRun calcula el resultado y la diferencia sin escribir el crontab
+	DryRun bool
+}
+
+// Resultado describe lo que hizo (o haría, en dry-run) una operación
+type Resultado struct {
+	Cambio  bool
+	Antes   string
+	Despues string
+}
+
+// Diff devuelve la diferencia entre el crontab anterior y el nuevo
+func (r Resultado) Diff() string {
+	return Diff(r.Antes, r.Despues)
+}
+
+// Nuevo crea un administrador sobre el crontab del sistema
+func Nuevo(marca string) *Administrador {
+	if marca == "" {
+		marca = MarcaPorDefecto
+	}
+	return &Administrador{Backend: Sistema{}, Marca: marca}
+}
+
+// Agregar instala la entrada; si ya existe una con el mismo nombre se reemplaza.
+// Si la entrada ya está tal cual no se escribe nada.
+func (a *Administrador) Agregar(e Entrada) (Resultado, error) {
+	if err := e.Validar(); err != nil {
+		return Resultado{}, err
+	}
+	return a.aplicar(func(arch *archivo) error {
+		if i := arch.buscar(e.Nombre); i >= 0 {
+			arch.propias[i] = e
+		} else {
+			arch.propias = append(arch.propias, e)
+		}
+		return nil
+	})
+}
+
+// Quitar elimina la entrada con ese nombre
+func (a *Administrador) Quitar(nombre string) (Resultado, error) {
+	return a.aplicar(func(arch *archivo) error {
+		i := arch.buscar(nombre)
+		if i < 0 {
+			return fmt.Errorf("no existe la entrada %q en el bloque %s", nombre, a.Marca)
+		}
+		arch.propias = append(arch.propias[:i], arch.propias[i+1:]...)
+		return nil
+	})
+}
+
+// QuitarTodas elimina el bloque completo, dejando intactas las entradas ajenas
+func (a *Administrador) QuitarTodas() (Resultado, error) {
+	return a.aplicar(func(arch *archivo) error {
+		arch.propias = nil
+		return nil
+	})
+}
+
+// Sincronizar deja en el bloque exactamente las entradas dadas
+func (a *Administrador) Sincronizar(entradas []Entrada) (Resultado, error) {
+	vistos := make(map[string]bool)
+	for _, e := range entradas {
+		if err := e.Validar(); err != nil {
+			return Resultado{}, err
+		}
+		if vistos[e.Nombre] {
+			return Resultado{}, fmt.Errorf("entrada duplicada: %s", e.Nombre)
+		}
+		vistos[e.Nombre] = true
+	}
+	return a.aplicar(func(arch *archivo) error {
+		arch.propias = append([]Entrada(nil), entradas...)
+		return nil
+	})
+}
+
+// Listar devuelve las entradas del bloque propio
+func (a *Administrador) Listar() ([]Entrada, error) {
+	contenido, err := a.Backend.Leer()
+	if err != nil {
+		return nil, err
+	}
+	arch, err := parsear(contenido, a.Marca)
+	if err != nil {
+		return nil, err
+	}
+	return arch.propias, nil
+}
+
+// Contenido devuelve el crontab completo, incluidas las entradas ajenas
+func (a *Administrador) Contenido() (string, error) {
+	return a.Backend.Leer()
+}
+
+// aplicar lee el crontab, aplica el cambio sobre el bloque y lo escribe solo si
+// el resultado es distinto (y no se está en dry-run)
+func (a *Administrador) aplicar(cambio func(*archivo) error) (Resultado, error) {
+	antes, err := a.Backend.Leer()
+	if err != nil {
+		return Resultado{}, err
+	}
+	arch, err := parsear(antes, a.Marca)
+	if err != nil {
+		return Resultado{}, err
+	}
+	if err := cambio(arch); err != nil {
+		return Resultado{}, err
+	}
+
+	// texto quita los saltos de línea sobrantes del final; se compara con el
+	// crontab anterior normalizado igual para no reescribirlo solo por eso
+	despues := arch.texto(a.Marca)
+	res := Resultado{Cambio: despues != normalizar(antes), Antes: antes, Despues: despues}
+	if !res.Cambio || a.DryRun {
+		return res, nil
+	}
+	if err := a.Backend.Escribir(despues); err != nil {
+		return Resultado{}, err
+	}
+	return res, nil
+}
+
+// normalizar deja el texto con un solo salto de línea al final, como texto
+func normalizar(contenido string) string {
+	contenido = strings.TrimRight(contenido, "\n")
+	if contenido == "" {
+		return ""
+	}
+	return contenido + "\n"
+}
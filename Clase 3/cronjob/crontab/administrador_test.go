@@ -0,0 +1,230 @@
+package crontab
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ajeno = "0 3 * * * /usr/bin/respaldo.sh\n"
+
+func nuevoAdmin(contenido string) (*Administrador, *Memoria) {
+	m := &Memoria{Contenido: contenido}
+	return &Administrador{Backend: m, Marca: "prueba"}, m
+}
+
+func entrada(nombre string) Entrada {
+	return Entrada{Nombre: nombre, Expresion: "*/5 * * * *", Comando: "/opt/" + nombre + ".sh"}
+}
+
+func TestAgregarConservaLineasAjenas(t *testing.T) {
+	admin, m := nuevoAdmin(ajeno)
+
+	res, err := admin.Agregar(entrada("generador"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Cambio || m.Escrituras != 1 {
+		t.Fatalf("Cambio = %v, escrituras = %d; se esperaba una escritura", res.Cambio, m.Escrituras)
+	}
+	esperado := ajeno +
+		"# BEGIN prueba\n" +
+		"# nombre: generador\n" +
+		"*/5 * * * * /opt/generador.sh\n" +
+		"# END prueba\n"
+	if m.Contenido != esperado {
+		t.Fatalf("crontab:\n%s\nse esperaba:\n%s", m.Contenido, esperado)
+	}
+}
+
+func TestAgregarDosVecesNoReescribe(t *testing.T) {
+	admin, m := nuevoAdmin("")
+	for i := 0; i < 2; i++ {
+		if _, err := admin.Agregar(entrada("generador")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Escrituras != 1 {
+		t.Fatalf("escrituras = %d, se esperaba 1", m.Escrituras)
+	}
+	if n := strings.Count(m.Contenido, "generador.sh"); n != 1 {
+		t.Fatalf("la entrada aparece %d veces", n)
+	}
+}
+
+func TestAgregarReemplazaPorNombre(t *testing.T) {
+	admin, m := nuevoAdmin("")
+	admin.Agregar(entrada("generador"))
+
+	cambiada := entrada("generador")
+	cambiada.Expresion = "@hourly"
+	if _, err := admin.Agregar(cambiada); err != nil {
+		t.Fatal(err)
+	}
+	entradas, _ := admin.Listar()
+	if len(entradas) != 1 || entradas[0] != cambiada {
+		t.Fatalf("entradas = %+v", entradas)
+	}
+	if m.Escrituras != 2 {
+		t.Fatalf("escrituras = %d, se esperaban 2", m.Escrituras)
+	}
+}
+
+func TestQuitar(t *testing.T) {
+	admin, m := nuevoAdmin(ajeno)
+	admin.Sincronizar([]Entrada{entrada("a"), entrada("b")})
+
+	if _, err := admin.Quitar("a"); err != nil {
+		t.Fatal(err)
+	}
+	entradas, _ := admin.Listar()
+	if len(entradas) != 1 || entradas[0].Nombre != "b" {
+		t.Fatalf("entradas = %+v", entradas)
+	}
+	if _, err := admin.Quitar("a"); err == nil {
+		t.Fatal("quitar una entrada inexistente debería fallar")
+	}
+
+	if _, err := admin.QuitarTodas(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Contenido != ajeno {
+		t.Fatalf("sin entradas propias debería quedar solo lo ajeno, quedó:\n%s", m.Contenido)
+	}
+}
+
+func TestSincronizar(t *testing.T) {
+	admin, m := nuevoAdmin(ajeno)
+	entradas := []Entrada{entrada("a"), entrada("b")}
+
+	if _, err := admin.Sincronizar(entradas); err != nil {
+		t.Fatal(err)
+	}
+	res, err := admin.Sincronizar(entradas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Cambio || m.Escrituras != 1 {
+		t.Fatalf("la segunda sincronización debería ser un no-op (Cambio = %v, escrituras = %d)", res.Cambio, m.Escrituras)
+	}
+
+	listadas, _ := admin.Listar()
+	if !reflect.DeepEqual(listadas, entradas) {
+		t.Fatalf("entradas = %+v", listadas)
+	}
+
+	if _, err := admin.Sincronizar([]Entrada{entrada("a"), entrada("a")}); err == nil {
+		t.Fatal("una entrada duplicada debería fallar")
+	}
+}
+
+func TestSincronizarConLineasVaciasAlFinal(t *testing.T) {
+	admin, m := nuevoAdmin("")
+	admin.Agregar(entrada("a"))
+	m.Contenido = ajeno + "\n\n" + m.Contenido + "\n\n\n"
+	escrituras := m.Escrituras
+
+	for i := 0; i < 3; i++ {
+		res, err := admin.Sincronizar([]Entrada{entrada("a")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Cambio {
+			t.Fatalf("sincronización %d: no debería haber cambios\n%s", i+1, res.Diff())
+		}
+	}
+	if m.Escrituras != escrituras {
+		t.Fatalf("se reescribió el crontab %d veces", m.Escrituras-escrituras)
+	}
+}
+
+func TestDryRunNoEscribe(t *testing.T) {
+	admin, m := nuevoAdmin(ajeno)
+	admin.DryRun = true
+
+	res, err := admin.Agregar(entrada("generador"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Cambio || m.Escrituras != 0 || m.Contenido != ajeno {
+		t.Fatalf("dry-run: Cambio = %v, escrituras = %d", res.Cambio, m.Escrituras)
+	}
+	if diff := res.Diff(); !strings.Contains(diff, "+ */5 * * * * /opt/generador.sh") {
+		t.Fatalf("diff sin la línea nueva:\n%s", diff)
+	}
+}
+
+func TestFormatoAnterior(t *testing.T) {
+	admin, _ := nuevoAdmin(ajeno + "# prueba\n* * * * * /opt/viejo.sh\n")
+
+	entradas, err := admin.Listar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entradas) != 1 || entradas[0].Comando != "/opt/viejo.sh" {
+		t.Fatalf("entradas = %+v", entradas)
+	}
+}
+
+func TestBloqueSinCerrar(t *testing.T) {
+	admin, m := nuevoAdmin("# BEGIN prueba\n* * * * * /opt/a.sh\n")
+	if _, err := admin.Agregar(entrada("b")); err == nil {
+		t.Fatal("un bloque sin cerrar debería fallar")
+	}
+	if m.Escrituras != 0 {
+		t.Fatal("no se debería escribir un crontab que no se pudo leer")
+	}
+}
+
+func TestErroresDelBackend(t *testing.T) {
+	admin, m := nuevoAdmin("")
+	m.ErrorEscribir = errors.New("sin permiso")
+	if _, err := admin.Agregar(entrada("a")); err == nil {
+		t.Fatal("el error de escritura debería devolverse")
+	}
+	m.ErrorLeer = errors.New("crontab no disponible")
+	if _, err := admin.Listar(); err == nil {
+		t.Fatal("el error de lectura debería devolverse")
+	}
+}
+
+func TestValidar(t *testing.T) {
+	casos := map[string]Entrada{
+		"sin nombre":         {Expresion: "* * * * *", Comando: "x"},
+		"expresión inválida": {Nombre: "a", Expresion: "* * *", Comando: "x"},
+		"sin comando":        {Nombre: "a", Expresion: "* * * * *", Comando: " "},
+		"salto de línea":     {Nombre: "a", Expresion: "* * * * *", Comando: "x\ny"},
+		"% sin escapar":      {Nombre: "a", Expresion: "* * * * *", Comando: "date +%s"},
+	}
+	for nombre, e := range casos {
+		if err := e.Validar(); err == nil {
+			t.Errorf("%s: se esperaba un error", nombre)
+		}
+	}
+	if err := (Entrada{Nombre: "a", Expresion: "@daily", Comando: `date +\%s`}).Validar(); err != nil {
+		t.Errorf("%% escapado: %v", err)
+	}
+}
+
+func TestComandoCitado(t *testing.T) {
+	args := []string{"sh", "-c", "echo 'a b' > /tmp/x", "Clase 3", "100%"}
+	linea := ComandoCitado(args)
+	if err := (Entrada{Nombre: "a", Expresion: "* * * * *", Comando: linea}).Validar(); err != nil {
+		t.Fatalf("%s: %v", linea, err)
+	}
+
+	// El shell (como lo hace cron, después de convertir \% en %) debe ver los mismos argumentos
+	shell := strings.ReplaceAll(linea, `\%`, "%")
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh no disponible")
+	}
+	salida, err := exec.Command("sh", "-c", `imprimir() { for a in "$@"; do printf '%s\n' "$a"; done; }; imprimir `+shell).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(salida), "\n"), "\n"); !reflect.DeepEqual(got, args) {
+		t.Fatalf("argumentos = %q, se esperaba %q", got, args)
+	}
+}
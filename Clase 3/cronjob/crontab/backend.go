@@ -0,0 +1,73 @@
+package crontab
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Backend es el origen/destino del texto completo del crontab.
+// Sistema usa el comando crontab; Memoria sirve para pruebas y simulaciones.
+type Backend interface {
+	Leer() (string, error)
+	Escribir(contenido string) error
+}
+
+// Sistema lee y escribe el crontab del usuario actual con el comando crontab
+type Sistema struct{}
+
+// Leer obtiene el crontab actual; un crontab inexistente se toma como vacío
+func (Sistema) Leer() (string, error) {
+	cmd := exec.Command("crontab", "-l")
+	var salida, errores bytes.Buffer
+	cmd.Stdout = &salida
+	cmd.Stderr = &errores
+
+	if err := cmd.Run(); err != nil {
+		// "no crontab for <usuario>" significa que todavía no hay entradas
+		if strings.Contains(errores.String(), "no crontab") {
+			return "", nil
+		}
+		return "", fmt.Errorf("error leyendo crontab: %v: %s", err, strings.TrimSpace(errores.String()))
+	}
+	return salida.String(), nil
+}
+
+// Escribir reemplaza el crontab completo
+func (Sistema) Escribir(contenido string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(contenido)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error escribiendo crontab: %v\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// Memoria guarda el crontab en un string; cuenta las escrituras para poder
+// comprobar que una operación idempotente no reescribe el crontab
+type Memoria struct {
+	Contenido     string
+	Escrituras    int
+	ErrorLeer     error
+	ErrorEscribir error
+}
+
+// Leer devuelve el contenido guardado
+func (m *Memoria) Leer() (string, error) {
+	if m.ErrorLeer != nil {
+		return "", m.ErrorLeer
+	}
+	return m.Contenido, nil
+}
+
+// Escribir reemplaza el contenido guardado
+func (m *Memoria) Escribir(contenido string) error {
+	if m.ErrorEscribir != nil {
+		return m.ErrorEscribir
+	}
+	m.Contenido = contenido
+	m.Escrituras++
+	return nil
+}
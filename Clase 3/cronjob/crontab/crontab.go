@@ -0,0 +1,250 @@
+// Package crontab administra las entradas del crontab del usuario actual.
+//
+// Las entradas propias se guardan dentro de un bloque delimitado por
+// comentarios, por ejemplo:
+//
+//	# BEGIN sopes1-daemon
+//	# nombre: generador
+//	* * * * * /ruta/script.sh >> /ruta/script.log 2>&1
+//	# END sopes1-daemon
+//
+// Todo lo que está fuera del bloque pertenece al usuario y se conserva tal cual.
+// Así se puede instalar la misma entrada varias veces sin duplicarla y
+// eliminar solo las entradas propias.
+package crontab
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"cronjob/cronexpr"
+)
+
+// MarcaPorDefecto es la marca del bloque si no se indica otra
+const MarcaPorDefecto = "sopes1-daemon"
+
+const prefijoNombre = "# nombre: "
+
+// Entrada es una línea del crontab identificada por nombre dentro del bloque
+type Entrada struct {
+	Nombre    string
+	Expresion string
+	Comando   string
+}
+
+// Linea devuelve la entrada con el formato de crontab
+func (e Entrada) Linea() string {
+	return e.Expresion + " " + e.Comando
+}
+
+// Validar revisa el nombre, la expresión cron y el comando antes de escribirlos
+func (e Entrada) Validar() error {
+	if e.Nombre == "" || strings.ContainsAny(e.Nombre, "\n\r") {
+		return fmt.Errorf("nombre de entrada inválido: %q", e.Nombre)
+	}
+	if err := cronexpr.Validar(e.Expresion); err != nil {
+		return err
+	}
+	if strings.TrimSpace(e.Comando) == "" {
+		return fmt.Errorf("la entrada %s no tiene comando", e.Nombre)
+	}
+	if strings.ContainsAny(e.Comando, "\n\r") {
+		return fmt.Errorf("el comando de %s no puede tener saltos de línea", e.Nombre)
+	}
+	// cron convierte los % sin escapar en saltos de línea
+	if strings.Contains(strings.ReplaceAll(e.Comando, `\%`, ""), "%") {
+		return fmt.Errorf("el comando de %s tiene un %% sin escapar (usar \\%%)", e.Nombre)
+	}
+	return nil
+}
+
+// ComandoCitado une los argumentos en una línea de comando para cron,
+// citando cada uno para que el shell los vuelva a separar igual
+func ComandoCitado(args []string) string {
+	citados := make([]string, len(args))
+	for i, a := range args {
+		citados[i] = citar(a)
+	}
+	return strings.Join(citados, " ")
+}
+
+// citar protege un argumento para el shell de cron; además escapa '%',
+// que cron convierte en salto de línea
+func citar(s string) string {
+	seguro := s != ""
+	for _, r := range s {
+		if !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./=:,+@", r) {
+			seguro = false
+			break
+		}
+	}
+	if !seguro {
+		s = "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	return strings.ReplaceAll(s, "%", `\%`)
+}
+
+// ParsearLinea separa una línea "expresión comando" del crontab.
+// La expresión puede ser de 5 campos o una macro como @hourly.
+func ParsearLinea(linea string) (expresion, comando string, err error) {
+	campos := strings.Fields(linea)
+	n := 5
+	if len(campos) > 0 && strings.HasPrefix(campos[0], "@") {
+		n = 1
+	}
+	if len(campos) <= n {
+		return "", "", fmt.Errorf("línea de crontab incompleta: %q", linea)
+	}
+
+	expresion = strings.Join(campos[:n], " ")
+	if err := cronexpr.Validar(expresion); err != nil {
+		return "", "", err
+	}
+
+	// El comando se toma del texto original para conservar sus espacios
+	resto := strings.TrimSpace(linea)
+	for i := 0; i < n; i++ {
+		resto = strings.TrimLeft(resto, " \t")
+		resto = resto[len(campos[i]):]
+	}
+	return expresion, strings.TrimSpace(resto), nil
+}
+
+// HacerEjecutable cambia los permisos del archivo para que sea ejecutable
+func HacerEjecutable(ruta string) error {
+	if err := os.Chmod(ruta, 0755); err != nil {
+		return fmt.Errorf("error haciendo ejecutable el script %s: %v", ruta, err)
+	}
+	return nil
+}
+
+// archivo es el crontab ya separado en líneas ajenas y entradas propias
+type archivo struct {
+	ajenas   []string
+	posicion int // índice de ajenas donde va el bloque
+	propias  []Entrada
+}
+
+func inicioBloque(marca string) string { return "# BEGIN " + marca }
+func finBloque(marca string) string    { return "# END " + marca }
+
+// parsear separa el contenido del crontab; también reconoce el formato anterior
+// en el que cada entrada propia iba precedida solo por "# <marca>"
+func parsear(contenido, marca string) (*archivo, error) {
+	a := &archivo{posicion: -1}
+	contenido = strings.TrimRight(contenido, "\n")
+	if contenido == "" {
+		return a, nil
+	}
+	lineas := strings.Split(contenido, "\n")
+
+	for i := 0; i < len(lineas); i++ {
+		linea := lineas[i]
+		switch strings.TrimSpace(linea) {
+		case inicioBloque(marca):
+			if a.posicion < 0 {
+				a.posicion = len(a.ajenas)
+			}
+			fin := -1
+			for j := i + 1; j < len(lineas); j++ {
+				if strings.TrimSpace(lineas[j]) == finBloque(marca) {
+					fin = j
+					break
+				}
+			}
+			if fin < 0 {
+				return nil, fmt.Errorf("el bloque %q no está cerrado, revise el crontab manualmente", marca)
+			}
+			entradas, err := parsearBloque(lineas[i+1:fin], marca)
+			if err != nil {
+				return nil, err
+			}
+			a.propias = append(a.propias, entradas...)
+			i = fin
+
+		case "# " + marca:
+			// Formato anterior: comentario de marca y la entrada en la siguiente línea
+			if i+1 < len(lineas) {
+				if expr, cmd, err := ParsearLinea(lineas[i+1]); err == nil {
+					if a.posicion < 0 {
+						a.posicion = len(a.ajenas)
+					}
+					nombre := fmt.Sprintf("%s-%d", marca, len(a.propias)+1)
+					a.propias = append(a.propias, Entrada{Nombre: nombre, Expresion: expr, Comando: cmd})
+					i++
+					continue
+				}
+			}
+			a.ajenas = append(a.ajenas, linea)
+
+		default:
+			a.ajenas = append(a.ajenas, linea)
+		}
+	}
+	return a, nil
+}
+
+// parsearBloque lee las entradas "# nombre: X" + línea de crontab de dentro del bloque
+func parsearBloque(lineas []string, marca string) ([]Entrada, error) {
+	var entradas []Entrada
+	nombre := ""
+	for _, linea := range lineas {
+		linea = strings.TrimSpace(linea)
+		switch {
+		case linea == "":
+			continue
+		case strings.HasPrefix(linea, prefijoNombre):
+			nombre = strings.TrimSpace(strings.TrimPrefix(linea, prefijoNombre))
+		case strings.HasPrefix(linea, "#"):
+			continue
+		default:
+			expr, cmd, err := ParsearLinea(linea)
+			if err != nil {
+				return nil, fmt.Errorf("bloque %s: %v", marca, err)
+			}
+			if nombre == "" {
+				nombre = fmt.Sprintf("%s-%d", marca, len(entradas)+1)
+			}
+			entradas = append(entradas, Entrada{Nombre: nombre, Expresion: expr, Comando: cmd})
+			nombre = ""
+		}
+	}
+	return entradas, nil
+}
+
+// texto reconstruye el crontab; si no hay entradas propias no se escribe el bloque
+func (a *archivo) texto(marca string) string {
+	var bloque []string
+	if len(a.propias) > 0 {
+		bloque = append(bloque, inicioBloque(marca))
+		for _, e := range a.propias {
+			bloque = append(bloque, prefijoNombre+e.Nombre, e.Linea())
+		}
+		bloque = append(bloque, finBloque(marca))
+	}
+
+	posicion := a.posicion
+	if posicion < 0 || posicion > len(a.ajenas) {
+		posicion = len(a.ajenas)
+	}
+
+	var lineas []string
+	lineas = append(lineas, a.ajenas[:posicion]...)
+	lineas = append(lineas, bloque...)
+	lineas = append(lineas, a.ajenas[posicion:]...)
+
+	if len(lineas) == 0 {
+		return ""
+	}
+	return strings.Join(lineas, "\n") + "\n"
+}
+
+func (a *archivo) buscar(nombre string) int {
+	for i, e := range a.propias {
+		if e.Nombre == nombre {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,57 @@
+package crontab
+
+import (
+	"strings"
+)
+
+// Diff compara dos versiones del crontab línea por línea. Las líneas
+// eliminadas empiezan con "-", las agregadas con "+" y las que no cambian
+// con dos espacios. Si no hay diferencias devuelve "".
+func Diff(antes, despues string) string {
+	if antes == despues {
+		return ""
+	}
+	a := separar(antes)
+	b := separar(despues)
+
+	// Subsecuencia común más larga (los crontabs son cortos, O(n*m) es suficiente)
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
+
+func separar(texto string) []string {
+	texto = strings.TrimRight(texto, "\n")
+	if texto == "" {
+		return nil
+	}
+	return strings.Split(texto, "\n")
+}
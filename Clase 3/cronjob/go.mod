@@ -0,0 +1,5 @@
+module cronjob
+
+go 1.25.5
+
+require github.com/mattn/go-sqlite3 v1.14.32
@@ -1,62 +1,252 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"log"
 	"os"
-	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"cronjob/crontab"
+	"cronjob/programador"
+	"cronjob/tarea"
 )
 
+// listaFlag permite repetir un flag (-arg a -arg b, -env A=1 -env B=2)
+type listaFlag []string
+
+func (l *listaFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listaFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+// opciones que no son parte de la tarea
+type opciones struct {
+	marca string
+	db    string
+}
+
+// Uso:
+//
+//	cronjob [install] [-config tarea.json] [-script ./script_cron.sh] [-cron "* * * * *"] ...
+//	cronjob run [...]      (lo ejecuta cron: corre el script una vez con rotación de log)
+//	cronjob schedule [...] (programador propio, sin crontab; guarda cada ejecución en SQLite)
 func main() {
-	// Ruta del script existente
-	scriptPath := "./script_cron.sh"
+	subcomando := "install"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcomando, args = args[0], args[1:]
+	}
+
+	t, op := leerTarea(subcomando, args)
+
+	switch subcomando {
+	case "install":
+		instalar(t, op.marca)
+	case "run":
+		ejecutar(t)
+	case "schedule":
+		programar(t, op.db)
+	default:
+		log.Fatalf("Subcomando desconocido %q (use install, run o schedule)", subcomando)
+	}
+}
+
+// leerTarea combina el archivo de configuración (si se indica) con los flags;
+// los flags que se escriban explícitamente tienen prioridad sobre el archivo
+func leerTarea(subcomando string, args []string) (*tarea.Tarea, opciones) {
+	fs := flag.NewFlagSet(subcomando, flag.ExitOnError)
+	config := fs.String("config", "", "archivo JSON con la configuración de la tarea")
+	nombre := fs.String("nombre", "", "nombre de la entrada en el crontab (por defecto, el del script)")
+	script := fs.String("script", "./script_cron.sh", "ruta del script a ejecutar")
+	horario := fs.String("cron", tarea.HorarioPorDefecto, "expresión cron")
+	rutaLog := fs.String("log", "", "archivo de log (por defecto <script>.log)")
+	logMaxMB := fs.Int("log-max-mb", tarea.LogMaxMBPorDefecto, "tamaño máximo del log antes de rotarlo (MB)")
+	logRespaldos := fs.Int("log-respaldos", tarea.LogRespaldosPorDefecto, "cantidad de logs rotados a conservar")
+	timeout := fs.Int("timeout", 0, "segundos máximos por ejecución (0 = sin límite)")
+	marca := fs.String("marca", crontab.MarcaPorDefecto, "marca del bloque propio en el crontab")
+	db := fs.String("db", "", "base de datos SQLite del historial en modo schedule (por defecto <carpeta del script>/ejecuciones.db)")
+	var argumentos, entorno listaFlag
+	fs.Var(&argumentos, "arg", "argumento para el script (se puede repetir)")
+	fs.Var(&entorno, "env", "variable de entorno CLAVE=valor (se puede repetir)")
+	fs.Parse(args)
+
+	t := &tarea.Tarea{LogMaxMB: *logMaxMB, LogRespaldos: *logRespaldos}
+	if *config != "" {
+		cargada, err := tarea.Cargar(*config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t = cargada
+	}
+
+	// Se aplican los flags escritos explícitamente; si no hay archivo, también los valores por defecto
+	explicitos := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicitos[f.Name] = true })
+	usar := func(nombreFlag string) bool { return *config == "" || explicitos[nombreFlag] }
+
+	if usar("nombre") && *nombre != "" {
+		t.Nombre = *nombre
+	}
+	if usar("script") {
+		t.Script = *script
+	}
+	if usar("cron") {
+		t.Horario = *horario
+	}
+	if usar("log") && *rutaLog != "" {
+		t.Log = *rutaLog
+	}
+	if explicitos["log-max-mb"] {
+		t.LogMaxMB = *logMaxMB
+	}
+	if explicitos["log-respaldos"] {
+		t.LogRespaldos = *logRespaldos
+	}
+	if usar("timeout") {
+		t.TimeoutSegundos = *timeout
+	}
+	if len(argumentos) > 0 {
+		t.Args = argumentos
+	}
+	for _, kv := range entorno {
+		clave, valor, ok := strings.Cut(kv, "=")
+		if !ok {
+			log.Fatalf("Variable de entorno inválida %q, use CLAVE=valor", kv)
+		}
+		if t.Env == nil {
+			t.Env = make(map[string]string)
+		}
+		t.Env[clave] = valor
+	}
+
+	if err := t.Preparar(); err != nil {
+		log.Fatal(err)
+	}
+
+	op := opciones{marca: *marca, db: *db}
+	if op.db == "" {
+		op.db = filepath.Join(filepath.Dir(t.Script), "ejecuciones.db")
+	}
+	return t, op
+}
 
+// instalar hace ejecutable el script y registra en el crontab la ejecución
+// a través de este mismo programa (subcomando run), que se encarga de los
+// argumentos, el entorno y la rotación del log
+func instalar(t *tarea.Tarea, marca string) {
 	// 1. Hacer el script ejecutable
-	hacerEjecutable(scriptPath)
+	hacerEjecutable(t.Script)
 
-	// 2. Agregar cronjob que se ejecute cada minuto
-	agregarCronJob(scriptPath)
+	// 2. Agregar cronjob con la configuración indicada
+	agregarCronJob(t, marca)
 
 	// 3. Verificar que se agregó correctamente
-	verificarCronJobs()
+	verificarCronJobs(marca)
 
 	log.Println("Cronjob configurado exitosamente!")
 }
 
+// ejecutar corre el script una vez; el código de salida del script se propaga
+func ejecutar(t *tarea.Tarea) {
+	res, err := t.Ejecutar(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(res.Codigo)
+}
+
+// programar ejecuta la tarea con el programador propio hasta recibir SIGINT/SIGTERM
+func programar(t *tarea.Tarea, rutaDB string) {
+	registro, err := programador.AbrirRegistroSQLite(rutaDB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer registro.Close()
+
+	p := programador.Nuevo(registro)
+	if err := p.Agregar(t); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Programador iniciado, historial en %s", rutaDB)
+	if err := p.Ejecutar(ctx); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Programador detenido")
+}
+
 // hacerEjecutable cambia los permisos del archivo para que sea ejecutable
 func hacerEjecutable(ruta string) {
-	err := os.Chmod(ruta, 0755)
-	if err != nil {
-		log.Fatalf("Error haciendo ejecutable el script: %v", err)
+	if err := crontab.HacerEjecutable(ruta); err != nil {
+		log.Fatal(err)
 	}
 
 	log.Printf("Script %s ahora es ejecutable", ruta)
 }
 
-// agregarCronJob agrega una nueva entrada a crontab
-func agregarCronJob(rutaScript string) {
-	expresionCron := "* * * * *"
-	comandoCron := fmt.Sprintf("%s %s >> %s.log 2>&1", expresionCron, rutaScript, rutaScript)
+// agregarCronJob agrega una nueva entrada a crontab (si ya existe no la duplica)
+func agregarCronJob(t *tarea.Tarea, marca string) {
+	ejecutable, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Error obteniendo ruta del ejecutable: %v", err)
+	}
+	// Con "go run" el binario vive en un directorio temporal que se borra al terminar
+	if strings.Contains(ejecutable, "go-build") {
+		log.Fatal("Compile el programa con 'go build' antes de instalar el cronjob: el binario de 'go run' es temporal")
+	}
 
-	cmd := exec.Command("bash", "-c",
-		fmt.Sprintf("(crontab -l 2>/dev/null; echo \"%s\") | crontab -", comandoCron))
+	entrada := crontab.Entrada{
+		Nombre:    t.Nombre,
+		Expresion: t.Horario,
+		Comando:   comandoCron(ejecutable, t),
+	}
 
-	output, err := cmd.CombinedOutput()
+	res, err := crontab.Nuevo(marca).Agregar(entrada)
 	if err != nil {
-		log.Fatalf("Error agregando cronjob: %v\nOutput: %s", err, string(output))
+		log.Fatalf("Error agregando cronjob: %v", err)
 	}
 
-	log.Printf("Cronjob agregado: %s", comandoCron)
+	if res.Cambio {
+		log.Printf("Cronjob agregado: %s", entrada.Linea())
+	} else {
+		log.Printf("El cronjob ya existía: %s", entrada.Linea())
+	}
 }
 
-// verificarCronJobs lista todos los cronjobs configurados
-func verificarCronJobs() {
-	cmd := exec.Command("crontab", "-l")
-	output, err := cmd.CombinedOutput()
+// comandoCron arma la línea "programa run -script ..." con rutas absolutas
+func comandoCron(ejecutable string, t *tarea.Tarea) string {
+	partes := []string{
+		ejecutable, "run",
+		"-nombre", t.Nombre,
+		"-script", t.Script,
+		"-log", t.Log,
+		"-log-max-mb", strconv.Itoa(t.LogMaxMB),
+		"-log-respaldos", strconv.Itoa(t.LogRespaldos),
+	}
+	if t.TimeoutSegundos > 0 {
+		partes = append(partes, "-timeout", strconv.Itoa(t.TimeoutSegundos))
+	}
+	for _, kv := range t.EnvOrdenado() {
+		partes = append(partes, "-env", kv)
+	}
+	for _, a := range t.Args {
+		partes = append(partes, "-arg", a)
+	}
+	return crontab.ComandoCitado(partes)
+}
 
+// verificarCronJobs lista todos los cronjobs configurados
+func verificarCronJobs(marca string) {
+	output, err := crontab.Nuevo(marca).Contenido()
 	if err != nil {
-		log.Printf("No se pudieron listar cronjobs (puede estar vacío): %v", err)
+		log.Printf("No se pudieron listar cronjobs: %v", err)
 	} else {
-		log.Printf("=== Cronjobs Actuales ===\n%s=== Fin de Cronjobs ===", string(output))
+		log.Printf("=== Cronjobs Actuales ===\n%s=== Fin de Cronjobs ===", output)
 	}
-}
\ No newline at end of file
+}
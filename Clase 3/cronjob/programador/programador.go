@@ -0,0 +1,187 @@
+// Package programador ejecuta tareas con expresiones cron dentro del mismo
+// proceso, sin depender del comando crontab (útil en contenedores mínimos).
+//
+// Cada tarea se ejecuta con su timeout y nunca se solapa consigo misma: si al
+// llegar su hora la ejecución anterior sigue corriendo, esa ejecución se omite.
+// Cada ejecución (u omisión) se guarda en el Registro.
+package programador
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"cronjob/cronexpr"
+	"cronjob/tarea"
+)
+
+// Estados posibles de una ejecución
+const (
+	EstadoOK            = "ok"
+	EstadoError         = "error"
+	EstadoTiempoAgotado = "tiempo_agotado"
+	EstadoOmitida       = "omitida"
+)
+
+// Ejecucion es lo que se guarda de cada vez que tocaba correr una tarea
+type Ejecucion struct {
+	Tarea    string
+	Inicio   time.Time
+	Duracion time.Duration
+	Codigo   int
+	Estado   string
+	Error    string
+}
+
+// Registro guarda el historial de ejecuciones
+type Registro interface {
+	Registrar(e Ejecucion) error
+}
+
+type entrada struct {
+	tarea     *tarea.Tarea
+	expresion *cronexpr.Expresion
+	siguiente time.Time
+
+	mu        sync.Mutex
+	corriendo bool
+}
+
+// Programador ejecuta las tareas agregadas según su horario
+type Programador struct {
+	registro Registro
+	entradas []*entrada
+	wg       sync.WaitGroup
+}
+
+// Nuevo crea un programador; registro puede ser nil si no se quiere historial
+func Nuevo(registro Registro) *Programador {
+	return &Programador{registro: registro}
+}
+
+// Agregar suma una tarea ya preparada (tarea.Preparar) al programador
+func (p *Programador) Agregar(t *tarea.Tarea) error {
+	expr, err := cronexpr.Parsear(t.Horario)
+	if err != nil {
+		return fmt.Errorf("tarea %s: %v", t.Nombre, err)
+	}
+	p.entradas = append(p.entradas, &entrada{tarea: t, expresion: expr})
+	return nil
+}
+
+// Ejecutar bloquea hasta que se cancele ctx. Las tareas @reboot se ejecutan
+// una sola vez al iniciar. Al terminar espera a las ejecuciones en curso
+// (que se cancelan junto con ctx).
+func (p *Programador) Ejecutar(ctx context.Context) error {
+	if len(p.entradas) == 0 {
+		return fmt.Errorf("no hay tareas que programar")
+	}
+	defer p.wg.Wait()
+
+	ahora := time.Now()
+	for _, e := range p.entradas {
+		if e.expresion.Reboot {
+			p.lanzar(ctx, e)
+			continue
+		}
+		e.siguiente = e.expresion.Siguiente(ahora)
+		log.Printf("Tarea %s programada (%s), próxima ejecución: %s",
+			e.tarea.Nombre, e.tarea.Horario, e.siguiente.Format(time.RFC3339))
+	}
+
+	for {
+		proxima := p.proxima()
+		if proxima.IsZero() {
+			log.Println("No quedan tareas con horario futuro")
+			<-ctx.Done()
+			return nil
+		}
+
+		timer := time.NewTimer(time.Until(proxima))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		ahora := time.Now()
+		for _, e := range p.entradas {
+			if e.siguiente.IsZero() || e.siguiente.After(ahora) {
+				continue
+			}
+			p.lanzar(ctx, e)
+			e.siguiente = e.expresion.Siguiente(ahora)
+		}
+	}
+}
+
+// proxima devuelve la hora más cercana entre todas las tareas
+func (p *Programador) proxima() time.Time {
+	var proxima time.Time
+	for _, e := range p.entradas {
+		if e.siguiente.IsZero() {
+			continue
+		}
+		if proxima.IsZero() || e.siguiente.Before(proxima) {
+			proxima = e.siguiente
+		}
+	}
+	return proxima
+}
+
+// lanzar ejecuta la tarea en segundo plano salvo que la anterior siga corriendo
+func (p *Programador) lanzar(ctx context.Context, e *entrada) {
+	e.mu.Lock()
+	if e.corriendo {
+		e.mu.Unlock()
+		log.Printf("Tarea %s omitida: la ejecución anterior sigue en curso", e.tarea.Nombre)
+		p.registrar(Ejecucion{Tarea: e.tarea.Nombre, Inicio: time.Now(), Codigo: -1, Estado: EstadoOmitida})
+		return
+	}
+	e.corriendo = true
+	e.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() {
+			e.mu.Lock()
+			e.corriendo = false
+			e.mu.Unlock()
+		}()
+
+		res, err := e.tarea.Ejecutar(ctx)
+		ejec := Ejecucion{
+			Tarea:    e.tarea.Nombre,
+			Inicio:   res.Inicio,
+			Duracion: res.Duracion,
+			Codigo:   res.Codigo,
+			Estado:   EstadoOK,
+		}
+		switch {
+		case err != nil:
+			ejec.Estado = EstadoError
+			ejec.Error = err.Error()
+		case res.TiempoAgotado:
+			ejec.Estado = EstadoTiempoAgotado
+		case res.Codigo != 0:
+			ejec.Estado = EstadoError
+		}
+
+		log.Printf("Tarea %s terminada: estado=%s código=%d duración=%s",
+			ejec.Tarea, ejec.Estado, ejec.Codigo, ejec.Duracion.Round(time.Millisecond))
+		p.registrar(ejec)
+	}()
+}
+
+func (p *Programador) registrar(e Ejecucion) {
+	if p.registro == nil {
+		return
+	}
+	if err := p.registro.Registrar(e); err != nil {
+		log.Println("Error registrando ejecución:", err)
+	}
+}
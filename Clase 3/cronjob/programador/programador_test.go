@@ -0,0 +1,148 @@
+package programador
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cronjob/tarea"
+)
+
+// registroPrueba guarda las ejecuciones en memoria
+type registroPrueba struct {
+	mu          sync.Mutex
+	ejecuciones []Ejecucion
+	nueva       chan struct{}
+}
+
+func nuevoRegistroPrueba() *registroPrueba {
+	return &registroPrueba{nueva: make(chan struct{}, 100)}
+}
+
+func (r *registroPrueba) Registrar(e Ejecucion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ejecuciones = append(r.ejecuciones, e)
+	r.nueva <- struct{}{}
+	return nil
+}
+
+func (r *registroPrueba) estados() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var estados []string
+	for _, e := range r.ejecuciones {
+		estados = append(estados, e.Estado)
+	}
+	return estados
+}
+
+// script crea una tarea preparada que corre el shell script dado
+func script(t *testing.T, nombre, horario, cuerpo string, timeout int) *tarea.Tarea {
+	t.Helper()
+	ruta := filepath.Join(t.TempDir(), nombre+".sh")
+	if err := os.WriteFile(ruta, []byte("#!/bin/sh\n"+cuerpo+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ta := &tarea.Tarea{Script: ruta, Horario: horario, TimeoutSegundos: timeout}
+	if err := ta.Preparar(); err != nil {
+		t.Fatal(err)
+	}
+	return ta
+}
+
+func TestEstados(t *testing.T) {
+	casos := []struct {
+		nombre  string
+		cuerpo  string
+		timeout int
+		estado  string
+		codigo  int
+	}{
+		{"bien", "echo hola", 0, EstadoOK, 0},
+		{"falla", "exit 3", 0, EstadoError, 3},
+		// El timeout termina al script y a sus hijos
+		{"lento", "sleep 30 & wait", 1, EstadoTiempoAgotado, -1},
+	}
+	for _, caso := range casos {
+		r := nuevoRegistroPrueba()
+		p := Nuevo(r)
+		e := &entrada{tarea: script(t, caso.nombre, "* * * * *", caso.cuerpo, caso.timeout)}
+		inicio := time.Now()
+		p.lanzar(context.Background(), e)
+		p.wg.Wait()
+
+		if len(r.ejecuciones) != 1 {
+			t.Fatalf("%s: %d ejecuciones registradas", caso.nombre, len(r.ejecuciones))
+		}
+		ejec := r.ejecuciones[0]
+		if ejec.Estado != caso.estado || ejec.Codigo != caso.codigo || ejec.Tarea != caso.nombre {
+			t.Errorf("%s: %+v; se esperaba estado %s y código %d", caso.nombre, ejec, caso.estado, caso.codigo)
+		}
+		if caso.timeout > 0 && time.Since(inicio) > 5*time.Second {
+			t.Errorf("%s: el timeout de %ds tardó %s", caso.nombre, caso.timeout, time.Since(inicio))
+		}
+	}
+}
+
+func TestOmitirSiSigueCorriendo(t *testing.T) {
+	r := nuevoRegistroPrueba()
+	p := Nuevo(r)
+	marca := filepath.Join(t.TempDir(), "seguir")
+	e := &entrada{tarea: script(t, "larga", "* * * * *", "while [ ! -e '"+marca+"' ]; do sleep 0.05; done", 0)}
+
+	p.lanzar(context.Background(), e)
+	p.lanzar(context.Background(), e)
+	<-r.nueva
+	if estados := r.estados(); len(estados) != 1 || estados[0] != EstadoOmitida {
+		t.Fatalf("la segunda ejecución debería omitirse mientras corre la primera: %v", estados)
+	}
+
+	os.WriteFile(marca, nil, 0644)
+	p.wg.Wait()
+	// Terminada la primera, la siguiente ya corre
+	p.lanzar(context.Background(), e)
+	p.wg.Wait()
+	estados := r.estados()
+	if len(estados) != 3 || estados[1] != EstadoOK || estados[2] != EstadoOK {
+		t.Fatalf("estados %v", estados)
+	}
+}
+
+func TestEjecutarReboot(t *testing.T) {
+	if err := Nuevo(nil).Ejecutar(context.Background()); err == nil {
+		t.Fatal("sin tareas debería fallar")
+	}
+	p := Nuevo(nil)
+	if err := p.Agregar(&tarea.Tarea{Nombre: "mala", Horario: "* * *"}); err == nil {
+		t.Fatal("un horario inválido debería fallar al agregar")
+	}
+
+	r := nuevoRegistroPrueba()
+	p = Nuevo(r)
+	if err := p.Agregar(script(t, "inicio", "@reboot", "true", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Agregar(script(t, "anual", "0 0 1 1 *", "true", 0)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	terminado := make(chan error)
+	go func() { terminado <- p.Ejecutar(ctx) }()
+
+	select {
+	case <-r.nueva:
+	case <-time.After(5 * time.Second):
+		t.Fatal("la tarea @reboot debería correr al iniciar")
+	}
+	cancel()
+	if err := <-terminado; err != nil {
+		t.Fatal(err)
+	}
+	if len(r.ejecuciones) != 1 || r.ejecuciones[0].Tarea != "inicio" {
+		t.Fatalf("ejecuciones %+v", r.ejecuciones)
+	}
+}
@@ -0,0 +1,67 @@
+package programador
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// RegistroSQLite guarda las ejecuciones en la tabla "ejecuciones"
+type RegistroSQLite struct {
+	db     *sql.DB
+	propia bool
+}
+
+// AbrirRegistroSQLite abre (o crea) la base de datos en la ruta indicada
+func AbrirRegistroSQLite(ruta string) (*RegistroSQLite, error) {
+	db, err := sql.Open("sqlite3", ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo base de datos %s: %v", ruta, err)
+	}
+	r, err := NuevoRegistroSQLite(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	r.propia = true
+	return r, nil
+}
+
+// NuevoRegistroSQLite usa una conexión existente (por ejemplo la del daemon)
+// y crea la tabla si no existe
+func NuevoRegistroSQLite(db *sql.DB) (*RegistroSQLite, error) {
+	createTable := `
+	CREATE TABLE IF NOT EXISTS ejecuciones (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tarea TEXT,
+		inicio INTEGER,
+		duracion_ms INTEGER,
+		codigo INTEGER,
+		estado TEXT,
+		error TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_ejecuciones_tarea_inicio ON ejecuciones (tarea, inicio);`
+	if _, err := db.Exec(createTable); err != nil {
+		return nil, fmt.Errorf("error creando tabla ejecuciones: %v", err)
+	}
+	return &RegistroSQLite{db: db}, nil
+}
+
+// Registrar inserta una ejecución; inicio se guarda en milisegundos como created_at en el daemon
+func (r *RegistroSQLite) Registrar(e Ejecucion) error {
+	_, err := r.db.Exec("INSERT INTO ejecuciones (tarea, inicio, duracion_ms, codigo, estado, error) VALUES (?, ?, ?, ?, ?, ?)",
+		e.Tarea, e.Inicio.UnixMilli(), e.Duracion.Milliseconds(), e.Codigo, e.Estado, e.Error)
+	if err != nil {
+		return fmt.Errorf("error insertando ejecución: %v", err)
+	}
+	return nil
+}
+
+// Close cierra la base de datos solo si la abrió el registro
+func (r *RegistroSQLite) Close() error {
+	if !r.propia {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,58 @@
+package programador
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistroSQLite(t *testing.T) {
+	ruta := filepath.Join(t.TempDir(), "historial.db")
+	r, err := AbrirRegistroSQLite(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	inicio := time.UnixMilli(1700000000123)
+	ejecuciones := []Ejecucion{
+		{Tarea: "generador", Inicio: inicio, Duracion: 1500 * time.Millisecond, Codigo: 0, Estado: EstadoOK},
+		{Tarea: "generador", Inicio: inicio.Add(time.Minute), Codigo: -1, Estado: EstadoOmitida},
+		{Tarea: "limpieza", Inicio: inicio, Duracion: time.Second, Codigo: -1, Estado: EstadoError, Error: "no se encontró"},
+	}
+	for _, e := range ejecuciones {
+		if err := r.Registrar(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Otro registro sobre la misma conexión no vuelve a crear la tabla ni la cierra
+	otro, err := NuevoRegistroSQLite(r.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otro.Close()
+
+	filas, err := r.db.Query("SELECT tarea, inicio, duracion_ms, codigo, estado, error FROM ejecuciones ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer filas.Close()
+	var i int
+	for ; filas.Next(); i++ {
+		var e Ejecucion
+		var inicioMS, duracionMS int64
+		if err := filas.Scan(&e.Tarea, &inicioMS, &duracionMS, &e.Codigo, &e.Estado, &e.Error); err != nil {
+			t.Fatal(err)
+		}
+		e.Inicio = time.UnixMilli(inicioMS)
+		e.Duracion = time.Duration(duracionMS) * time.Millisecond
+		if desea := ejecuciones[i]; e.Tarea != desea.Tarea || !e.Inicio.Equal(desea.Inicio) || e.Duracion != desea.Duracion ||
+			e.Codigo != desea.Codigo || e.Estado != desea.Estado || e.Error != desea.Error {
+			t.Errorf("fila %d: %+v; se esperaba %+v", i, e, desea)
+		}
+	}
+	if i != len(ejecuciones) {
+		t.Fatalf("%d filas; se esperaban %d", i, len(ejecuciones))
+	}
+}
@@ -0,0 +1,114 @@
+// Package rotacion implementa un archivo de log que se rota por tamaño.
+//
+// Cuando el archivo supera MaxBytes se renombra a <ruta>.1, el anterior .1
+// pasa a .2 y así hasta Respaldos; el más viejo se elimina.
+package rotacion
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Archivo es un io.WriteCloser que rota el log al superar MaxBytes
+type Archivo struct {
+	Ruta      string
+	MaxBytes  int64
+	Respaldos int
+
+	mu     sync.Mutex
+	f      *os.File
+	tamano int64
+}
+
+// Abrir abre (o crea) el log en modo append
+func Abrir(ruta string, maxBytes int64, respaldos int) (*Archivo, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("tamaño máximo de log inválido: %d", maxBytes)
+	}
+	if respaldos < 0 {
+		respaldos = 0
+	}
+	if err := os.MkdirAll(filepath.Dir(ruta), 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de logs: %v", err)
+	}
+
+	a := &Archivo{Ruta: ruta, MaxBytes: maxBytes, Respaldos: respaldos}
+	if err := a.abrir(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Write escribe en el log, rotando antes si la escritura lo haría pasar del límite
+func (a *Archivo) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.f == nil {
+		return 0, os.ErrClosed
+	}
+	if a.tamano > 0 && a.tamano+int64(len(p)) > a.MaxBytes {
+		if err := a.rotar(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := a.f.Write(p)
+	a.tamano += int64(n)
+	return n, err
+}
+
+// Close cierra el archivo actual
+func (a *Archivo) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.f == nil {
+		return nil
+	}
+	err := a.f.Close()
+	a.f = nil
+	return err
+}
+
+func (a *Archivo) abrir() error {
+	f, err := os.OpenFile(a.Ruta, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error abriendo log %s: %v", a.Ruta, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("error leyendo tamaño del log %s: %v", a.Ruta, err)
+	}
+	a.f = f
+	a.tamano = info.Size()
+	return nil
+}
+
+// rotar desplaza los respaldos (.1 -> .2, ...) y empieza un archivo nuevo
+func (a *Archivo) rotar() error {
+	if err := a.f.Close(); err != nil {
+		return fmt.Errorf("error cerrando log para rotar: %v", err)
+	}
+	a.f = nil
+
+	if a.Respaldos == 0 {
+		os.Remove(a.Ruta)
+	} else {
+		os.Remove(respaldo(a.Ruta, a.Respaldos))
+		for i := a.Respaldos - 1; i >= 1; i-- {
+			os.Rename(respaldo(a.Ruta, i), respaldo(a.Ruta, i+1))
+		}
+		if err := os.Rename(a.Ruta, respaldo(a.Ruta, 1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rotando log %s: %v", a.Ruta, err)
+		}
+	}
+	return a.abrir()
+}
+
+func respaldo(ruta string, n int) string {
+	return fmt.Sprintf("%s.%d", ruta, n)
+}
@@ -0,0 +1,196 @@
+// Package tarea describe el script que ejecuta el cronjob: ruta, horario,
+// argumentos, variables de entorno y destino del log.
+//
+// cron ejecuta los comandos sin el directorio de trabajo de quien los instaló,
+// por eso todas las rutas se resuelven a absolutas antes de instalarse.
+package tarea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"cronjob/cronexpr"
+	"cronjob/rotacion"
+)
+
+// Tarea es la configuración de un script programado
+type Tarea struct {
+	Nombre       string            `json:"nombre"`
+	Script       string            `json:"script"`
+	Horario      string            `json:"horario"`
+	Args         []string          `json:"args,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	Log          string            `json:"log"`
+	LogMaxMB     int               `json:"log_max_mb"`
+	LogRespaldos int               `json:"log_respaldos"`
+	// TimeoutSegundos limita la duración de cada ejecución (0 = sin límite)
+	TimeoutSegundos int `json:"timeout_segundos,omitempty"`
+}
+
+// Resultado describe una ejecución del script
+type Resultado struct {
+	Inicio        time.Time
+	Duracion      time.Duration
+	Codigo        int
+	TiempoAgotado bool
+}
+
+// Valores por defecto
+const (
+	HorarioPorDefecto      = "* * * * *"
+	LogMaxMBPorDefecto     = 10
+	LogRespaldosPorDefecto = 3
+)
+
+// Cargar lee una tarea desde un archivo JSON. Las rutas relativas del archivo
+// se toman relativas a la carpeta donde está el archivo de configuración.
+func Cargar(ruta string) (*Tarea, error) {
+	contenido, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo configuración %s: %v", ruta, err)
+	}
+
+	// Los campos que no estén en el archivo conservan los valores por defecto
+	t := Tarea{LogMaxMB: LogMaxMBPorDefecto, LogRespaldos: LogRespaldosPorDefecto}
+	if err := json.Unmarshal(contenido, &t); err != nil {
+		return nil, fmt.Errorf("error al parsear configuración %s: %v", ruta, err)
+	}
+
+	base := filepath.Dir(ruta)
+	if t.Script != "" && !filepath.IsAbs(t.Script) {
+		t.Script = filepath.Join(base, t.Script)
+	}
+	if t.Log != "" && !filepath.IsAbs(t.Log) {
+		t.Log = filepath.Join(base, t.Log)
+	}
+	return &t, nil
+}
+
+// Preparar completa los valores por defecto, convierte las rutas a absolutas
+// y valida la tarea. Debe llamarse antes de instalar o ejecutar.
+func (t *Tarea) Preparar() error {
+	if t.Script == "" {
+		return errors.New("no se indicó el script a ejecutar")
+	}
+
+	script, err := filepath.Abs(t.Script)
+	if err != nil {
+		return fmt.Errorf("error resolviendo ruta del script: %v", err)
+	}
+	t.Script = script
+
+	info, err := os.Stat(t.Script)
+	if err != nil {
+		return fmt.Errorf("no se encontró el script %s: %v", t.Script, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s es un directorio, no un script", t.Script)
+	}
+
+	if t.Nombre == "" {
+		t.Nombre = strings.TrimSuffix(filepath.Base(t.Script), filepath.Ext(t.Script))
+	}
+	if t.Horario == "" {
+		t.Horario = HorarioPorDefecto
+	}
+	if err := cronexpr.Validar(t.Horario); err != nil {
+		return err
+	}
+
+	if t.Log == "" {
+		t.Log = t.Script + ".log"
+	}
+	if t.Log, err = filepath.Abs(t.Log); err != nil {
+		return fmt.Errorf("error resolviendo ruta del log: %v", err)
+	}
+	if t.LogMaxMB <= 0 {
+		t.LogMaxMB = LogMaxMBPorDefecto
+	}
+	if t.LogRespaldos < 0 {
+		t.LogRespaldos = 0
+	}
+	if t.TimeoutSegundos < 0 {
+		return fmt.Errorf("timeout inválido: %d", t.TimeoutSegundos)
+	}
+
+	for clave := range t.Env {
+		if clave == "" || strings.ContainsAny(clave, "= ") {
+			return fmt.Errorf("variable de entorno inválida: %q", clave)
+		}
+	}
+	return nil
+}
+
+// EnvOrdenado devuelve las variables como "CLAVE=valor" en orden alfabético
+func (t *Tarea) EnvOrdenado() []string {
+	var env []string
+	for clave, valor := range t.Env {
+		env = append(env, clave+"="+valor)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Ejecutar corre el script una vez con sus argumentos y variables, enviando
+// stdout y stderr al log con rotación por tamaño. Si la tarea tiene timeout,
+// al agotarse se termina el script junto con los procesos que haya creado.
+func (t *Tarea) Ejecutar(ctx context.Context) (Resultado, error) {
+	res := Resultado{Inicio: time.Now(), Codigo: -1}
+
+	log, err := rotacion.Abrir(t.Log, int64(t.LogMaxMB)*1024*1024, t.LogRespaldos)
+	if err != nil {
+		return res, err
+	}
+	defer log.Close()
+
+	if t.TimeoutSegundos > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(t.TimeoutSegundos)*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, t.Script, t.Args...)
+	// cron no tiene directorio de trabajo propio; se usa la carpeta del script
+	cmd.Dir = filepath.Dir(t.Script)
+	cmd.Env = append(os.Environ(), t.EnvOrdenado()...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	// El script corre en su propio grupo de procesos para poder terminar también a sus hijos
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	fmt.Fprintf(log, "=== %s inicio %s ===\n", t.Nombre, res.Inicio.Format(time.RFC3339))
+
+	err = cmd.Run()
+	res.Duracion = time.Since(res.Inicio)
+	res.TiempoAgotado = errors.Is(ctx.Err(), context.DeadlineExceeded)
+
+	if err != nil {
+		var errSalida *exec.ExitError
+		if !errors.As(err, &errSalida) {
+			fmt.Fprintf(log, "=== %s no se pudo ejecutar: %v ===\n", t.Nombre, err)
+			return res, fmt.Errorf("error ejecutando %s: %v", t.Script, err)
+		}
+		res.Codigo = errSalida.ExitCode()
+	} else {
+		res.Codigo = 0
+	}
+
+	if res.TiempoAgotado {
+		fmt.Fprintf(log, "=== %s terminado por timeout (%ds) ===\n", t.Nombre, t.TimeoutSegundos)
+	}
+	fmt.Fprintf(log, "=== %s fin código=%d duración=%s ===\n", t.Nombre, res.Codigo, res.Duracion.Round(time.Millisecond))
+	return res, nil
+}
@@ -0,0 +1,13 @@
+module mydaemon
+
+go 1.25.5
+
+require (
+	daemon v0.0.0
+	github.com/mattn/go-sqlite3 v1.14.32
+)
+
+// El recolector de cgroups es el mismo del daemon de Clase 4/daemon_proc_sqlite_grafana
+replace daemon => ../../daemon_proc_sqlite_grafana/daemon
+
+replace cronjob => "../../../Clase 3/cronjob"
@@ -4,6 +4,7 @@ set -e
 
 DAEMON_DIR="./daemon"
 GRAFANA_DIR="./grafana"
+DATA_DIR="./datos"
 DB_FILE="containers.db"
 SERVICE_FILE="mydaemon.service"
 PROJECT_ROOT="$(pwd)"
@@ -29,7 +30,7 @@ fi
 
 # Limpiar TODAS las instancias de containers.db
 echo "[INFO] Limpiando bases de datos en TODOS los directorios..."
-for db_path in "$DB_FILE" "$DAEMON_DIR/$DB_FILE" "$GRAFANA_DIR/$DB_FILE" "daemon/$DB_FILE"; do
+for db_path in "$DB_FILE" "$DAEMON_DIR/$DB_FILE" "$GRAFANA_DIR/$DB_FILE" "$DATA_DIR/$DB_FILE" "$DATA_DIR/$DB_FILE-wal" "$DATA_DIR/$DB_FILE-shm"; do
     if [ -e "$db_path" ]; then
         if [ -d "$db_path" ]; then
             echo "[INFO] $db_path es un DIRECTORIO (corrupto), eliminando..."
@@ -50,7 +51,8 @@ done
 echo "[INFO] Limpiando datos de Grafana..."
 sudo rm -rf "$GRAFANA_DIR/grafana-data"
 mkdir -p "$GRAFANA_DIR/grafana-data"
-sudo chown -R $USER:$USER "$GRAFANA_DIR/grafana-data"
+# Grafana corre como uid 472 (gid 0) dentro del contenedor y tiene que poder escribir aquí
+sudo chown -R 472:0 "$GRAFANA_DIR/grafana-data"
 
 echo ""
 echo "=========================================="
@@ -120,6 +122,7 @@ After=network.target
 [Service]
 Type=simple
 ExecStart=$PROJECT_ROOT/$DAEMON_DIR/mydaemon
+ExecReload=/bin/kill -HUP \$MAINPID
 WorkingDirectory=$PROJECT_ROOT/$DAEMON_DIR
 Restart=always
 RestartSec=10
@@ -158,20 +161,20 @@ echo "=========================================="
 # Esperar a que se cree la DB
 echo "[INFO] Esperando a que se cree la base de datos..."
 for i in {1..10}; do
-    if [ -f "$DAEMON_DIR/$DB_FILE" ] && [ ! -d "$DAEMON_DIR/$DB_FILE" ]; then
+    if [ -f "$DATA_DIR/$DB_FILE" ] && [ ! -d "$DATA_DIR/$DB_FILE" ]; then
         echo "[INFO] ✓ Base de datos creada correctamente"
-        ls -lh "$DAEMON_DIR/$DB_FILE"
+        ls -lh "$DATA_DIR/$DB_FILE"
         
         # Verificar permisos
-        if [ -r "$DAEMON_DIR/$DB_FILE" ]; then
+        if [ -r "$DATA_DIR/$DB_FILE" ]; then
             echo "[INFO] ✓ Archivo legible"
         else
             echo "[WARN] Ajustando permisos..."
-            chmod 644 "$DAEMON_DIR/$DB_FILE"
+            chmod 644 "$DATA_DIR/$DB_FILE"
         fi
         
         # Verificar integridad
-        if sqlite3 "$DAEMON_DIR/$DB_FILE" "SELECT count(*) FROM containers;" &>/dev/null; then
+        if sqlite3 "$DATA_DIR/$DB_FILE" "SELECT count(*) FROM containers;" &>/dev/null; then
             echo "[INFO] ✓ Base de datos SQLite válida"
         else
             echo "[ERROR] Base de datos corrupta"
@@ -184,7 +187,7 @@ for i in {1..10}; do
     sleep 2
 done
 
-if [ ! -f "$DAEMON_DIR/$DB_FILE" ]; then
+if [ ! -f "$DATA_DIR/$DB_FILE" ]; then
     echo "[ERROR] La base de datos no se creó después de 20 segundos"
     echo "[INFO] Logs del daemon:"
     sudo journalctl -u mydaemon -n 30 --no-pager
@@ -2,17 +2,39 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
-	"math/rand"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
 	"time"
 
+	"daemon/recolector"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Configuración del daemon (daemon.json junto al ejecutable). Se recarga con SIGHUP.
+type Config struct {
+	// Intervalo de muestreo, por ejemplo "20s" o "1m"
+	Intervalo string `json:"intervalo"`
+}
+
+// Lotes que puede acumular la cola de escritura
+const capacidadCola = 32
+
+// Usuario con el que corre la imagen oficial de Grafana (grafana:root)
+const uidGrafana, gidGrafana = 472, 0
+
+// loteContainers son los contenedores de un tick; se insertan en una sola transacción
+type loteContainers struct {
+	containers []Container
+	createdAt  int64
+}
+
 type Container struct {
 	Name   string
 	CPU    float64
@@ -21,7 +43,8 @@ type Container struct {
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	rutaConfig := flag.String("config", "", "archivo JSON de configuración (por defecto daemon.json junto al ejecutable)")
+	flag.Parse()
 
 	// Obtener directorio del ejecutable
 	exePath, err := os.Executable()
@@ -29,9 +52,22 @@ func main() {
 		log.Fatal("Error obteniendo ruta del ejecutable:", err)
 	}
 	exeDir := filepath.Dir(exePath)
-	
-	// Crear ruta absoluta para la DB
-	dbPath := filepath.Join(filepath.Dir(exeDir), "containers.db")
+
+	if *rutaConfig == "" {
+		*rutaConfig = filepath.Join(exeDir, "daemon.json")
+	}
+	intervalo, err := cargarIntervalo(*rutaConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Crear ruta absoluta para la DB. Va en su propia carpeta (datos/, junto a daemon/)
+	// porque Grafana monta esa carpeta completa: así no ve el código ni el binario.
+	dirDatos := filepath.Join(filepath.Dir(exeDir), "datos")
+	if err := os.MkdirAll(dirDatos, 0755); err != nil {
+		log.Fatal("Error creando carpeta de datos:", err)
+	}
+	dbPath := filepath.Join(dirDatos, "containers.db")
 	log.Println("Usando base de datos en:", dbPath)
 
 	// IMPORTANTE: Eliminar si existe como directorio
@@ -50,9 +86,11 @@ func main() {
 		file.Close()
 		log.Println("Archivo containers.db creado")
 	}
+	compartirConGrafana(dirDatos, dbPath)
 
-	// Conectar a SQLite
-	db, err := sql.Open("sqlite3", dbPath)
+	// Conectar a SQLite en modo WAL: Grafana puede leer mientras el daemon escribe.
+	// busy_timeout hace que una conexión espere hasta 5s el lock en lugar de fallar con "database is locked".
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL")
 	if err != nil {
 		log.Fatal("Error abriendo base de datos:", err)
 	}
@@ -73,7 +111,8 @@ func main() {
 		memory REAL,
 		status TEXT,
 		created_at INTEGER
-	);`
+	);
+	CREATE INDEX IF NOT EXISTS idx_containers_created_at ON containers (created_at);`
 	_, err = db.Exec(createTable)
 	if err != nil {
 		log.Fatal("Error creando tabla:", err)
@@ -81,30 +120,66 @@ func main() {
 
 	log.Println("Base de datos inicializada correctamente")
 
+	// Cola de escritura: un goroutine inserta cada lote en una transacción
+	lotes := make(chan loteContainers, capacidadCola)
+	escrituraTerminada := make(chan struct{})
+	go escribirLotes(db, lotes, escrituraTerminada)
+	defer func() {
+		// Se escriben los lotes pendientes antes de cerrar la base
+		close(lotes)
+		<-escrituraTerminada
+	}()
+
 	// Canal para señales del sistema
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-	log.Println("Daemon iniciado. Generando datos cada 20 segundos...")
+	// SIGHUP recarga el intervalo sin reiniciar el daemon
+	recargar := make(chan os.Signal, 1)
+	signal.Notify(recargar, syscall.SIGHUP)
 
-	ticker := time.NewTicker(20 * time.Second)
-	defer ticker.Stop()
+	// Las métricas se leen de cgroup v2 (/sys/fs/cgroup) para los contenedores de Docker y containerd
+	cgroups := recolector.NuevoCgroups()
+
+	log.Printf("Daemon iniciado. Leyendo contenedores cada %s...\n", intervalo)
+
+	// El timer se programa en múltiplos del intervalo (:00, :20, :40...) y no según la hora de arranque
+	siguiente := alinear(time.Now(), intervalo)
+	timer := time.NewTimer(time.Until(siguiente))
+	defer timer.Stop()
 
 loop:
 	for {
 		select {
-		case <-ticker.C:
-			containers := generateRandomContainers()
-			for _, c := range containers {
-				_, err := db.Exec("INSERT INTO containers (name, cpu, memory, status, created_at) VALUES (?, ?, ?, ?, ?)",
-					c.Name, c.CPU, c.Memory, c.Status, time.Now().UnixMilli())
-				if err != nil {
-					log.Println("Error insertando:", err)
-				} else {
-					log.Printf("Insertado: %s (CPU: %.2f%%, MEM: %.2fMB, Estado: %s)\n",
-						c.Name, c.CPU, c.Memory, c.Status)
+		case <-timer.C:
+			// Todas las filas del tick usan la hora alineada como created_at.
+			// El lote se encola sin esperar: si la base está bloqueada el muestreo no se atrasa.
+			containers, err := leerContainers(cgroups)
+			if err != nil {
+				log.Println("Error leyendo contenedores, se omite la muestra:", err)
+			} else {
+				lote := loteContainers{containers: containers, createdAt: siguiente.UnixMilli()}
+				select {
+				case lotes <- lote:
+				default:
+					log.Printf("Cola de escritura llena, se descartan %d contenedores\n", len(lote.containers))
 				}
 			}
+			siguiente = alinear(time.Now(), intervalo)
+			timer.Reset(time.Until(siguiente))
+		case <-recargar:
+			nuevo, err := cargarIntervalo(*rutaConfig)
+			if err != nil {
+				log.Println("No se pudo recargar la configuración, se mantiene la anterior:", err)
+				continue
+			}
+			if nuevo != intervalo {
+				intervalo = nuevo
+				// Desde Go 1.23 Reset descarta un tick pendiente del intervalo anterior
+				siguiente = alinear(time.Now(), intervalo)
+				timer.Reset(time.Until(siguiente))
+			}
+			log.Printf("Configuración recargada. Leyendo contenedores cada %s\n", intervalo)
 		case <-sigs:
 			log.Println(" Daemon detenido.")
 			break loop
@@ -112,19 +187,104 @@ loop:
 	}
 }
 
-func generateRandomContainers() []Container {
-	names := []string{"nginx", "redis", "mysql", "golang-app", "nodejs-app", "python-app", "java-app", "ruby-app", "postgres", "mongodb", "ubuntu", "alpine"}
-	statuses := []string{"running", "stopped", "paused", "restarting"}
-
-	n := rand.Intn(4) + 1
-	var containers []Container
-	for i := 0; i < n; i++ {
-		containers = append(containers, Container{
-			Name:   names[rand.Intn(len(names))],
-			CPU:    rand.Float64() * 100,
-			Memory: rand.Float64() * 512,
-			Status: statuses[rand.Intn(len(statuses))],
-		})
-	}
-	return containers
-}
\ No newline at end of file
+// escribirLotes inserta los lotes de la cola hasta que se cierre el canal.
+// La sentencia se prepara una vez y se reutiliza dentro de la transacción de cada lote.
+func escribirLotes(db *sql.DB, lotes <-chan loteContainers, terminada chan<- struct{}) {
+	defer close(terminada)
+
+	stmt, err := db.Prepare("INSERT INTO containers (name, cpu, memory, status, created_at) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		log.Println("Error preparando la sentencia de inserción:", err)
+		for range lotes {
+		}
+		return
+	}
+	defer stmt.Close()
+
+	for lote := range lotes {
+		if err := insertarLote(db, stmt, lote); err != nil {
+			log.Printf("Error insertando %d contenedores: %v\n", len(lote.containers), err)
+			continue
+		}
+		for _, c := range lote.containers {
+			log.Printf("Insertado: %s (CPU: %.2f%%, MEM: %.2fMB, Estado: %s)\n",
+				c.Name, c.CPU, c.Memory, c.Status)
+		}
+	}
+}
+
+func insertarLote(db *sql.DB, stmt *sql.Stmt, lote loteContainers) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txStmt := tx.Stmt(stmt)
+	for _, c := range lote.containers {
+		if _, err := txStmt.Exec(c.Name, c.CPU, c.Memory, c.Status, lote.createdAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// cargarIntervalo lee el intervalo de muestreo; si el archivo no existe se usan 20 segundos
+func cargarIntervalo(ruta string) (time.Duration, error) {
+	cfg := Config{Intervalo: "20s"}
+
+	contenido, err := os.ReadFile(ruta)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("error leyendo configuración %s: %v", ruta, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(contenido, &cfg); err != nil {
+			return 0, fmt.Errorf("error al parsear configuración %s: %v", ruta, err)
+		}
+	}
+
+	intervalo, err := time.ParseDuration(cfg.Intervalo)
+	if err != nil {
+		return 0, fmt.Errorf("intervalo inválido en %s: %v", ruta, err)
+	}
+	if intervalo < time.Second {
+		return 0, fmt.Errorf("el intervalo debe ser de al menos 1s (es %s)", intervalo)
+	}
+	return intervalo, nil
+}
+
+// alinear devuelve el próximo múltiplo del intervalo posterior a t
+func alinear(t time.Time, intervalo time.Duration) time.Time {
+	return t.Truncate(intervalo).Add(intervalo)
+}
+
+// leerContainers lee CPU, memoria y estado de cada contenedor desde sus cgroups.
+// El CPU es el porcentaje de un núcleo desde el tick anterior (0 en el primero).
+func leerContainers(cgroups *recolector.Cgroups) ([]Container, error) {
+	leidos, err := cgroups.Leer()
+	if err != nil {
+		return nil, err
+	}
+	containers := make([]Container, 0, len(leidos))
+	for _, c := range leidos {
+		containers = append(containers, Container{Name: c.Name, CPU: c.CPU, Memory: c.Memory, Status: c.Status})
+	}
+	return containers, nil
+}
+
+// compartirConGrafana deja la carpeta de datos y containers.db a nombre del usuario
+// de Grafana, así el contenedor no necesita correr como root. En modo WAL Grafana
+// tiene que poder escribir en la carpeta para abrir containers.db-shm; SQLite, si
+// corre como root, crea -wal y -shm con el mismo dueño que containers.db.
+// El servicio systemd corre como root; si el daemon se ejecuta a mano como otro
+// usuario no se cambia nada y Grafana solo podrá leer la base si se le da acceso.
+func compartirConGrafana(dirDatos, dbPath string) {
+	if os.Geteuid() != 0 {
+		return
+	}
+	for _, ruta := range []string{dirDatos, dbPath} {
+		if err := os.Chown(ruta, uidGrafana, gidGrafana); err != nil {
+			log.Printf("No se pudo asignar %s a Grafana: %v", ruta, err)
+		}
+	}
+}
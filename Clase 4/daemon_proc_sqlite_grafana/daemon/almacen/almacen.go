@@ -0,0 +1,129 @@
+// Package almacen define dónde se guardan las muestras del daemon. Cada
+// destino implementa MetricStore; se pueden activar varios a la vez (SQLite
+// para Grafana, un archivo JSON lines, InfluxDB y Prometheus remote-write).
+package almacen
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"daemon/recolector"
+)
+
+// Muestras es lo leído en un tick. Solo viene cargado el campo de la métrica
+// que correspondía a ese tick.
+type Muestras struct {
+	Tiempo       time.Time
+	Totales      *recolector.Totales
+	Procesos     []recolector.Proceso
+	Contenedores []recolector.Container
+}
+
+// MetricStore es un destino de las muestras
+type MetricStore interface {
+	// Nombre identifica al destino en los logs
+	Nombre() string
+	Guardar(m Muestras) error
+	// Close escribe lo pendiente y libera el destino
+	Close() error
+}
+
+// ConErrores lo implementan los destinos que escriben en segundo plano: sus
+// errores no se devuelven en Guardar, así que se exponen como contadores
+type ConErrores interface {
+	// Fallidos son los envíos o inserciones que fallaron
+	Fallidos() int64
+	// Descartados son los ticks perdidos porque la cola estaba llena
+	Descartados() int64
+}
+
+// Varios envía las muestras a todos los destinos. Un destino que falla no
+// impide que los demás reciban las muestras.
+type Varios []MetricStore
+
+func (v Varios) Nombre() string {
+	return "varios"
+}
+
+func (v Varios) Guardar(m Muestras) error {
+	var errs []error
+	for _, s := range v {
+		if err := s.Guardar(m); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Nombre(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (v Varios) Close() error {
+	var errs []error
+	for _, s := range v {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Nombre(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enSegundoPlano desacopla un destino lento (HTTP) del muestreo: Guardar solo
+// encola y un goroutine hace el envío. Si la cola se llena se descartan las
+// muestras nuevas, igual que en la cola de escritura de SQLite.
+type enSegundoPlano struct {
+	destino   MetricStore
+	muestras  chan Muestras
+	terminado chan struct{}
+
+	fallidos    atomic.Int64
+	descartados atomic.Int64
+}
+
+// EnSegundoPlano envuelve el destino con una cola de hasta capacidad ticks
+func EnSegundoPlano(destino MetricStore, capacidad int) MetricStore {
+	s := &enSegundoPlano{
+		destino:   destino,
+		muestras:  make(chan Muestras, capacidad),
+		terminado: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.terminado)
+		for m := range s.muestras {
+			if err := destino.Guardar(m); err != nil {
+				s.fallidos.Add(1)
+				slog.Error("Error enviando muestras", "destino", destino.Nombre(), "error", err)
+			}
+		}
+	}()
+	return s
+}
+
+func (s *enSegundoPlano) Nombre() string {
+	return s.destino.Nombre()
+}
+
+func (s *enSegundoPlano) Guardar(m Muestras) error {
+	select {
+	case s.muestras <- m:
+		return nil
+	default:
+		n := s.descartados.Add(1)
+		slog.Warn("Cola de envío llena, se descartan las muestras", "destino", s.destino.Nombre(), "descartados", n)
+		return nil
+	}
+}
+
+func (s *enSegundoPlano) Fallidos() int64 {
+	return s.fallidos.Load()
+}
+
+func (s *enSegundoPlano) Descartados() int64 {
+	return s.descartados.Load()
+}
+
+func (s *enSegundoPlano) Close() error {
+	close(s.muestras)
+	<-s.terminado
+	return s.destino.Close()
+}
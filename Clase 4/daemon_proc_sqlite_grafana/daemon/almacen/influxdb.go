@@ -0,0 +1,114 @@
+package almacen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxDB envía los puntos en line protocol al endpoint de escritura HTTP.
+// Funciona con InfluxDB 2.x (/api/v2/write?org=...&bucket=...) y con 1.x
+// (/write?db=...). La precisión se pide en milisegundos.
+type InfluxDB struct {
+	URL string
+	// Token se envía como "Authorization: Token <token>" (vacío = sin autenticación)
+	Token string
+	// MaxProcesos limita las series por proceso (ver PuntosRecortados)
+	MaxProcesos int
+	Cliente     *http.Client
+}
+
+// NuevoInfluxDB crea el destino con un cliente HTTP con timeout
+func NuevoInfluxDB(url, token string) *InfluxDB {
+	return &InfluxDB{URL: url, Token: token, Cliente: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (i *InfluxDB) Nombre() string {
+	return "influxdb"
+}
+
+func (i *InfluxDB) Guardar(m Muestras) error {
+	puntos := PuntosRecortados(m, i.MaxProcesos)
+	if len(puntos) == 0 {
+		return nil
+	}
+
+	var cuerpo bytes.Buffer
+	for _, p := range puntos {
+		cuerpo.WriteString(LineaInflux(p))
+		cuerpo.WriteByte('\n')
+	}
+
+	url := i.URL
+	if !strings.Contains(url, "precision=") {
+		if strings.Contains(url, "?") {
+			url += "&precision=ms"
+		} else {
+			url += "?precision=ms"
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, &cuerpo)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.Token)
+	}
+	return enviar(i.Cliente, req)
+}
+
+func (i *InfluxDB) Close() error {
+	return nil
+}
+
+// LineaInflux escribe el punto como "metrica,etiqueta=valor valor=1.5 <ms>"
+func LineaInflux(p Punto) string {
+	var sb strings.Builder
+	sb.WriteString(escaparInflux(p.Metrica, false))
+	for _, e := range p.Etiquetas {
+		if e.Valor == "" {
+			// InfluxDB no acepta etiquetas vacías
+			continue
+		}
+		sb.WriteByte(',')
+		sb.WriteString(escaparInflux(e.Nombre, true))
+		sb.WriteByte('=')
+		sb.WriteString(escaparInflux(e.Valor, true))
+	}
+	sb.WriteString(" valor=")
+	sb.WriteString(strconv.FormatFloat(p.Valor, 'g', -1, 64))
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.FormatInt(p.Tiempo.UnixMilli(), 10))
+	return sb.String()
+}
+
+// escaparInflux escapa comas y espacios (y en etiquetas también el '=')
+func escaparInflux(s string, etiqueta bool) string {
+	reemplazos := []string{`\`, `\\`, ",", `\,`, " ", `\ `, "\n", `\n`}
+	if etiqueta {
+		reemplazos = append(reemplazos, "=", `\=`)
+	}
+	return strings.NewReplacer(reemplazos...).Replace(s)
+}
+
+// enviar hace la petición y convierte las respuestas que no son 2xx en error
+func enviar(cliente *http.Client, req *http.Request) error {
+	resp, err := cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detalle, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s respondió %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(detalle)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,125 @@
+package almacen
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"daemon/recolector"
+)
+
+// muestrasPrueba tiene totales y tres procesos, uno con espacios y comas en el nombre
+func muestrasPrueba() Muestras {
+	return Muestras{
+		Tiempo:  time.UnixMilli(1700000000123),
+		Totales: &recolector.Totales{TotalRAM: 8000, RAMLibre: 2000, Procesos: 3},
+		Procesos: []recolector.Proceso{
+			{PID: 1, Nombre: "systemd", Estado: "S", RSSKB: 1000, CPU: 0.5},
+			{PID: 42, Nombre: "mi proceso,x", Estado: "R", RSSKB: 500, CPU: 80},
+			{PID: 7, Nombre: "bash", Estado: "S", RSSKB: 3000, CPU: 0.5},
+		},
+	}
+}
+
+// peticion es lo que recibió el servidor de prueba
+type peticion struct {
+	url     string
+	headers http.Header
+	cuerpo  []byte
+}
+
+func servidorPrueba(t *testing.T, estado int) (*httptest.Server, chan peticion) {
+	recibidas := make(chan peticion, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cuerpo, _ := io.ReadAll(r.Body)
+		recibidas <- peticion{url: r.URL.String(), headers: r.Header, cuerpo: cuerpo}
+		w.WriteHeader(estado)
+		if estado/100 != 2 {
+			io.WriteString(w, "bucket no encontrado")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, recibidas
+}
+
+func TestInfluxDBGuardar(t *testing.T) {
+	srv, recibidas := servidorPrueba(t, http.StatusNoContent)
+	influx := NuevoInfluxDB(srv.URL+"/api/v2/write?org=sopes1&bucket=daemon", "secreto")
+
+	if err := influx.Guardar(muestrasPrueba()); err != nil {
+		t.Fatal(err)
+	}
+	p := <-recibidas
+	if p.url != "/api/v2/write?org=sopes1&bucket=daemon&precision=ms" {
+		t.Errorf("url = %s", p.url)
+	}
+	if got := p.headers.Get("Authorization"); got != "Token secreto" {
+		t.Errorf("Authorization = %q", got)
+	}
+
+	lineas := strings.Split(strings.TrimSuffix(string(p.cuerpo), "\n"), "\n")
+	for _, esperada := range []string{
+		"sopes1_ram_total valor=8000 1700000000123",
+		`sopes1_proceso_cpu_porcentaje,estado=R,nombre=mi\ proceso\,x,pid=42 valor=80 1700000000123`,
+		"sopes1_proceso_rss_kb,estado=S,nombre=bash,pid=7 valor=3000 1700000000123",
+	} {
+		if !contiene(lineas, esperada) {
+			t.Errorf("falta la línea %q en:\n%s", esperada, p.cuerpo)
+		}
+	}
+	if len(lineas) != 3+2*3 {
+		t.Errorf("se enviaron %d líneas, se esperaban 9", len(lineas))
+	}
+}
+
+func TestInfluxDBRecortaProcesos(t *testing.T) {
+	srv, recibidas := servidorPrueba(t, http.StatusNoContent)
+	influx := NuevoInfluxDB(srv.URL+"/write?db=daemon", "")
+	influx.MaxProcesos = 2
+
+	if err := influx.Guardar(muestrasPrueba()); err != nil {
+		t.Fatal(err)
+	}
+	p := <-recibidas
+	if p.headers.Get("Authorization") != "" {
+		t.Error("sin token no se debería enviar Authorization")
+	}
+	cuerpo := string(p.cuerpo)
+	// Quedan el de más CPU y, a igual CPU, el de más memoria
+	if !strings.Contains(cuerpo, "pid=42 ") || !strings.Contains(cuerpo, "pid=7 ") || strings.Contains(cuerpo, "pid=1 ") {
+		t.Errorf("procesos enviados:\n%s", cuerpo)
+	}
+	if !strings.Contains(cuerpo, "sopes1_procesos_omitidos valor=1 1700000000123\n") {
+		t.Errorf("falta sopes1_procesos_omitidos:\n%s", cuerpo)
+	}
+}
+
+func TestInfluxDBErrorDelServidor(t *testing.T) {
+	srv, _ := servidorPrueba(t, http.StatusNotFound)
+	err := NuevoInfluxDB(srv.URL+"/api/v2/write", "").Guardar(muestrasPrueba())
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "bucket no encontrado") {
+		t.Fatalf("err = %v, se esperaba el estado y el cuerpo de la respuesta", err)
+	}
+}
+
+func TestInfluxDBSinPuntos(t *testing.T) {
+	srv, recibidas := servidorPrueba(t, http.StatusNoContent)
+	if err := NuevoInfluxDB(srv.URL, "").Guardar(Muestras{Tiempo: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if len(recibidas) != 0 {
+		t.Fatal("sin puntos no se debería hacer la petición")
+	}
+}
+
+func contiene(lineas []string, linea string) bool {
+	for _, l := range lineas {
+		if l == linea {
+			return true
+		}
+	}
+	return false
+}
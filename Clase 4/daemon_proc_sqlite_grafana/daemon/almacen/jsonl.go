@@ -0,0 +1,64 @@
+package almacen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"cronjob/rotacion"
+)
+
+// JSONL escribe un punto por línea en un archivo que se rota por tamaño.
+// Sirve para importar las muestras en otra herramienta o para depurar.
+type JSONL struct {
+	archivo *rotacion.Archivo
+}
+
+type lineaJSON struct {
+	Metrica   string            `json:"metrica"`
+	Etiquetas map[string]string `json:"etiquetas,omitempty"`
+	Valor     float64           `json:"valor"`
+	// Milisegundos desde epoch, igual que created_at en SQLite
+	Tiempo int64 `json:"tiempo"`
+}
+
+// NuevoJSONL abre (o crea) el archivo en modo append
+func NuevoJSONL(ruta string, maxBytes int64, respaldos int) (*JSONL, error) {
+	archivo, err := rotacion.Abrir(ruta, maxBytes, respaldos)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo %s: %v", ruta, err)
+	}
+	return &JSONL{archivo: archivo}, nil
+}
+
+func (j *JSONL) Nombre() string {
+	return "jsonl"
+}
+
+func (j *JSONL) Guardar(m Muestras) error {
+	// Se arma todo el tick en memoria para escribirlo con un solo Write
+	// (así la rotación nunca parte un tick entre dos archivos)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, p := range Puntos(m) {
+		linea := lineaJSON{Metrica: p.Metrica, Valor: p.Valor, Tiempo: p.Tiempo.UnixMilli()}
+		if len(p.Etiquetas) > 0 {
+			linea.Etiquetas = make(map[string]string, len(p.Etiquetas))
+			for _, e := range p.Etiquetas {
+				linea.Etiquetas[e.Nombre] = e.Valor
+			}
+		}
+		if err := enc.Encode(linea); err != nil {
+			return err
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	_, err := j.archivo.Write(buf.Bytes())
+	return err
+}
+
+func (j *JSONL) Close() error {
+	return j.archivo.Close()
+}
@@ -0,0 +1,101 @@
+package almacen
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// PrometheusRemoteWrite envía los puntos con el protocolo remote-write 1.0
+// (WriteRequest en protobuf comprimido con snappy). Lo aceptan Prometheus con
+// --web.enable-remote-write-receiver, Mimir, Thanos, VictoriaMetrics, etc.
+type PrometheusRemoteWrite struct {
+	URL string
+	// MaxProcesos limita las series por proceso (ver PuntosRecortados)
+	MaxProcesos int
+	Cliente     *http.Client
+}
+
+// NuevoPrometheusRemoteWrite crea el destino con un cliente HTTP con timeout
+func NuevoPrometheusRemoteWrite(url string) *PrometheusRemoteWrite {
+	return &PrometheusRemoteWrite{URL: url, Cliente: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *PrometheusRemoteWrite) Nombre() string {
+	return "prometheus"
+}
+
+func (p *PrometheusRemoteWrite) Guardar(m Muestras) error {
+	puntos := PuntosRecortados(m, p.MaxProcesos)
+	if len(puntos) == 0 {
+		return nil
+	}
+
+	cuerpo := snappy.Encode(nil, WriteRequest(puntos))
+	req, err := http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(cuerpo))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	return enviar(p.Cliente, req)
+}
+
+func (p *PrometheusRemoteWrite) Close() error {
+	return nil
+}
+
+// Números de campo de prometheus/prompb/types.proto y remote.proto
+const (
+	campoWriteRequestSeries = 1
+	campoSerieEtiquetas     = 1
+	campoSerieMuestras      = 2
+	campoEtiquetaNombre     = 1
+	campoEtiquetaValor      = 2
+	campoMuestraValor       = 1
+	campoMuestraTiempo      = 2
+)
+
+// WriteRequest codifica los puntos como prometheus.WriteRequest: una serie por
+// punto, con la etiqueta __name__ y una sola muestra (tiempo en milisegundos).
+// Se arma a mano con protowire para no depender de los tipos de Prometheus.
+func WriteRequest(puntos []Punto) []byte {
+	var req []byte
+	for _, p := range puntos {
+		var serie []byte
+		// __name__ va primero: las etiquetas tienen que estar ordenadas por nombre
+		serie = agregarEtiqueta(serie, "__name__", p.Metrica)
+		for _, e := range p.Etiquetas {
+			serie = agregarEtiqueta(serie, e.Nombre, e.Valor)
+		}
+
+		var muestra []byte
+		muestra = protowire.AppendTag(muestra, campoMuestraValor, protowire.Fixed64Type)
+		muestra = protowire.AppendFixed64(muestra, math.Float64bits(p.Valor))
+		muestra = protowire.AppendTag(muestra, campoMuestraTiempo, protowire.VarintType)
+		muestra = protowire.AppendVarint(muestra, uint64(p.Tiempo.UnixMilli()))
+
+		serie = protowire.AppendTag(serie, campoSerieMuestras, protowire.BytesType)
+		serie = protowire.AppendBytes(serie, muestra)
+
+		req = protowire.AppendTag(req, campoWriteRequestSeries, protowire.BytesType)
+		req = protowire.AppendBytes(req, serie)
+	}
+	return req
+}
+
+func agregarEtiqueta(serie []byte, nombre, valor string) []byte {
+	var etiqueta []byte
+	etiqueta = protowire.AppendTag(etiqueta, campoEtiquetaNombre, protowire.BytesType)
+	etiqueta = protowire.AppendString(etiqueta, nombre)
+	etiqueta = protowire.AppendTag(etiqueta, campoEtiquetaValor, protowire.BytesType)
+	etiqueta = protowire.AppendString(etiqueta, valor)
+
+	serie = protowire.AppendTag(serie, campoSerieEtiquetas, protowire.BytesType)
+	return protowire.AppendBytes(serie, etiqueta)
+}
@@ -0,0 +1,144 @@
+package almacen
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// serie es una serie decodificada de un WriteRequest
+type serie struct {
+	etiquetas []Etiqueta
+	valor     float64
+	tiempo    int64
+}
+
+// decodificar lee el WriteRequest con protowire, campo por campo
+func decodificar(t *testing.T, req []byte) []serie {
+	t.Helper()
+	var series []serie
+	recorrer(t, req, func(_ protowire.Number, b []byte) {
+		var s serie
+		recorrer(t, b, func(campo protowire.Number, b []byte) {
+			switch campo {
+			case campoSerieEtiquetas:
+				var e Etiqueta
+				recorrer(t, b, func(campo protowire.Number, b []byte) {
+					if campo == campoEtiquetaNombre {
+						e.Nombre = string(b)
+					} else {
+						e.Valor = string(b)
+					}
+				})
+				s.etiquetas = append(s.etiquetas, e)
+			case campoSerieMuestras:
+				for len(b) > 0 {
+					campo, tipo, n := protowire.ConsumeTag(b)
+					b = b[n:]
+					if campo == campoMuestraValor && tipo == protowire.Fixed64Type {
+						v, n := protowire.ConsumeFixed64(b)
+						s.valor, b = math.Float64frombits(v), b[n:]
+					} else {
+						v, n := protowire.ConsumeVarint(b)
+						s.tiempo, b = int64(v), b[n:]
+					}
+				}
+			}
+		})
+		series = append(series, s)
+	})
+	return series
+}
+
+// recorrer llama a f con cada campo de tipo bytes del mensaje
+func recorrer(t *testing.T, b []byte, f func(protowire.Number, []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		campo, tipo, n := protowire.ConsumeTag(b)
+		if n < 0 || tipo != protowire.BytesType {
+			t.Fatalf("campo %d inválido (tipo %d)", campo, tipo)
+		}
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			t.Fatalf("campo %d truncado", campo)
+		}
+		f(campo, v)
+		b = b[n:]
+	}
+}
+
+func TestPrometheusRemoteWriteGuardar(t *testing.T) {
+	srv, recibidas := servidorPrueba(t, http.StatusNoContent)
+	remoto := NuevoPrometheusRemoteWrite(srv.URL + "/api/v1/write")
+	remoto.MaxProcesos = 1
+
+	if err := remoto.Guardar(muestrasPrueba()); err != nil {
+		t.Fatal(err)
+	}
+	p := <-recibidas
+	for nombre, esperado := range map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if got := p.headers.Get(nombre); got != esperado {
+			t.Errorf("%s = %q, se esperaba %q", nombre, got, esperado)
+		}
+	}
+
+	req, err := snappy.Decode(nil, p.cuerpo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	series := decodificar(t, req)
+	// 3 totales, 2 series del proceso que más CPU usa y sopes1_procesos_omitidos
+	if len(series) != 6 {
+		t.Fatalf("se enviaron %d series: %+v", len(series), series)
+	}
+
+	porNombre := map[string]serie{}
+	for _, s := range series {
+		if s.etiquetas[0].Nombre != "__name__" {
+			t.Fatalf("__name__ debe ir primero: %+v", s.etiquetas)
+		}
+		for i := 2; i < len(s.etiquetas); i++ {
+			if s.etiquetas[i-1].Nombre >= s.etiquetas[i].Nombre {
+				t.Fatalf("etiquetas desordenadas: %+v", s.etiquetas)
+			}
+		}
+		if s.tiempo != 1700000000123 {
+			t.Errorf("tiempo = %d", s.tiempo)
+		}
+		porNombre[s.etiquetas[0].Valor] = s
+	}
+
+	cpu := porNombre["sopes1_proceso_cpu_porcentaje"]
+	if cpu.valor != 80 || etiqueta(cpu, "pid") != "42" || etiqueta(cpu, "nombre") != "mi proceso,x" {
+		t.Errorf("serie de CPU = %+v", cpu)
+	}
+	if omitidos := porNombre["sopes1_procesos_omitidos"]; omitidos.valor != 2 {
+		t.Errorf("sopes1_procesos_omitidos = %v, se esperaba 2", omitidos.valor)
+	}
+}
+
+func TestPrometheusRemoteWriteErrorDelServidor(t *testing.T) {
+	srv, _ := servidorPrueba(t, http.StatusBadRequest)
+	err := NuevoPrometheusRemoteWrite(srv.URL).Guardar(muestrasPrueba())
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("err = %v, se esperaba el estado de la respuesta", err)
+	}
+}
+
+func etiqueta(s serie, nombre string) string {
+	for _, e := range s.etiquetas {
+		if e.Nombre == nombre {
+			return e.Valor
+		}
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package almacen
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"daemon/recolector"
+)
+
+// Etiqueta es un par nombre=valor que identifica una serie
+type Etiqueta struct {
+	Nombre string
+	Valor  string
+}
+
+// Punto es un valor de una serie de tiempo. Es la forma en que las muestras
+// se envían a los destinos que no son SQLite.
+type Punto struct {
+	Metrica   string
+	Etiquetas []Etiqueta
+	Valor     float64
+	Tiempo    time.Time
+}
+
+// Puntos convierte las muestras de un tick en puntos. Las etiquetas quedan
+// ordenadas por nombre (Prometheus lo exige y en InfluxDB es más eficiente).
+func Puntos(m Muestras) []Punto {
+	var puntos []Punto
+	agregar := func(metrica string, valor float64, etiquetas ...Etiqueta) {
+		sort.Slice(etiquetas, func(i, j int) bool { return etiquetas[i].Nombre < etiquetas[j].Nombre })
+		puntos = append(puntos, Punto{Metrica: metrica, Etiquetas: etiquetas, Valor: valor, Tiempo: m.Tiempo})
+	}
+
+	if t := m.Totales; t != nil {
+		agregar("sopes1_ram_total", float64(t.TotalRAM))
+		agregar("sopes1_ram_libre", float64(t.RAMLibre))
+		agregar("sopes1_procesos_total", float64(t.Procesos))
+	}
+
+	for _, p := range m.Procesos {
+		pid := strconv.Itoa(p.PID)
+		agregar("sopes1_proceso_cpu_porcentaje", p.CPU,
+			Etiqueta{"pid", pid}, Etiqueta{"nombre", p.Nombre}, Etiqueta{"estado", p.Estado})
+		agregar("sopes1_proceso_rss_kb", float64(p.RSSKB),
+			Etiqueta{"pid", pid}, Etiqueta{"nombre", p.Nombre}, Etiqueta{"estado", p.Estado})
+	}
+
+	for _, c := range m.Contenedores {
+		agregar("sopes1_contenedor_cpu_porcentaje", c.CPU, Etiqueta{"name", c.Name}, Etiqueta{"status", c.Status})
+		agregar("sopes1_contenedor_memoria_mb", c.Memory, Etiqueta{"name", c.Name}, Etiqueta{"status", c.Status})
+	}
+
+	return puntos
+}
+
+// PuntosRecortados es Puntos con las series por proceso limitadas a los
+// maxProcesos que más CPU usan (ver RecortarProcesos). Cada PID es una serie
+// nueva, así que los destinos remotos sin límite crecen sin cota. Los que
+// quedan afuera se cuentan en sopes1_procesos_omitidos, igual que en /metrics.
+func PuntosRecortados(m Muestras, maxProcesos int) []Punto {
+	if m.Procesos == nil || maxProcesos <= 0 {
+		return Puntos(m)
+	}
+	var omitidos int
+	m.Procesos, omitidos = RecortarProcesos(m.Procesos, maxProcesos)
+	return append(Puntos(m), Punto{Metrica: "sopes1_procesos_omitidos", Valor: float64(omitidos), Tiempo: m.Tiempo})
+}
+
+// RecortarProcesos ordena por CPU y RSS y deja los primeros max (max <= 0 =
+// sin límite). Devuelve también cuántos quedaron afuera.
+func RecortarProcesos(procesos []recolector.Proceso, max int) ([]recolector.Proceso, int) {
+	if max <= 0 || len(procesos) <= max {
+		return append([]recolector.Proceso(nil), procesos...), 0
+	}
+	ordenados := append([]recolector.Proceso(nil), procesos...)
+	sort.Slice(ordenados, func(i, j int) bool {
+		if ordenados[i].CPU != ordenados[j].CPU {
+			return ordenados[i].CPU > ordenados[j].CPU
+		}
+		return ordenados[i].RSSKB > ordenados[j].RSSKB
+	})
+	return ordenados[:max], len(procesos) - max
+}
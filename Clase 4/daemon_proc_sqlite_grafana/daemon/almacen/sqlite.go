@@ -0,0 +1,79 @@
+package almacen
+
+import (
+	"database/sql"
+
+	"daemon/basedatos"
+)
+
+// SQLite guarda las muestras en las tablas registros, procesos y containers
+// que lee Grafana, a través de la cola de escritura de basedatos
+type SQLite struct {
+	cola *basedatos.Cola
+}
+
+// NuevoSQLite crea el destino sobre una base ya migrada
+func NuevoSQLite(db *sql.DB, capacidadCola int) *SQLite {
+	return &SQLite{cola: basedatos.NuevaCola(db, capacidadCola)}
+}
+
+func (s *SQLite) Nombre() string {
+	return "sqlite"
+}
+
+// Guardar encola un lote por métrica; todas las filas usan la hora del tick como created_at
+func (s *SQLite) Guardar(m Muestras) error {
+	creado := m.Tiempo.UnixMilli()
+
+	if t := m.Totales; t != nil {
+		// Una sola fila con los valores del sistema
+		s.cola.Encolar(basedatos.Lote{
+			Descripcion: "registros",
+			Consulta:    "INSERT INTO registros (total_ram, ram_libre, total_procesos, created_at) VALUES (?, ?, ?, ?)",
+			Filas:       [][]any{{t.TotalRAM, t.RAMLibre, t.Procesos, creado}},
+		})
+	}
+
+	if len(m.Procesos) > 0 {
+		filas := make([][]any, 0, len(m.Procesos))
+		for _, p := range m.Procesos {
+			filas = append(filas, []any{p.PID, p.Nombre, p.Estado, p.RSSKB, p.CPU, creado})
+		}
+		s.cola.Encolar(basedatos.Lote{
+			Descripcion: "procesos",
+			Consulta:    "INSERT INTO procesos (pid, nombre, estado, rss_kb, cpu, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			Filas:       filas,
+		})
+	}
+
+	if len(m.Contenedores) > 0 {
+		filas := make([][]any, 0, len(m.Contenedores))
+		for _, c := range m.Contenedores {
+			filas = append(filas, []any{c.Name, c.CPU, c.Memory, c.Status, creado})
+		}
+		s.cola.Encolar(basedatos.Lote{
+			Descripcion: "containers",
+			Consulta:    "INSERT INTO containers (name, cpu, memory, status, created_at) VALUES (?, ?, ?, ?, ?)",
+			Filas:       filas,
+		})
+	}
+
+	// Si la cola está llena el lote se descarta y ya quedó en el log; no es un error del tick
+	return nil
+}
+
+// Fallidos devuelve los lotes que no se pudieron insertar
+func (s *SQLite) Fallidos() int64 {
+	return s.cola.Fallidos()
+}
+
+// Descartados devuelve los lotes perdidos porque la cola estaba llena
+func (s *SQLite) Descartados() int64 {
+	return s.cola.Descartados()
+}
+
+// Close espera a que se escriban los lotes pendientes (la base la cierra el daemon)
+func (s *SQLite) Close() error {
+	s.cola.Cerrar()
+	return nil
+}
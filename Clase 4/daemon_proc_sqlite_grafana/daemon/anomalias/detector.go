@@ -0,0 +1,205 @@
+package anomalias
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"daemon/almacen"
+	"daemon/recolector"
+)
+
+// Anomalia es una serie que se mantuvo por encima de su línea base
+type Anomalia struct {
+	Serie      string    `json:"serie"`
+	Valor      float64   `json:"valor"`
+	Esperado   float64   `json:"esperado"`
+	Dispersion float64   `json:"dispersion"`
+	Puntaje    float64   `json:"puntaje"`
+	Metodo     string    `json:"metodo"`
+	Tiempo     time.Time `json:"tiempo"`
+}
+
+func (a Anomalia) String() string {
+	return fmt.Sprintf("%s = %.0f, se esperaba %.0f (%.1f desvíos, %s)", a.Serie, a.Valor, a.Esperado, a.Puntaje, a.Metodo)
+}
+
+// Parametros de la detección
+type Parametros struct {
+	// Linea crea la línea base de cada serie
+	Linea func() Linea
+	// Umbral en desvíos sobre lo esperado
+	Umbral float64
+	// Consecutivas muestras sobre el umbral antes de avisar
+	Consecutivas int
+}
+
+// Nombres de las series que se vigilan
+const (
+	SerieRAMUsada = "ram_usada"
+	SerieProcesos = "total_procesos"
+)
+
+// serie es el estado de detección de una métrica
+type serie struct {
+	nombre string
+	valor  func(recolector.Totales) float64
+	linea  Linea
+	// seguidas son las muestras consecutivas sobre el umbral
+	seguidas int
+	// avisada evita repetir el aviso mientras la anomalía continúa
+	avisada bool
+}
+
+// Avisos pendientes antes de descartar; las anomalías son raras, así que
+// llenar la cola significa que los notificadores están caídos
+const capacidadAvisos = 16
+
+// Detector vigila la RAM usada y la cantidad de procesos. Implementa
+// almacen.MetricStore para recibir los totales como un destino más; los
+// avisos se envían desde su propio goroutine para que un webhook lento no
+// atrase el muestreo.
+//
+// Solo se marcan subidas (fugas de memoria, fork bombs): una serie que baja
+// no se considera anómala.
+type Detector struct {
+	parametros    Parametros
+	series        []*serie
+	notificadores []Notificador
+	avisos        chan Anomalia
+	terminado     chan struct{}
+}
+
+// Nuevo crea el detector; cada anomalía se envía a todos los notificadores
+func Nuevo(p Parametros, notificadores ...Notificador) *Detector {
+	d := &Detector{
+		parametros: p,
+		series: []*serie{
+			{nombre: SerieRAMUsada, valor: func(t recolector.Totales) float64 { return float64(t.TotalRAM - t.RAMLibre) }},
+			{nombre: SerieProcesos, valor: func(t recolector.Totales) float64 { return float64(t.Procesos) }},
+		},
+		notificadores: notificadores,
+		avisos:        make(chan Anomalia, capacidadAvisos),
+		terminado:     make(chan struct{}),
+	}
+	for _, s := range d.series {
+		s.linea = p.Linea()
+	}
+	go d.notificar()
+	return d
+}
+
+// Precargar arma la línea base con las últimas muestras de registros, así
+// el detector no necesita esperar a juntar muestras después de reiniciar
+func (d *Detector) Precargar(db *sql.DB, muestras int) error {
+	filas, err := db.Query("SELECT total_ram, ram_libre, total_procesos FROM registros ORDER BY created_at DESC LIMIT ?", muestras)
+	if err != nil {
+		return fmt.Errorf("error leyendo registros para la línea base: %v", err)
+	}
+	defer filas.Close()
+
+	var historial []recolector.Totales
+	for filas.Next() {
+		var total, libre, procesos float64
+		if err := filas.Scan(&total, &libre, &procesos); err != nil {
+			return err
+		}
+		historial = append(historial, recolector.Totales{TotalRAM: int64(total), RAMLibre: int64(libre), Procesos: int(procesos)})
+	}
+	if err := filas.Err(); err != nil {
+		return err
+	}
+
+	// La consulta trae primero las más nuevas
+	slices.Reverse(historial)
+	for _, t := range historial {
+		for _, s := range d.series {
+			s.linea.Agregar(s.valor(t))
+		}
+	}
+	slog.Info("Línea base de anomalías precargada", "muestras", len(historial))
+	return nil
+}
+
+func (d *Detector) Nombre() string {
+	return "anomalias"
+}
+
+// Guardar evalúa los totales contra la línea base y después los agrega a ella
+func (d *Detector) Guardar(m almacen.Muestras) error {
+	if m.Totales == nil {
+		return nil
+	}
+	for _, s := range d.series {
+		v := s.valor(*m.Totales)
+		if a, ok := d.evaluar(s, v, m.Tiempo); ok {
+			select {
+			case d.avisos <- a:
+			default:
+				slog.Warn("Cola de avisos llena, se descarta la anomalía", "anomalia", a.String())
+			}
+		}
+		s.linea.Agregar(v)
+	}
+	return nil
+}
+
+// Fracción del valor esperado que se usa como dispersión mínima: si la serie
+// estuvo constante (por ejemplo siempre 312 procesos) la MAD es 0 y cualquier
+// cambio sería infinitos desvíos
+const dispersionMinima = 0.01
+
+// evaluar devuelve la anomalía cuando la serie lleva Consecutivas muestras
+// sobre el umbral; se avisa una sola vez hasta que la serie vuelve a lo normal
+func (d *Detector) evaluar(s *serie, v float64, t time.Time) (Anomalia, bool) {
+	esperado, dispersion, listo := s.linea.Base()
+	if !listo {
+		return Anomalia{}, false
+	}
+	dispersion = max(dispersion, dispersionMinima*math.Abs(esperado), 1)
+	puntaje := (v - esperado) / dispersion
+
+	if puntaje < d.parametros.Umbral {
+		if s.avisada {
+			slog.Info("La serie volvió a la normalidad", "serie", s.nombre, "valor", v, "esperado", esperado)
+		}
+		s.seguidas, s.avisada = 0, false
+		return Anomalia{}, false
+	}
+
+	s.seguidas++
+	if s.avisada || s.seguidas < d.parametros.Consecutivas {
+		return Anomalia{}, false
+	}
+	s.avisada = true
+	return Anomalia{
+		Serie:      s.nombre,
+		Valor:      v,
+		Esperado:   esperado,
+		Dispersion: dispersion,
+		Puntaje:    puntaje,
+		Metodo:     s.linea.Metodo(),
+		Tiempo:     t,
+	}, true
+}
+
+func (d *Detector) notificar() {
+	defer close(d.terminado)
+	for a := range d.avisos {
+		for _, n := range d.notificadores {
+			if err := n.Notificar(a); err != nil {
+				slog.Error("Error notificando anomalía", "anomalia", a.String(), "error", err)
+			}
+		}
+	}
+}
+
+// Close espera a que se envíen los avisos pendientes
+func (d *Detector) Close() error {
+	close(d.avisos)
+	<-d.terminado
+	return nil
+}
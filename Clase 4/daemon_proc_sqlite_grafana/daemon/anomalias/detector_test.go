@@ -0,0 +1,213 @@
+package anomalias
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"daemon/almacen"
+	"daemon/basedatos"
+	"daemon/recolector"
+)
+
+// avisosPrueba guarda las anomalías notificadas
+type avisosPrueba struct {
+	mu        sync.Mutex
+	anomalias []Anomalia
+}
+
+func (a *avisosPrueba) Notificar(an Anomalia) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.anomalias = append(a.anomalias, an)
+	return nil
+}
+
+// fallido siempre falla: un notificador caído no debe frenar a los demás
+type fallido struct{}
+
+func (fallido) Notificar(Anomalia) error {
+	return errors.New("webhook caído")
+}
+
+const totalRAM = 16000
+
+var inicio = time.UnixMilli(1700000000000)
+
+// muestreador alimenta al detector con un tick por segundo
+type muestreador struct {
+	t *testing.T
+	d *Detector
+	n int
+}
+
+// guardar manda una muestra con ramUsada y procesos; devuelve su tiempo
+func (m *muestreador) guardar(ramUsada float64, procesos int) time.Time {
+	m.t.Helper()
+	tiempo := inicio.Add(time.Duration(m.n) * time.Second)
+	m.n++
+	err := m.d.Guardar(almacen.Muestras{
+		Tiempo:  tiempo,
+		Totales: &recolector.Totales{TotalRAM: totalRAM, RAMLibre: totalRAM - int64(ramUsada), Procesos: procesos},
+	})
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return tiempo
+}
+
+// normal manda n muestras alrededor de 4000 MB usados y 300 procesos
+func (m *muestreador) normal(n int) {
+	m.t.Helper()
+	for i, v := range ruido(4000, 40, n) {
+		m.guardar(v, 300+i%3)
+	}
+}
+
+func nuevoDetector(t *testing.T, linea func() Linea, consecutivas int) (*muestreador, *avisosPrueba) {
+	avisos := &avisosPrueba{}
+	d := Nuevo(Parametros{Linea: linea, Umbral: 4, Consecutivas: consecutivas}, fallido{}, avisos)
+	return &muestreador{t: t, d: d}, avisos
+}
+
+func TestDetectarSubidaSostenida(t *testing.T) {
+	lineas := map[string]func() Linea{
+		"mad":  func() Linea { return NuevoMAD(120) },
+		"ewma": func() Linea { return NuevoEWMA(120) },
+	}
+	for metodo, linea := range lineas {
+		m, avisos := nuevoDetector(t, linea, 3)
+		m.normal(60)
+
+		// Dos muestras altas seguidas no alcanzan. Después hacen falta
+		// bastantes muestras normales porque el EWMA tarda en olvidar la
+		// dispersión que agregaron los picos (la MAD no la nota).
+		m.guardar(9000, 301)
+		m.guardar(9000, 301)
+		m.normal(200)
+
+		// Con tres se avisa una sola vez aunque la subida siga
+		m.guardar(9000, 301)
+		m.guardar(9000, 301)
+		tercera := m.guardar(9000, 301)
+		m.guardar(9000, 301)
+		m.guardar(9000, 301)
+		m.d.Close()
+
+		if len(avisos.anomalias) != 1 {
+			t.Fatalf("%s: %d avisos; se esperaba uno: %v", metodo, len(avisos.anomalias), avisos.anomalias)
+		}
+		a := avisos.anomalias[0]
+		if a.Serie != SerieRAMUsada || a.Metodo != metodo || !a.Tiempo.Equal(tercera) || a.Valor != 9000 || a.Puntaje < 4 {
+			t.Errorf("%s: anomalía %+v", metodo, a)
+		}
+	}
+}
+
+func TestAvisarDeNuevoDespuesDeNormalizar(t *testing.T) {
+	m, avisos := nuevoDetector(t, func() Linea { return NuevoMAD(120) }, 2)
+	m.normal(60)
+	m.guardar(4000, 900)
+	m.guardar(4000, 900)
+	m.guardar(4000, 900)
+	// Una sola muestra normal reinicia la cuenta y el aviso
+	m.normal(1)
+	m.guardar(4000, 900)
+	segunda := m.guardar(4000, 900)
+	m.d.Close()
+
+	if len(avisos.anomalias) != 2 {
+		t.Fatalf("%d avisos; se esperaban dos: %v", len(avisos.anomalias), avisos.anomalias)
+	}
+	if a := avisos.anomalias[1]; a.Serie != SerieProcesos || !a.Tiempo.Equal(segunda) {
+		t.Fatalf("segundo aviso %+v", a)
+	}
+}
+
+func TestNoAvisar(t *testing.T) {
+	casos := map[string]func(m *muestreador){
+		// Sin muestrasMinimas no hay línea base: las tres muestras altas llegan
+		// antes de que esté lista
+		"sin línea base": func(m *muestreador) {
+			m.normal(muestrasMinimas - 3)
+			for i := 0; i < 3; i++ {
+				m.guardar(15000, 5000)
+			}
+		},
+		// Solo importan las subidas
+		"bajada": func(m *muestreador) {
+			m.normal(60)
+			for i := 0; i < 5; i++ {
+				m.guardar(500, 10)
+			}
+		},
+		// Con una serie constante la MAD es 0: se usa el 1% del valor como
+		// dispersión mínima, así 312 → 314 procesos no es anómalo
+		"constante": func(m *muestreador) {
+			for i := 0; i < 60; i++ {
+				m.guardar(4000, 312)
+			}
+			for i := 0; i < 5; i++ {
+				m.guardar(4020, 314)
+			}
+		},
+		"sin totales": func(m *muestreador) {
+			m.normal(60)
+			for i := 0; i < 5; i++ {
+				if err := m.d.Guardar(almacen.Muestras{Tiempo: inicio}); err != nil {
+					m.t.Fatal(err)
+				}
+			}
+		},
+	}
+	for nombre, caso := range casos {
+		m, avisos := nuevoDetector(t, func() Linea { return NuevoMAD(120) }, 3)
+		caso(m)
+		m.d.Close()
+		if len(avisos.anomalias) != 0 {
+			t.Errorf("%s: avisos %v", nombre, avisos.anomalias)
+		}
+	}
+
+	// En la misma serie constante, 340 procesos sí es anómalo
+	m, avisos := nuevoDetector(t, func() Linea { return NuevoMAD(120) }, 1)
+	for i := 0; i < 60; i++ {
+		m.guardar(4000, 312)
+	}
+	m.guardar(4000, 340)
+	m.d.Close()
+	if len(avisos.anomalias) != 1 || avisos.anomalias[0].Dispersion != 3.12 {
+		t.Fatalf("avisos %+v", avisos.anomalias)
+	}
+}
+
+func TestPrecargar(t *testing.T) {
+	db, err := basedatos.Abrir(filepath.Join(t.TempDir(), "containers.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := basedatos.Migrar(db); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range ruido(4000, 40, 60) {
+		_, err := db.Exec("INSERT INTO registros (total_ram, ram_libre, total_procesos, created_at) VALUES (?, ?, ?, ?)",
+			totalRAM, totalRAM-v, 300, inicio.Add(time.Duration(i)*time.Second).UnixMilli())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Con la línea precargada la primera muestra ya se evalúa
+	m, avisos := nuevoDetector(t, func() Linea { return NuevoMAD(120) }, 1)
+	if err := m.d.Precargar(db, 60); err != nil {
+		t.Fatal(err)
+	}
+	m.guardar(9000, 300)
+	m.d.Close()
+	if len(avisos.anomalias) != 1 || avisos.anomalias[0].Esperado < 3950 || avisos.anomalias[0].Esperado > 4050 {
+		t.Fatalf("avisos %+v", avisos.anomalias)
+	}
+}
@@ -0,0 +1,107 @@
+// Package anomalias detecta valores fuera de lo normal en la RAM usada y la
+// cantidad de procesos: cada serie tiene una línea base móvil (EWMA o mediana
+// y MAD) y una muestra que la supera por varios desvíos se marca como anómala.
+package anomalias
+
+import (
+	"math"
+	"slices"
+)
+
+// Linea es la línea base de una serie: el valor esperado y cuánto varía
+// normalmente alrededor de él
+type Linea interface {
+	// Base devuelve el valor esperado y la dispersión; listo es false mientras
+	// no haya suficientes muestras para confiar en ella
+	Base() (esperado, dispersion float64, listo bool)
+	// Agregar incorpora una muestra nueva
+	Agregar(v float64)
+	// Metodo se guarda con cada anomalía ("ewma" o "mad")
+	Metodo() string
+}
+
+// Muestras antes de evaluar una serie
+const muestrasMinimas = 30
+
+// EWMA es un promedio y una varianza móviles exponenciales. Con una ventana
+// de n muestras alfa = 2/(n+1), como en un promedio móvil de n muestras.
+type EWMA struct {
+	alfa     float64
+	promedio float64
+	varianza float64
+	n        int
+}
+
+// NuevoEWMA crea la línea base con el alfa equivalente a la ventana
+func NuevoEWMA(ventana int) *EWMA {
+	return &EWMA{alfa: 2 / float64(ventana+1)}
+}
+
+func (e *EWMA) Base() (float64, float64, bool) {
+	return e.promedio, math.Sqrt(e.varianza), e.n >= muestrasMinimas
+}
+
+func (e *EWMA) Metodo() string {
+	return "ewma"
+}
+
+func (e *EWMA) Agregar(v float64) {
+	if e.n == 0 {
+		e.promedio = v
+	}
+	e.n++
+	diferencia := v - e.promedio
+	e.promedio += e.alfa * diferencia
+	e.varianza = (1 - e.alfa) * (e.varianza + e.alfa*diferencia*diferencia)
+}
+
+// MAD usa la mediana de las últimas muestras y la desviación absoluta
+// mediana. A diferencia del EWMA, un pico aislado no mueve la línea base.
+type MAD struct {
+	ventana []float64
+	tamano  int
+	proxima int
+}
+
+// NuevoMAD crea la línea base sobre las últimas ventana muestras
+func NuevoMAD(ventana int) *MAD {
+	return &MAD{tamano: ventana}
+}
+
+// La MAD multiplicada por esta constante estima el desvío estándar en datos normales
+const escalaMAD = 1.4826
+
+func (m *MAD) Base() (float64, float64, bool) {
+	if len(m.ventana) == 0 {
+		return 0, 0, false
+	}
+	med := mediana(m.ventana)
+	desvios := make([]float64, len(m.ventana))
+	for i, v := range m.ventana {
+		desvios[i] = math.Abs(v - med)
+	}
+	return med, escalaMAD * mediana(desvios), len(m.ventana) >= min(muestrasMinimas, m.tamano)
+}
+
+func (m *MAD) Metodo() string {
+	return "mad"
+}
+
+func (m *MAD) Agregar(v float64) {
+	if len(m.ventana) < m.tamano {
+		m.ventana = append(m.ventana, v)
+		return
+	}
+	m.ventana[m.proxima] = v
+	m.proxima = (m.proxima + 1) % m.tamano
+}
+
+func mediana(valores []float64) float64 {
+	ordenados := slices.Clone(valores)
+	slices.Sort(ordenados)
+	mitad := len(ordenados) / 2
+	if len(ordenados)%2 == 1 {
+		return ordenados[mitad]
+	}
+	return (ordenados[mitad-1] + ordenados[mitad]) / 2
+}
@@ -0,0 +1,121 @@
+package anomalias
+
+import (
+	"math"
+	"testing"
+)
+
+// ruido devuelve una serie alrededor de base que varía entre -amplitud y
+// +amplitud siempre igual, para que las pruebas sean reproducibles
+func ruido(base, amplitud float64, n int) []float64 {
+	serie := make([]float64, n)
+	for i := range serie {
+		serie[i] = base + amplitud*math.Sin(float64(i)*1.7)
+	}
+	return serie
+}
+
+func agregar(l Linea, valores []float64) {
+	for _, v := range valores {
+		l.Agregar(v)
+	}
+}
+
+func TestMediana(t *testing.T) {
+	casos := []struct {
+		valores []float64
+		desea   float64
+	}{
+		{[]float64{5}, 5},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{1, 1, 1, 1000}, 1},
+	}
+	for _, caso := range casos {
+		if got := mediana(caso.valores); got != caso.desea {
+			t.Errorf("mediana(%v) = %v; se esperaba %v", caso.valores, got, caso.desea)
+		}
+	}
+}
+
+func TestMADListo(t *testing.T) {
+	m := NuevoMAD(100)
+	if _, _, listo := m.Base(); listo {
+		t.Fatal("sin muestras no está lista")
+	}
+	agregar(m, ruido(100, 5, muestrasMinimas-1))
+	if _, _, listo := m.Base(); listo {
+		t.Fatalf("con %d muestras no debería estar lista", muestrasMinimas-1)
+	}
+	m.Agregar(100)
+	if _, _, listo := m.Base(); !listo {
+		t.Fatalf("con %d muestras debería estar lista", muestrasMinimas)
+	}
+
+	// Con una ventana menor que muestrasMinimas basta con llenarla
+	chica := NuevoMAD(10)
+	agregar(chica, ruido(100, 5, 10))
+	if _, _, listo := chica.Base(); !listo {
+		t.Fatal("una ventana llena debería estar lista")
+	}
+}
+
+func TestMADIgnoraPicos(t *testing.T) {
+	m := NuevoMAD(60)
+	agregar(m, ruido(100, 5, 60))
+	esperado, dispersion, _ := m.Base()
+	if math.Abs(esperado-100) > 1 || dispersion < 3 || dispersion > 8 {
+		t.Fatalf("esperado %.2f, dispersión %.2f", esperado, dispersion)
+	}
+
+	// Unos picos aislados no mueven la mediana ni la MAD
+	agregar(m, []float64{5000, 100, 9000, 100, 7000})
+	despues, dispersionDespues, _ := m.Base()
+	if math.Abs(despues-esperado) > 1 || math.Abs(dispersionDespues-dispersion) > 1 {
+		t.Fatalf("los picos movieron la línea: %.2f ± %.2f → %.2f ± %.2f", esperado, dispersion, despues, dispersionDespues)
+	}
+}
+
+func TestMADVentanaCircular(t *testing.T) {
+	m := NuevoMAD(40)
+	agregar(m, ruido(100, 5, 40))
+	// Un nivel nuevo reemplaza a las muestras más viejas hasta dominar la ventana
+	agregar(m, ruido(300, 5, 21))
+	if esperado, _, _ := m.Base(); esperado < 290 {
+		t.Fatalf("con la mayoría de la ventana en 300 se esperaba ~300: %.2f", esperado)
+	}
+	if len(m.ventana) != 40 {
+		t.Fatalf("la ventana creció a %d", len(m.ventana))
+	}
+}
+
+func TestEWMA(t *testing.T) {
+	e := NuevoEWMA(19)
+	if e.alfa != 0.1 {
+		t.Fatalf("alfa = %v; con ventana 19 se esperaba 0.1", e.alfa)
+	}
+
+	// Una serie constante arranca en su valor y no tiene dispersión
+	agregar(e, ruido(250, 0, muestrasMinimas-1))
+	if _, _, listo := e.Base(); listo {
+		t.Fatal("no debería estar lista antes de muestrasMinimas")
+	}
+	e.Agregar(250)
+	esperado, dispersion, listo := e.Base()
+	if !listo || esperado != 250 || dispersion != 0 {
+		t.Fatalf("constante: %.2f ± %.2f, lista %v", esperado, dispersion, listo)
+	}
+
+	// A diferencia de la MAD un pico sí mueve el promedio y la dispersión
+	e.Agregar(1250)
+	esperado, dispersion, _ = e.Base()
+	if math.Abs(esperado-350) > 1e-9 || dispersion < 250 {
+		t.Fatalf("después del pico: %.2f ± %.2f", esperado, dispersion)
+	}
+
+	// Y un nivel nuevo se alcanza con unas pocas ventanas
+	agregar(e, ruido(500, 0, 100))
+	if esperado, _, _ = e.Base(); math.Abs(esperado-500) > 1 {
+		t.Fatalf("nivel nuevo: %.2f", esperado)
+	}
+}
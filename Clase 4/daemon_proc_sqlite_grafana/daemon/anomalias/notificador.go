@@ -0,0 +1,84 @@
+package anomalias
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Notificador recibe cada anomalía detectada. Se pueden combinar varios: el
+// daemon siempre la registra en el log y en la tabla anomalias, y opcionalmente
+// la envía a un webhook.
+type Notificador interface {
+	Notificar(a Anomalia) error
+}
+
+// Log escribe la anomalía como advertencia en el log del daemon (journalctl)
+type Log struct{}
+
+func (Log) Notificar(a Anomalia) error {
+	slog.Warn("Anomalía detectada", "serie", a.Serie, "valor", a.Valor, "esperado", a.Esperado,
+		"puntaje", a.Puntaje, "metodo", a.Metodo)
+	return nil
+}
+
+// SQLite guarda la anomalía en la tabla anomalias de containers.db
+type SQLite struct {
+	DB *sql.DB
+}
+
+func (s SQLite) Notificar(a Anomalia) error {
+	_, err := s.DB.Exec(`INSERT INTO anomalias (serie, valor, esperado, dispersion, puntaje, metodo, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.Serie, a.Valor, a.Esperado, a.Dispersion, a.Puntaje, a.Metodo, a.Tiempo.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("error guardando anomalía: %v", err)
+	}
+	return nil
+}
+
+// Webhook envía la anomalía en un POST con JSON. El campo "text" lleva un
+// resumen legible, así que sirve directamente como webhook entrante de Slack,
+// Mattermost o Discord (con /slack al final de la url).
+type Webhook struct {
+	URL     string
+	Equipo  string
+	Cliente *http.Client
+}
+
+// NuevoWebhook crea el notificador con el nombre del equipo en cada aviso
+func NuevoWebhook(url string) *Webhook {
+	equipo, _ := os.Hostname()
+	return &Webhook{URL: url, Equipo: equipo, Cliente: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *Webhook) Notificar(a Anomalia) error {
+	cuerpo, err := json.Marshal(struct {
+		Anomalia
+		Equipo string `json:"equipo"`
+		Texto  string `json:"text"`
+	}{a, w.Equipo, fmt.Sprintf("[%s] Anomalía: %s", w.Equipo, a)})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Cliente.Post(w.URL, "application/json", bytes.NewReader(cuerpo))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detalle, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook respondió %s: %s", resp.Status, strings.TrimSpace(string(detalle)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,164 @@
+package basedatos
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Parámetros de conexión de go-sqlite3 que se aplican a cada conexión del pool:
+//   - WAL: Grafana puede leer mientras el daemon escribe (y viceversa) sin "database is locked"
+//   - busy_timeout: si otra conexión tiene el lock se espera hasta 5s en lugar de fallar
+//   - synchronous=NORMAL: con WAL sigue siendo seguro ante caídas del proceso y escribe menos a disco
+const parametrosConexion = "_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL"
+
+// Abrir abre containers.db con los parámetros de conexión del daemon
+func Abrir(ruta string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", ruta+"?"+parametrosConexion)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo base de datos %s: %v", ruta, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error conectando a la base de datos %s: %v", ruta, err)
+	}
+	return db, nil
+}
+
+// AbrirLectura abre containers.db en modo de solo lectura, para consultas que
+// no deben poder modificar la base (la API HTTP)
+func AbrirLectura(ruta string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+ruta+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo base de datos %s: %v", ruta, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error conectando a la base de datos %s: %v", ruta, err)
+	}
+	return db, nil
+}
+
+// Lote son las filas de un tick para una misma sentencia INSERT. Se insertan
+// todas en una transacción: o entran todas o ninguna.
+type Lote struct {
+	// Descripcion se usa en los logs (por ejemplo "procesos")
+	Descripcion string
+	Consulta    string
+	Filas       [][]any
+}
+
+// Cola desacopla el muestreo de la escritura: los ticks encolan lotes sin
+// esperar y un único goroutine los inserta. Si la base queda bloqueada (por
+// ejemplo una consulta larga de Grafana) la cola se llena y los lotes nuevos
+// se descartan, pero los relojes de muestreo nunca se atrasan.
+type Cola struct {
+	db         *sql.DB
+	lotes      chan Lote
+	sentencias map[string]*sql.Stmt
+	terminada  chan struct{}
+
+	descartados atomic.Int64
+	fallidos    atomic.Int64
+}
+
+// Intentos por lote antes de descartarlo cuando la base sigue bloqueada
+const intentosEscritura = 3
+
+// NuevaCola inicia el goroutine escritor con una cola de hasta capacidad lotes
+func NuevaCola(db *sql.DB, capacidad int) *Cola {
+	c := &Cola{
+		db:         db,
+		lotes:      make(chan Lote, capacidad),
+		sentencias: make(map[string]*sql.Stmt),
+		terminada:  make(chan struct{}),
+	}
+	go c.escribir()
+	return c
+}
+
+// Encolar agrega el lote sin bloquear. Devuelve false si la cola está llena
+// y el lote se descartó.
+func (c *Cola) Encolar(l Lote) bool {
+	if len(l.Filas) == 0 {
+		return true
+	}
+	select {
+	case c.lotes <- l:
+		return true
+	default:
+		n := c.descartados.Add(1)
+		slog.Warn("Cola de escritura llena, se descarta el lote", "lote", l.Descripcion, "filas", len(l.Filas), "descartados", n)
+		return false
+	}
+}
+
+// Descartados devuelve cuántos lotes se perdieron por cola llena
+func (c *Cola) Descartados() int64 {
+	return c.descartados.Load()
+}
+
+// Fallidos devuelve cuántos lotes no se pudieron insertar
+func (c *Cola) Fallidos() int64 {
+	return c.fallidos.Load()
+}
+
+// Cerrar deja de aceptar lotes y espera a que se escriban los pendientes
+func (c *Cola) Cerrar() {
+	close(c.lotes)
+	<-c.terminada
+}
+
+func (c *Cola) escribir() {
+	defer close(c.terminada)
+	defer func() {
+		for _, s := range c.sentencias {
+			s.Close()
+		}
+	}()
+
+	for l := range c.lotes {
+		var err error
+		for intento := 1; intento <= intentosEscritura; intento++ {
+			if err = c.insertar(l); err == nil {
+				break
+			}
+			slog.Warn("Error insertando lote, se reintenta", "lote", l.Descripcion, "intento", intento, "error", err)
+			time.Sleep(time.Duration(intento) * time.Second)
+		}
+		if err != nil {
+			c.fallidos.Add(1)
+			slog.Error("No se pudo insertar el lote", "lote", l.Descripcion, "filas", len(l.Filas), "error", err)
+		}
+	}
+}
+
+// insertar ejecuta el lote en una transacción usando la sentencia preparada
+// de su consulta (se prepara una sola vez y se reutiliza en cada tick)
+func (c *Cola) insertar(l Lote) error {
+	stmt, ok := c.sentencias[l.Consulta]
+	if !ok {
+		var err error
+		stmt, err = c.db.Prepare(l.Consulta)
+		if err != nil {
+			return fmt.Errorf("error preparando sentencia: %v", err)
+		}
+		c.sentencias[l.Consulta] = stmt
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txStmt := tx.Stmt(stmt)
+	for _, fila := range l.Filas {
+		if _, err := txStmt.Exec(fila...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
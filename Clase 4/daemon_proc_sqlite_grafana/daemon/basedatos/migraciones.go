@@ -0,0 +1,242 @@
+// Package basedatos administra el esquema de containers.db: las migraciones
+// versionadas y la retención de las muestras (compactación en promedios por
+// minuto y por hora).
+package basedatos
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Migracion es un cambio de esquema. Las versiones nunca se modifican una vez
+// publicadas: para cambiar algo se agrega una versión nueva al final.
+type Migracion struct {
+	Version     int
+	Descripcion string
+	SQL         string
+}
+
+// Migraciones en orden de versión
+var Migraciones = []Migracion{
+	{
+		Version:     1,
+		Descripcion: "tablas de muestras",
+		// IF NOT EXISTS porque las bases creadas antes de las migraciones ya tienen estas tablas
+		SQL: `
+		CREATE TABLE IF NOT EXISTS registros (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			total_ram REAL,
+			ram_libre REAL,
+			total_procesos REAL,
+			created_at INTEGER
+		);
+		CREATE TABLE IF NOT EXISTS procesos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			pid INTEGER,
+			nombre TEXT,
+			estado TEXT,
+			rss_kb INTEGER,
+			cpu REAL,
+			created_at INTEGER
+		);
+		CREATE TABLE IF NOT EXISTS containers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			cpu REAL,
+			memory REAL,
+			status TEXT,
+			created_at INTEGER
+		);`,
+	},
+	{
+		Version:     2,
+		Descripcion: "índices por created_at",
+		SQL: `
+		CREATE INDEX IF NOT EXISTS idx_registros_created_at ON registros (created_at);
+		CREATE INDEX IF NOT EXISTS idx_procesos_created_at ON procesos (created_at);
+		CREATE INDEX IF NOT EXISTS idx_containers_created_at ON containers (created_at);
+		CREATE INDEX IF NOT EXISTS idx_containers_name_created_at ON containers (name, created_at);`,
+	},
+	{
+		Version:     3,
+		Descripcion: "promedios por minuto y por hora",
+		// created_at es el inicio del minuto/hora y muestras la cantidad de filas promediadas.
+		// Las vistas *_historico juntan los tres niveles para consultar cualquier rango desde Grafana.
+		SQL: `
+		CREATE TABLE registros_1m (
+			created_at INTEGER PRIMARY KEY,
+			total_ram REAL,
+			ram_libre REAL,
+			total_procesos REAL,
+			muestras INTEGER NOT NULL
+		);
+		CREATE TABLE registros_1h (
+			created_at INTEGER PRIMARY KEY,
+			total_ram REAL,
+			ram_libre REAL,
+			total_procesos REAL,
+			muestras INTEGER NOT NULL
+		);
+		CREATE TABLE containers_1m (
+			created_at INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			cpu REAL,
+			memory REAL,
+			status TEXT,
+			muestras INTEGER NOT NULL,
+			PRIMARY KEY (created_at, name)
+		);
+		CREATE TABLE containers_1h (
+			created_at INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			cpu REAL,
+			memory REAL,
+			status TEXT,
+			muestras INTEGER NOT NULL,
+			PRIMARY KEY (created_at, name)
+		);
+		CREATE VIEW registros_historico AS
+			SELECT created_at, total_ram, ram_libre, total_procesos FROM registros
+			UNION ALL SELECT created_at, total_ram, ram_libre, total_procesos FROM registros_1m
+			UNION ALL SELECT created_at, total_ram, ram_libre, total_procesos FROM registros_1h;
+		CREATE VIEW containers_historico AS
+			SELECT created_at, name, cpu, memory, status FROM containers
+			UNION ALL SELECT created_at, name, cpu, memory, status FROM containers_1m
+			UNION ALL SELECT created_at, name, cpu, memory, status FROM containers_1h;`,
+	},
+	{
+		Version:     4,
+		Descripcion: "anomalías de RAM y procesos",
+		// esperado y dispersion son la línea base al momento de la anomalía; puntaje = (valor - esperado) / dispersion
+		SQL: `
+		CREATE TABLE anomalias (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			serie TEXT NOT NULL,
+			valor REAL,
+			esperado REAL,
+			dispersion REAL,
+			puntaje REAL,
+			metodo TEXT,
+			created_at INTEGER NOT NULL
+		);
+		CREATE INDEX idx_anomalias_created_at ON anomalias (created_at);`,
+	},
+}
+
+// Migrar aplica en orden las migraciones que falten y devuelve la versión final.
+// Cada migración corre en su propia transacción junto con su registro en la
+// tabla migraciones, así que si una falla la base queda en la versión anterior.
+func Migrar(db *sql.DB) (int, error) {
+	createTable := `
+	CREATE TABLE IF NOT EXISTS migraciones (
+		version INTEGER PRIMARY KEY,
+		descripcion TEXT,
+		aplicada_en INTEGER
+	);`
+	if _, err := db.Exec(createTable); err != nil {
+		return 0, fmt.Errorf("error creando tabla migraciones: %v", err)
+	}
+
+	actual, err := Version(db)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range Migraciones {
+		if m.Version <= actual {
+			continue
+		}
+		if err := aplicar(db, m); err != nil {
+			return actual, err
+		}
+		slog.Info("Migración aplicada", "version", m.Version, "descripcion", m.Descripcion)
+		actual = m.Version
+	}
+	return actual, nil
+}
+
+// Version devuelve la última migración aplicada (0 si no hay ninguna)
+func Version(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM migraciones").Scan(&version); err != nil {
+		return 0, fmt.Errorf("error consultando la versión del esquema: %v", err)
+	}
+	return int(version.Int64), nil
+}
+
+func aplicar(db *sql.DB, m Migracion) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("migración %d: %v", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("migración %d (%s): %v", m.Version, m.Descripcion, err)
+	}
+	_, err = tx.Exec("INSERT INTO migraciones (version, descripcion, aplicada_en) VALUES (?, ?, ?)",
+		m.Version, m.Descripcion, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("migración %d: %v", m.Version, err)
+	}
+	return tx.Commit()
+}
+
+// Esquema son las columnas de cada tabla y vista de la base, por nombre
+type Esquema map[string][]string
+
+// Tiene indica si la tabla o vista existe y tiene todas las columnas
+func (e Esquema) Tiene(tabla string, columnas ...string) bool {
+	existentes, ok := e[tabla]
+	if !ok {
+		return false
+	}
+	for _, c := range columnas {
+		if !slices.Contains(existentes, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// LeerEsquema consulta sqlite_master y PRAGMA table_info para conocer las
+// tablas y vistas actuales (por ejemplo para generar los dashboards)
+func LeerEsquema(db *sql.DB) (Esquema, error) {
+	filas, err := db.Query("SELECT name FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el esquema: %v", err)
+	}
+	var tablas []string
+	for filas.Next() {
+		var nombre string
+		if err := filas.Scan(&nombre); err != nil {
+			filas.Close()
+			return nil, err
+		}
+		tablas = append(tablas, nombre)
+	}
+	filas.Close()
+
+	esquema := make(Esquema)
+	for _, t := range tablas {
+		columnas, err := db.Query("SELECT name FROM pragma_table_info(?)", t)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo columnas de %s: %v", t, err)
+		}
+		for columnas.Next() {
+			var nombre string
+			if err := columnas.Scan(&nombre); err != nil {
+				columnas.Close()
+				return nil, err
+			}
+			esquema[t] = append(esquema[t], nombre)
+		}
+		columnas.Close()
+	}
+	return esquema, nil
+}
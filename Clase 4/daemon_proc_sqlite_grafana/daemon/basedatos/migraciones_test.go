@@ -0,0 +1,133 @@
+package basedatos
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// baseTemporal abre una containers.db nueva en una carpeta temporal
+func baseTemporal(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := Abrir(filepath.Join(t.TempDir(), "containers.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// baseMigrada es una base temporal con todas las migraciones aplicadas
+func baseMigrada(t *testing.T) *sql.DB {
+	t.Helper()
+	db := baseTemporal(t)
+	if _, err := Migrar(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func contar(t *testing.T, db *sql.DB, consulta string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(consulta, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", consulta, err)
+	}
+	return n
+}
+
+func TestMigrarDosVeces(t *testing.T) {
+	db := baseTemporal(t)
+	ultima := Migraciones[len(Migraciones)-1].Version
+
+	for i := 0; i < 2; i++ {
+		version, err := Migrar(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != ultima {
+			t.Fatalf("pasada %d: versión %d; se esperaba %d", i+1, version, ultima)
+		}
+		// La segunda pasada no vuelve a aplicar nada
+		if n := contar(t, db, "SELECT COUNT(*) FROM migraciones"); n != len(Migraciones) {
+			t.Fatalf("pasada %d: %d migraciones registradas", i+1, n)
+		}
+	}
+	if v, err := Version(db); err != nil || v != ultima {
+		t.Fatalf("Version = %d, %v", v, err)
+	}
+}
+
+func TestMigrarBaseAnterior(t *testing.T) {
+	// Una base creada antes de las migraciones ya tiene registros con datos
+	db := baseTemporal(t)
+	if v, err := Version(db); err == nil {
+		t.Fatalf("sin la tabla migraciones Version debería fallar: %d", v)
+	}
+	_, err := db.Exec(`CREATE TABLE registros (id INTEGER PRIMARY KEY AUTOINCREMENT, total_ram REAL,
+		ram_libre REAL, total_procesos REAL, created_at INTEGER);
+		INSERT INTO registros (total_ram, ram_libre, total_procesos, created_at) VALUES (8000, 2000, 300, 1000)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrar(db); err != nil {
+		t.Fatal(err)
+	}
+	if n := contar(t, db, "SELECT COUNT(*) FROM registros_historico"); n != 1 {
+		t.Fatalf("la muestra anterior debería conservarse: %d filas", n)
+	}
+}
+
+func TestMigracionFallidaNoSeAplica(t *testing.T) {
+	db := baseMigrada(t)
+	anterior, _ := Version(db)
+
+	originales := Migraciones
+	t.Cleanup(func() { Migraciones = originales })
+	Migraciones = append(originales[:len(originales):len(originales)], Migracion{
+		Version:     anterior + 1,
+		Descripcion: "falla a la mitad",
+		SQL:         "CREATE TABLE a_medias (id INTEGER); CREATE TABLE registros (id INTEGER);",
+	})
+
+	version, err := Migrar(db)
+	if err == nil {
+		t.Fatal("la migración debería fallar")
+	}
+	if version != anterior {
+		t.Fatalf("versión %d; se esperaba que siguiera en %d", version, anterior)
+	}
+	// La transacción se deshizo completa
+	if n := contar(t, db, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'a_medias'"); n != 0 {
+		t.Fatal("la tabla de la migración fallida no debería existir")
+	}
+	if v, _ := Version(db); v != anterior {
+		t.Fatalf("Version = %d", v)
+	}
+}
+
+func TestLeerEsquema(t *testing.T) {
+	esquema, err := LeerEsquema(baseMigrada(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	casos := []struct {
+		tabla    string
+		columnas []string
+		desea    bool
+	}{
+		{"registros", []string{"created_at", "total_ram"}, true},
+		{"containers_historico", []string{"created_at", "name", "status"}, true},
+		{"anomalias", []string{"serie", "puntaje", "metodo"}, true},
+		{"registros", []string{"name"}, false},
+		{"no_existe", nil, false},
+	}
+	for _, caso := range casos {
+		if got := esquema.Tiene(caso.tabla, caso.columnas...); got != caso.desea {
+			t.Errorf("Tiene(%s, %v) = %v", caso.tabla, caso.columnas, got)
+		}
+	}
+	if _, ok := esquema["sqlite_sequence"]; ok {
+		t.Error("las tablas internas de SQLite no son parte del esquema")
+	}
+}
@@ -0,0 +1,146 @@
+package basedatos
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Politica indica cuánto tiempo se guarda cada nivel de detalle:
+// las muestras crudas durante Crudo, los promedios por minuto durante Minuto
+// y los promedios por hora para siempre.
+type Politica struct {
+	Crudo  time.Duration
+	Minuto time.Duration
+}
+
+// Resumen cuenta las filas que se movieron en una compactación
+type Resumen struct {
+	// Filas crudas que pasaron a los promedios por minuto (o se borraron, en procesos)
+	Crudas int64
+	// Filas por minuto que pasaron a los promedios por hora
+	Minutos int64
+}
+
+// serie describe una tabla de muestras que se compacta
+type serie struct {
+	tabla string
+	// Columnas que identifican la serie dentro de un mismo instante (name en containers)
+	clave []string
+	// Columnas numéricas que se promedian
+	promedios []string
+	// Columnas que conservan el último valor del intervalo (status en containers)
+	ultimos []string
+}
+
+var series = []serie{
+	{tabla: "registros", promedios: []string{"total_ram", "ram_libre", "total_procesos"}},
+	{tabla: "containers", clave: []string{"name"}, promedios: []string{"cpu", "memory"}, ultimos: []string{"status"}},
+}
+
+// Compactar agrupa las muestras crudas más viejas que p.Crudo en promedios por
+// minuto y los promedios por minuto más viejos que p.Minuto en promedios por
+// hora, borrando las filas originales. La tabla procesos no tiene promedios
+// (un pid no es una serie estable): sus filas se borran al vencer p.Crudo.
+// Todo ocurre en una transacción, así que una consulta nunca ve una muestra
+// en dos niveles ni en ninguno.
+func Compactar(db *sql.DB, p Politica, ahora time.Time) (Resumen, error) {
+	var r Resumen
+	if p.Crudo <= 0 || p.Minuto <= 0 {
+		return r, fmt.Errorf("política de retención inválida: %+v", p)
+	}
+
+	// Los cortes se alinean al inicio del minuto/hora para no partir un intervalo
+	// entre dos compactaciones
+	corteCrudo := ahora.Add(-p.Crudo).Truncate(time.Minute).UnixMilli()
+	corteMinuto := ahora.Add(-p.Minuto).Truncate(time.Hour).UnixMilli()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return r, fmt.Errorf("error iniciando compactación: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, s := range series {
+		n, err := s.compactar(tx, s.tabla, s.tabla+"_1m", "1", time.Minute, corteCrudo)
+		if err != nil {
+			return r, err
+		}
+		r.Crudas += n
+
+		n, err = s.compactar(tx, s.tabla+"_1m", s.tabla+"_1h", "muestras", time.Hour, corteMinuto)
+		if err != nil {
+			return r, err
+		}
+		r.Minutos += n
+	}
+
+	res, err := tx.Exec("DELETE FROM procesos WHERE created_at < ?", corteCrudo)
+	if err != nil {
+		return r, fmt.Errorf("error borrando procesos viejos: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	r.Crudas += n
+
+	if err := tx.Commit(); err != nil {
+		return r, fmt.Errorf("error confirmando compactación: %v", err)
+	}
+	return r, nil
+}
+
+// compactar pasa las filas de origen anteriores a corte a la tabla destino,
+// agrupadas en intervalos de ancho. peso es la cantidad de muestras que
+// representa cada fila de origen: 1 en las crudas y la columna muestras en
+// los promedios por minuto, para que el promedio por hora sea exacto.
+// Si el intervalo ya existe en destino (una compactación anterior lo empezó)
+// se combinan los dos promedios según sus muestras.
+func (s serie) compactar(tx *sql.Tx, origen, destino, peso string, ancho time.Duration, corte int64) (int64, error) {
+	ms := ancho.Milliseconds()
+
+	// Columnas del SELECT interno
+	internas := []string{fmt.Sprintf("(created_at / %d) * %d AS intervalo", ms, ms)}
+	internas = append(internas, s.clave...)
+	for _, c := range s.promedios {
+		internas = append(internas, fmt.Sprintf("SUM(%s * %s) / SUM(%s) AS %s", c, peso, peso, c))
+	}
+	// Con un único MAX() en la consulta, SQLite toma las columnas sin agregar
+	// (ultimos) de la fila que tiene el máximo, es decir, la más reciente
+	internas = append(internas, s.ultimos...)
+	internas = append(internas, fmt.Sprintf("SUM(%s) AS muestras", peso), "MAX(created_at) AS ultima")
+
+	columnas := append(append(append([]string{}, s.clave...), s.promedios...), s.ultimos...)
+	agrupar := append([]string{"intervalo"}, s.clave...)
+	conflicto := append([]string{"created_at"}, s.clave...)
+
+	var actualizar []string
+	for _, c := range s.promedios {
+		actualizar = append(actualizar, fmt.Sprintf(
+			"%s = (%s * muestras + excluded.%s * excluded.muestras) / (muestras + excluded.muestras)", c, c, c))
+	}
+	for _, c := range s.ultimos {
+		actualizar = append(actualizar, fmt.Sprintf("%s = excluded.%s", c, c))
+	}
+	actualizar = append(actualizar, "muestras = muestras + excluded.muestras")
+
+	// El "WHERE true" evita que SQLite confunda el ON CONFLICT con un JOIN ... ON
+	insertar := fmt.Sprintf(`INSERT INTO %s (created_at, %s, muestras)
+		SELECT intervalo, %s, muestras FROM (
+			SELECT %s FROM %s WHERE created_at < ? GROUP BY %s
+		) WHERE true
+		ON CONFLICT (%s) DO UPDATE SET %s`,
+		destino, strings.Join(columnas, ", "),
+		strings.Join(columnas, ", "),
+		strings.Join(internas, ", "), origen, strings.Join(agrupar, ", "),
+		strings.Join(conflicto, ", "), strings.Join(actualizar, ", "))
+
+	if _, err := tx.Exec(insertar, corte); err != nil {
+		return 0, fmt.Errorf("error compactando %s en %s: %v", origen, destino, err)
+	}
+
+	res, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE created_at < ?", origen), corte)
+	if err != nil {
+		return 0, fmt.Errorf("error borrando filas compactadas de %s: %v", origen, err)
+	}
+	return res.RowsAffected()
+}
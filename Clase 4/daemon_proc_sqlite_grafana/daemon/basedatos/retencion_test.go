@@ -0,0 +1,187 @@
+package basedatos
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+)
+
+var (
+	ahora    = time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	politica = Politica{Crudo: time.Hour, Minuto: 24 * time.Hour}
+)
+
+// ms es el created_at de la hora dada del 31 de diciembre o del 1 de enero
+func ms(dia int, hora, minuto, segundo int) int64 {
+	if dia == 31 {
+		return time.Date(2023, 12, 31, hora, minuto, segundo, 0, time.UTC).UnixMilli()
+	}
+	return time.Date(2024, 1, 1, hora, minuto, segundo, 0, time.UTC).UnixMilli()
+}
+
+func ejecutar(t *testing.T, db *sql.DB, consulta string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(consulta, args...); err != nil {
+		t.Fatalf("%s: %v", consulta, err)
+	}
+}
+
+func registro(t *testing.T, db *sql.DB, creado int64, totalRAM float64) {
+	t.Helper()
+	ejecutar(t, db, "INSERT INTO registros (total_ram, ram_libre, total_procesos, created_at) VALUES (?, 0, 100, ?)", totalRAM, creado)
+}
+
+func container(t *testing.T, db *sql.DB, creado int64, nombre, estado string, cpu float64) {
+	t.Helper()
+	ejecutar(t, db, "INSERT INTO containers (name, cpu, memory, status, created_at) VALUES (?, ?, 10, ?, ?)", nombre, cpu, estado, creado)
+}
+
+// promedio es una fila de registros_1m o registros_1h
+type promedio struct {
+	creado   int64
+	totalRAM float64
+	muestras int
+}
+
+func promedios(t *testing.T, db *sql.DB, tabla string) []promedio {
+	t.Helper()
+	filas, err := db.Query("SELECT created_at, total_ram, muestras FROM " + tabla + " ORDER BY created_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer filas.Close()
+	var lista []promedio
+	for filas.Next() {
+		var p promedio
+		if err := filas.Scan(&p.creado, &p.totalRAM, &p.muestras); err != nil {
+			t.Fatal(err)
+		}
+		lista = append(lista, p)
+	}
+	return lista
+}
+
+func iguales(t *testing.T, tabla string, got, desea []promedio) {
+	t.Helper()
+	if len(got) != len(desea) {
+		t.Fatalf("%s: %+v; se esperaba %+v", tabla, got, desea)
+	}
+	for i := range got {
+		if got[i].creado != desea[i].creado || got[i].muestras != desea[i].muestras || math.Abs(got[i].totalRAM-desea[i].totalRAM) > 1e-9 {
+			t.Fatalf("%s: %+v; se esperaba %+v", tabla, got, desea)
+		}
+	}
+}
+
+func TestCompactar(t *testing.T) {
+	db := baseMigrada(t)
+
+	// El corte de las crudas es 11:00 (una hora antes, al inicio del minuto)
+	registro(t, db, ms(1, 10, 58, 10), 100)
+	registro(t, db, ms(1, 10, 58, 40), 200)
+	registro(t, db, ms(1, 10, 59, 59), 300)
+	registro(t, db, ms(1, 11, 0, 5), 999)
+	ejecutar(t, db, "INSERT INTO procesos (pid, nombre, created_at) VALUES (1, 'viejo', ?), (2, 'nuevo', ?)", ms(1, 10, 30, 0), ms(1, 11, 30, 0))
+
+	// El de los promedios por minuto es el 31 a las 12:00 (un día antes, al
+	// inicio de la hora); los dos minutos de las 10 se combinan según sus muestras
+	ejecutar(t, db, "INSERT INTO registros_1m (created_at, total_ram, ram_libre, total_procesos, muestras) VALUES (?, 100, 0, 100, 60), (?, 200, 0, 100, 20), (?, 500, 0, 100, 60)",
+		ms(31, 10, 5, 0), ms(31, 10, 50, 0), ms(31, 12, 0, 0))
+
+	resumen, err := Compactar(db, politica, ahora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 registros y 1 proceso crudos; 2 promedios por minuto
+	if resumen != (Resumen{Crudas: 4, Minutos: 2}) {
+		t.Fatalf("resumen %+v", resumen)
+	}
+
+	// Solo queda cruda la muestra posterior al corte
+	if n := contar(t, db, "SELECT COUNT(*) FROM registros"); n != 1 {
+		t.Fatalf("quedan %d registros crudos", n)
+	}
+	iguales(t, "registros_1m", promedios(t, db, "registros_1m"), []promedio{
+		{ms(31, 12, 0, 0), 500, 60},
+		{ms(1, 10, 58, 0), 150, 2},
+		{ms(1, 10, 59, 0), 300, 1},
+	})
+	iguales(t, "registros_1h", promedios(t, db, "registros_1h"), []promedio{{ms(31, 10, 0, 0), 125, 80}})
+
+	if n := contar(t, db, "SELECT COUNT(*) FROM procesos"); n != 1 {
+		t.Fatalf("quedan %d procesos; el viejo debería borrarse", n)
+	}
+	// Cada muestra aparece una sola vez en la vista
+	if n := contar(t, db, "SELECT COUNT(*) FROM registros_historico"); n != 5 {
+		t.Fatalf("registros_historico tiene %d filas", n)
+	}
+
+	// Compactar otra vez con la misma hora no cambia nada
+	resumen, err = Compactar(db, politica, ahora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumen != (Resumen{}) {
+		t.Fatalf("segunda compactación: %+v", resumen)
+	}
+	iguales(t, "registros_1h", promedios(t, db, "registros_1h"), []promedio{{ms(31, 10, 0, 0), 125, 80}})
+}
+
+func TestCompactarCombinaIntervaloExistente(t *testing.T) {
+	db := baseMigrada(t)
+	// Una compactación anterior ya dejó el minuto 10:58 con dos muestras
+	ejecutar(t, db, "INSERT INTO registros_1m (created_at, total_ram, ram_libre, total_procesos, muestras) VALUES (?, 400, 0, 100, 2)", ms(1, 10, 58, 0))
+	registro(t, db, ms(1, 10, 58, 10), 100)
+	registro(t, db, ms(1, 10, 58, 40), 200)
+
+	if _, err := Compactar(db, politica, ahora); err != nil {
+		t.Fatal(err)
+	}
+	iguales(t, "registros_1m", promedios(t, db, "registros_1m"), []promedio{{ms(1, 10, 58, 0), 275, 4}})
+}
+
+func TestCompactarContainers(t *testing.T) {
+	db := baseMigrada(t)
+	container(t, db, ms(1, 10, 58, 10), "web", "running", 10)
+	container(t, db, ms(1, 10, 58, 50), "web", "exited", 30)
+	container(t, db, ms(1, 10, 58, 10), "db", "running", 5)
+
+	if _, err := Compactar(db, politica, ahora); err != nil {
+		t.Fatal(err)
+	}
+	filas, err := db.Query("SELECT name, cpu, status, muestras FROM containers_1m WHERE created_at = ? ORDER BY name", ms(1, 10, 58, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer filas.Close()
+
+	type fila struct {
+		nombre   string
+		cpu      float64
+		estado   string
+		muestras int
+	}
+	// Un promedio por contenedor; el estado es el de la última muestra
+	desea := []fila{{"db", 5, "running", 1}, {"web", 20, "exited", 2}}
+	var got []fila
+	for filas.Next() {
+		var f fila
+		if err := filas.Scan(&f.nombre, &f.cpu, &f.estado, &f.muestras); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, f)
+	}
+	if len(got) != len(desea) || got[0] != desea[0] || got[1] != desea[1] {
+		t.Fatalf("containers_1m: %+v; se esperaba %+v", got, desea)
+	}
+}
+
+func TestCompactarPoliticaInvalida(t *testing.T) {
+	db := baseMigrada(t)
+	for _, p := range []Politica{{}, {Crudo: time.Hour}, {Minuto: time.Hour}} {
+		if _, err := Compactar(db, p, ahora); err == nil {
+			t.Errorf("%+v debería ser inválida", p)
+		}
+	}
+}
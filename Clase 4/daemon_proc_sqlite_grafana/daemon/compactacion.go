@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"daemon/basedatos"
+	"daemon/config"
+)
+
+// compactador ejecuta basedatos.Compactar en segundo plano, de a una vez:
+// si la compactación anterior sigue corriendo, el tick se omite
+type compactador struct {
+	db         *sql.DB
+	corriendo  sync.Mutex
+	terminados sync.WaitGroup
+}
+
+func nuevoCompactador(db *sql.DB) *compactador {
+	return &compactador{db: db}
+}
+
+func (c *compactador) iniciar(r config.Retencion) {
+	if !c.corriendo.TryLock() {
+		slog.Warn("La compactación anterior sigue en curso, se omite")
+		return
+	}
+
+	politica := basedatos.Politica{
+		Crudo:  time.Duration(r.Crudo),
+		Minuto: time.Duration(r.Minuto),
+	}
+
+	c.terminados.Add(1)
+	go func() {
+		defer c.terminados.Done()
+		defer c.corriendo.Unlock()
+
+		inicio := time.Now()
+		resumen, err := basedatos.Compactar(c.db, politica, inicio)
+		if err != nil {
+			slog.Error("Error compactando la base de datos", "error", err)
+			return
+		}
+		slog.Info("Compactación terminada",
+			"crudas", resumen.Crudas,
+			"minutos", resumen.Minutos,
+			"duracion", time.Since(inicio).Round(time.Millisecond))
+	}()
+}
+
+// esperar bloquea hasta que termine la compactación en curso (antes de cerrar la DB)
+func (c *compactador) esperar() {
+	c.terminados.Wait()
+}
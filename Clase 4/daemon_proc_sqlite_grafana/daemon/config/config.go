@@ -0,0 +1,250 @@
+// Package config carga la configuración del daemon desde un archivo JSON.
+// Si el archivo no existe se usan los valores por defecto.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Duracion permite escribir intervalos como "5s" o "1m" en el JSON
+type Duracion time.Duration
+
+// UnmarshalJSON acepta un string con formato de time.ParseDuration
+func (d *Duracion) UnmarshalJSON(b []byte) error {
+	var texto string
+	if err := json.Unmarshal(b, &texto); err != nil {
+		return fmt.Errorf("la duración debe ser un texto como \"10s\": %v", err)
+	}
+	v, err := time.ParseDuration(texto)
+	if err != nil {
+		return err
+	}
+	*d = Duracion(v)
+	return nil
+}
+
+// MarshalJSON escribe la duración como texto
+func (d Duracion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Intervalos de muestreo de cada métrica
+type Intervalos struct {
+	// Totales de RAM y procesos (/proc/sysinfo -> registros)
+	Totales Duracion `json:"totales"`
+	// Tabla de procesos (/proc/<pid> -> procesos)
+	Procesos Duracion `json:"procesos"`
+	// Estado de los contenedores (-> containers)
+	Contenedores Duracion `json:"contenedores"`
+}
+
+// Retencion de las muestras en containers.db
+type Retencion struct {
+	// Tiempo que se guardan las muestras crudas antes de pasar a promedios por minuto
+	Crudo Duracion `json:"crudo"`
+	// Tiempo que se guardan los promedios por minuto antes de pasar a promedios por hora
+	Minuto Duracion `json:"minuto"`
+	// Cada cuánto corre la compactación
+	Compactacion Duracion `json:"compactacion"`
+}
+
+// Almacenes son los destinos de las muestras; se pueden activar varios.
+// Un destino con ruta o url vacía queda desactivado.
+type Almacenes struct {
+	// SQLite guarda en containers.db (las tablas que lee Grafana)
+	SQLite bool `json:"sqlite"`
+	// JSONL escribe un punto por línea; el archivo se rota a los 50 MB
+	JSONL string `json:"jsonl"`
+	// InfluxDB recibe line protocol, por ejemplo http://localhost:8086/api/v2/write?org=sopes1&bucket=daemon
+	InfluxDB      string `json:"influxdb"`
+	InfluxDBToken string `json:"influxdb_token"`
+	// PrometheusRemoteWrite, por ejemplo http://localhost:9090/api/v1/write
+	PrometheusRemoteWrite string `json:"prometheus_remote_write"`
+	// MaxProcesos limita las series por proceso que se envían a InfluxDB y a
+	// remote-write (0 = sin límite)
+	MaxProcesos int `json:"max_procesos"`
+}
+
+// Exportador es el endpoint /metrics para Prometheus
+type Exportador struct {
+	// Direccion donde escucha, por ejemplo ":9101" (vacía = desactivado)
+	Direccion string `json:"direccion"`
+	// MaxProcesos limita las series por proceso (0 = sin límite)
+	MaxProcesos int `json:"max_procesos"`
+}
+
+// API es la API HTTP de solo lectura sobre containers.db (/api/registros y /api/containers)
+type API struct {
+	// Direccion donde escucha, por ejemplo "127.0.0.1:9102" (vacía = desactivada)
+	Direccion string `json:"direccion"`
+}
+
+// Grafana levantado por el daemon con docker compose
+type Grafana struct {
+	// Activo = false si Grafana se administra por fuera del daemon
+	Activo  bool   `json:"activo"`
+	Puerto  int    `json:"puerto"`
+	Usuario string `json:"usuario"`
+	Clave   string `json:"clave"`
+	// BajarAlSalir ejecuta "docker compose down" cuando se detiene el daemon
+	BajarAlSalir bool `json:"bajar_al_salir"`
+}
+
+// Anomalias configura la detección de anomalías en la RAM usada y la cantidad
+// de procesos (por ejemplo fugas de memoria o fork bombs)
+type Anomalias struct {
+	Activo bool `json:"activo"`
+	// Metodo de la línea base: MetodoEWMA o MetodoMAD
+	Metodo string `json:"metodo"`
+	// Ventana en muestras de totales (con 5s, 120 muestras son 10 minutos)
+	Ventana int `json:"ventana"`
+	// Umbral en desvíos sobre la línea base a partir del cual una muestra es anómala
+	Umbral float64 `json:"umbral"`
+	// Consecutivas muestras anómalas necesarias para avisar (evita alertas por un pico aislado)
+	Consecutivas int `json:"consecutivas"`
+	// Webhook recibe un POST con la anomalía en JSON (vacío = solo log y tabla anomalias)
+	Webhook string `json:"webhook"`
+}
+
+// Métodos de la línea base de las anomalías
+const (
+	// MetodoEWMA usa promedio y varianza móviles exponenciales
+	MetodoEWMA = "ewma"
+	// MetodoMAD usa la mediana y la desviación absoluta mediana de la ventana
+	MetodoMAD = "mad"
+)
+
+// Fuentes de las métricas de contenedores
+const (
+	// FuenteCgroups lee /sys/fs/cgroup (cgroup v2) de Docker y containerd
+	FuenteCgroups = "cgroups"
+	// FuenteContinfo lee el JSON del módulo de kernel en /proc/continfo
+	FuenteContinfo = "continfo"
+)
+
+// Config es la configuración completa del daemon
+type Config struct {
+	Intervalos Intervalos `json:"intervalos"`
+	// FuenteContenedores es FuenteCgroups o FuenteContinfo
+	FuenteContenedores string    `json:"fuente_contenedores"`
+	Retencion          Retencion `json:"retencion"`
+	// Los almacenes se abren al iniciar; para cambiarlos hay que reiniciar el daemon
+	Almacenes Almacenes `json:"almacenes"`
+	// El exportador también se configura al iniciar
+	Exportador Exportador `json:"exportador"`
+	// La API de consulta también se configura al iniciar
+	API     API     `json:"api"`
+	Grafana Grafana `json:"grafana"`
+	// La detección de anomalías se configura al iniciar
+	Anomalias Anomalias `json:"anomalias"`
+}
+
+// PorDefecto devuelve la configuración que se usa si no hay archivo
+func PorDefecto() *Config {
+	return &Config{
+		Intervalos: Intervalos{
+			Totales:      Duracion(5 * time.Second),
+			Procesos:     Duracion(30 * time.Second),
+			Contenedores: Duracion(10 * time.Second),
+		},
+		FuenteContenedores: FuenteCgroups,
+		Retencion: Retencion{
+			Crudo:        Duracion(24 * time.Hour),
+			Minuto:       Duracion(30 * 24 * time.Hour),
+			Compactacion: Duracion(time.Hour),
+		},
+		Almacenes:  Almacenes{SQLite: true, MaxProcesos: 50},
+		Exportador: Exportador{Direccion: ":9101", MaxProcesos: 50},
+		API:        API{Direccion: "127.0.0.1:9102"},
+		Grafana:    Grafana{Activo: true, Puerto: 3000, Usuario: "admin", Clave: "admin"},
+		Anomalias:  Anomalias{Activo: true, Metodo: MetodoMAD, Ventana: 120, Umbral: 4, Consecutivas: 3},
+	}
+}
+
+// Cargar lee el archivo; los campos que falten conservan el valor por defecto
+func Cargar(ruta string) (*Config, error) {
+	cfg := PorDefecto()
+
+	contenido, err := os.ReadFile(ruta)
+	if os.IsNotExist(err) {
+		// Los valores por defecto también se validan: un intervalo en cero
+		// haría girar sin pausa al reloj de muestreo
+		if err := cfg.Validar(); err != nil {
+			return nil, fmt.Errorf("configuración por defecto inválida: %v", err)
+		}
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo configuración %s: %v", ruta, err)
+	}
+
+	if err := json.Unmarshal(contenido, cfg); err != nil {
+		return nil, fmt.Errorf("error al parsear configuración %s: %v", ruta, err)
+	}
+	if err := cfg.Validar(); err != nil {
+		return nil, fmt.Errorf("configuración %s inválida: %v", ruta, err)
+	}
+
+	// Las rutas relativas son relativas al archivo de configuración
+	if j := cfg.Almacenes.JSONL; j != "" && !filepath.IsAbs(j) {
+		cfg.Almacenes.JSONL = filepath.Join(filepath.Dir(ruta), j)
+	}
+	return cfg, nil
+}
+
+// Validar revisa que los intervalos tengan sentido
+func (c *Config) Validar() error {
+	intervalos := map[string]Duracion{
+		"totales":      c.Intervalos.Totales,
+		"procesos":     c.Intervalos.Procesos,
+		"contenedores": c.Intervalos.Contenedores,
+	}
+	for nombre, d := range intervalos {
+		if time.Duration(d) < time.Second {
+			return fmt.Errorf("el intervalo %s debe ser de al menos 1s (es %s)", nombre, time.Duration(d))
+		}
+	}
+
+	if c.FuenteContenedores != FuenteCgroups && c.FuenteContenedores != FuenteContinfo {
+		return fmt.Errorf("fuente_contenedores debe ser %q o %q", FuenteCgroups, FuenteContinfo)
+	}
+
+	r := c.Retencion
+	if time.Duration(r.Crudo) < time.Minute || time.Duration(r.Compactacion) < time.Minute {
+		return fmt.Errorf("la retención cruda y el intervalo de compactación deben ser de al menos 1m")
+	}
+	if r.Minuto <= r.Crudo {
+		return fmt.Errorf("la retención por minuto (%s) debe ser mayor que la cruda (%s)",
+			time.Duration(r.Minuto), time.Duration(r.Crudo))
+	}
+
+	a := c.Almacenes
+	if !a.SQLite && a.JSONL == "" && a.InfluxDB == "" && a.PrometheusRemoteWrite == "" {
+		return fmt.Errorf("no hay ningún almacén activo")
+	}
+	if a.MaxProcesos < 0 {
+		return fmt.Errorf("almacenes.max_procesos no puede ser negativo")
+	}
+	if c.Exportador.MaxProcesos < 0 {
+		return fmt.Errorf("max_procesos no puede ser negativo")
+	}
+	if g := c.Grafana; g.Activo && (g.Puerto <= 0 || g.Puerto > 65535) {
+		return fmt.Errorf("puerto de grafana inválido: %d", g.Puerto)
+	}
+	if an := c.Anomalias; an.Activo {
+		if an.Metodo != MetodoEWMA && an.Metodo != MetodoMAD {
+			return fmt.Errorf("anomalias.metodo debe ser %q o %q", MetodoEWMA, MetodoMAD)
+		}
+		if an.Ventana < 30 {
+			return fmt.Errorf("anomalias.ventana debe ser de al menos 30 muestras (es %d)", an.Ventana)
+		}
+		if an.Umbral <= 0 || an.Consecutivas < 1 {
+			return fmt.Errorf("anomalias.umbral debe ser positivo y anomalias.consecutivas al menos 1")
+		}
+	}
+	return nil
+}
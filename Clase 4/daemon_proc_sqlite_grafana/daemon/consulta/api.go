@@ -0,0 +1,217 @@
+// Package consulta expone una API HTTP de solo lectura sobre containers.db,
+// para que scripts y otros servicios lean el historial sin compartir el
+// archivo de SQLite:
+//
+//	GET /api/registros?from=now-6h&to=now&step=1m&agg=avg
+//	GET /api/containers?status=running&top=5
+//
+// Las respuestas son JSON (un objeto por fila) o CSV con format=csv o
+// "Accept: text/csv".
+package consulta
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// API atiende las consultas sobre una conexión de solo lectura
+type API struct {
+	db *sql.DB
+	// ahora se puede reemplazar para resolver "now" en un instante fijo
+	ahora func() time.Time
+}
+
+// Nueva crea la API; db debería abrirse con basedatos.AbrirLectura
+func Nueva(db *sql.DB) *API {
+	return &API{db: db, ahora: time.Now}
+}
+
+// Tiempo máximo de una consulta; un rango enorme sin step no debe trabar la base
+const tiempoConsulta = 10 * time.Second
+
+// Handler devuelve las rutas de la API
+func (a *API) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/registros", a.registros)
+	mux.HandleFunc("GET /api/containers", a.containers)
+	return mux
+}
+
+// Servir atiende la API en la dirección indicada (por ejemplo "127.0.0.1:9102")
+// hasta que se llame Shutdown en el servidor devuelto
+func (a *API) Servir(direccion string) *http.Server {
+	srv := &http.Server{
+		Addr:              direccion,
+		Handler:           a.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      tiempoConsulta + 5*time.Second,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("La API de consulta se detuvo", "direccion", direccion, "error", err)
+		}
+	}()
+	return srv
+}
+
+// registros devuelve RAM y procesos del rango. Con step las muestras se
+// agrupan en intervalos de ese ancho (created_at es el inicio del intervalo)
+// y se agregan con agg; sin step se devuelven tal como están guardadas.
+// Se usa la vista registros_historico, así que incluye los promedios de la
+// retención.
+func (a *API) registros(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	rango, err := leerRango(q, a.ahora())
+	if err != nil {
+		responderError(w, http.StatusBadRequest, err)
+		return
+	}
+	agg, err := leerAgregacion(q)
+	if err != nil {
+		responderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	desde, hasta := rango.Desde.UnixMilli(), rango.Hasta.UnixMilli()
+	var consulta string
+	var args []any
+	if rango.Paso == 0 {
+		consulta = `SELECT created_at, total_ram, ram_libre, total_procesos
+			FROM registros_historico WHERE created_at >= ? AND created_at < ?
+			ORDER BY created_at LIMIT ?`
+		args = []any{desde, hasta, maxFilas}
+	} else {
+		paso := rango.Paso.Milliseconds()
+		consulta = strings.ReplaceAll(`SELECT created_at / ? * ? AS created_at,
+				AGG(total_ram) AS total_ram, AGG(ram_libre) AS ram_libre, AGG(total_procesos) AS total_procesos,
+				COUNT(*) AS muestras
+			FROM registros_historico WHERE created_at >= ? AND created_at < ?
+			GROUP BY 1 ORDER BY 1 LIMIT ?`, "AGG", agg)
+		args = []any{paso, paso, desde, hasta, maxFilas}
+	}
+	a.responder(w, r, consulta, args...)
+}
+
+// Último status de cada grupo: el status de la fila con mayor created_at
+// (created_at con ceros a la izquierda se ordena igual como texto)
+const ultimoStatus = "substr(MAX(printf('%020d', created_at) || status), 21)"
+
+// containers devuelve los contenedores filtrados por status y name:
+//   - sin from, to ni step: la última muestra, ordenada por CPU
+//   - con from/to: un resumen por contenedor del rango (agg de CPU y memoria,
+//     último status y cantidad de muestras)
+//   - con step: el mismo resumen por contenedor e intervalo
+//
+// top limita la respuesta a los contenedores que más CPU usaron.
+func (a *API) containers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	top, err := leerEntero(q, "top")
+	if err != nil {
+		responderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var filtro string
+	var filtroArgs []any
+	if v := q.Get("status"); v != "" {
+		filtro += " AND status = ?"
+		filtroArgs = append(filtroArgs, v)
+	}
+	if v := q.Get("name"); v != "" {
+		filtro += " AND name = ?"
+		filtroArgs = append(filtroArgs, v)
+	}
+
+	if !q.Has("from") && !q.Has("to") && !q.Has("step") {
+		consulta := `SELECT created_at, name, cpu, memory, status FROM containers
+			WHERE created_at = (SELECT MAX(created_at) FROM containers)` + filtro + `
+			ORDER BY cpu DESC, name LIMIT ?`
+		a.responder(w, r, consulta, append(filtroArgs, limite(top))...)
+		return
+	}
+
+	rango, err := leerRango(q, a.ahora())
+	if err != nil {
+		responderError(w, http.StatusBadRequest, err)
+		return
+	}
+	agg, err := leerAgregacion(q)
+	if err != nil {
+		responderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	enRango := "created_at >= ? AND created_at < ?" + filtro
+	rangoArgs := append([]any{rango.Desde.UnixMilli(), rango.Hasta.UnixMilli()}, filtroArgs...)
+
+	var consulta string
+	var args []any
+	if rango.Paso == 0 {
+		consulta = `SELECT MAX(created_at) AS created_at, name, AGG(cpu) AS cpu, AGG(memory) AS memory,
+				` + ultimoStatus + ` AS status, COUNT(*) AS muestras
+			FROM containers_historico WHERE ` + enRango + `
+			GROUP BY name ORDER BY cpu DESC, name LIMIT ?`
+		args = append(rangoArgs, limite(top))
+	} else {
+		// Con step, top elige los contenedores con más CPU en todo el rango
+		// y se devuelven todos sus intervalos
+		paso := rango.Paso.Milliseconds()
+		consulta = `SELECT created_at / ? * ? AS created_at, name, AGG(cpu) AS cpu, AGG(memory) AS memory,
+				` + ultimoStatus + ` AS status, COUNT(*) AS muestras
+			FROM containers_historico WHERE ` + enRango
+		args = append([]any{paso, paso}, rangoArgs...)
+		if top > 0 {
+			consulta += ` AND name IN (SELECT name FROM containers_historico WHERE ` + enRango + `
+				GROUP BY name ORDER BY AGG(cpu) DESC LIMIT ?)`
+			args = append(append(args, rangoArgs...), top)
+		}
+		consulta += ` GROUP BY 1, name ORDER BY 1, cpu DESC, name LIMIT ?`
+		args = append(args, maxFilas)
+	}
+	a.responder(w, r, strings.ReplaceAll(consulta, "AGG", agg), args...)
+}
+
+// limite convierte top en el LIMIT de la consulta (0 = hasta maxFilas)
+func limite(top int) int {
+	if top == 0 || top > maxFilas {
+		return maxFilas
+	}
+	return top
+}
+
+// responder ejecuta la consulta y escribe el resultado en el formato pedido
+func (a *API) responder(w http.ResponseWriter, r *http.Request, consulta string, args ...any) {
+	ctx, cancelar := context.WithTimeout(r.Context(), tiempoConsulta)
+	defer cancelar()
+
+	t, err := leerTabla(ctx, a.db, consulta, args...)
+	if err != nil {
+		slog.Error("Error en consulta de la API", "ruta", r.URL.Path, "error", err)
+		responderError(w, http.StatusInternalServerError, fmt.Errorf("error consultando la base"))
+		return
+	}
+
+	if pideCSV(r) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		err = t.escribirCSV(w)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = t.escribirJSON(w)
+	}
+	if err != nil {
+		slog.Warn("Error escribiendo respuesta de la API", "ruta", r.URL.Path, "error", err)
+	}
+}
+
+func pideCSV(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f == "csv"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
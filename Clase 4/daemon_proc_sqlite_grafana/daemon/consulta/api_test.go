@@ -0,0 +1,237 @@
+package consulta
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"daemon/basedatos"
+)
+
+var ahora = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// hace devuelve el created_at de hace d
+func hace(d time.Duration) int64 {
+	return ahora.Add(-d).UnixMilli()
+}
+
+// servidorPrueba levanta la API sobre una base temporal con muestras de la
+// última hora y "now" fijo en ahora
+func servidorPrueba(t *testing.T) *httptest.Server {
+	t.Helper()
+	ruta := filepath.Join(t.TempDir(), "containers.db")
+	db, err := basedatos.Abrir(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := basedatos.Migrar(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sentencias := []struct {
+		consulta string
+		args     []any
+	}{
+		{"INSERT INTO registros (total_ram, ram_libre, total_procesos, created_at) VALUES (8000, 2000, 100, ?), (8000, 3000, 200, ?), (8000, 4000, 300, ?)",
+			[]any{hace(50 * time.Minute), hace(40 * time.Minute), hace(20 * time.Minute)}},
+		// Un promedio de la retención también aparece en registros_historico
+		{"INSERT INTO registros_1m (created_at, total_ram, ram_libre, total_procesos, muestras) VALUES (?, 8000, 1000, 400, 60)",
+			[]any{hace(2 * time.Hour)}},
+		{`INSERT INTO containers (name, cpu, memory, status, created_at) VALUES
+			('web', 10, 100, 'running', ?), ('db', 50, 500, 'running', ?),
+			('web', 30, 300, 'exited', ?), ('db', 40, 400, 'running', ?)`,
+			[]any{hace(50 * time.Minute), hace(50 * time.Minute), hace(20 * time.Minute), hace(20 * time.Minute)}},
+	}
+	for _, s := range sentencias {
+		if _, err := db.Exec(s.consulta, s.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lectura, err := basedatos.AbrirLectura(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lectura.Close() })
+	api := Nueva(lectura)
+	api.ahora = func() time.Time { return ahora }
+
+	srv := httptest.NewServer(api.Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func pedir(t *testing.T, srv *httptest.Server, ruta string, encabezados ...string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+ruta, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(encabezados); i += 2 {
+		req.Header.Set(encabezados[i], encabezados[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// pedirJSON hace el GET y devuelve las filas de la respuesta
+func pedirJSON(t *testing.T, srv *httptest.Server, ruta string) []map[string]any {
+	t.Helper()
+	resp := pedir(t, srv, ruta)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s: %s", ruta, resp.Status)
+	}
+	if tipo := resp.Header.Get("Content-Type"); tipo != "application/json" {
+		t.Fatalf("%s: Content-Type %q", ruta, tipo)
+	}
+	var filas []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&filas); err != nil {
+		t.Fatalf("%s: %v", ruta, err)
+	}
+	return filas
+}
+
+// columna devuelve los valores de una columna de las filas como texto
+func columna(filas []map[string]any, nombre string) string {
+	var valores []string
+	for _, f := range filas {
+		valores = append(valores, texto(f[nombre]))
+	}
+	return strings.Join(valores, ",")
+}
+
+func TestRegistros(t *testing.T) {
+	srv := servidorPrueba(t)
+	casos := []struct {
+		ruta     string
+		columna  string
+		desea    string
+		muestras string
+	}{
+		// Sin from se consulta la última hora, tal como está guardada
+		{"/api/registros", "ram_libre", "2000,3000,4000", ""},
+		{"/api/registros?from=now-3h", "ram_libre", "1000,2000,3000,4000", ""},
+		{"/api/registros?from=now-45m&to=now-10m", "total_procesos", "200,300", ""},
+		// Con step se agrupa desde el inicio de cada intervalo y se cuentan
+		// las muestras (la fila de registros_1m cuenta como una)
+		{"/api/registros?step=30m", "ram_libre", "2500,4000", "2,1"},
+		{"/api/registros?step=30m&agg=max", "total_procesos", "200,300", "2,1"},
+		{"/api/registros?step=30m&agg=min", "total_procesos", "100,300", "2,1"},
+		{"/api/registros?from=now-7d&step=1d", "ram_libre", "2500", "4"},
+		{"/api/registros?from=now-1w&to=now-1d", "ram_libre", "", ""},
+	}
+	for _, caso := range casos {
+		filas := pedirJSON(t, srv, caso.ruta)
+		if got := columna(filas, caso.columna); got != caso.desea {
+			t.Errorf("%s: %s = %s; se esperaba %s", caso.ruta, caso.columna, got, caso.desea)
+		}
+		if got := columna(filas, "muestras"); caso.muestras != "" && got != caso.muestras {
+			t.Errorf("%s: muestras = %s; se esperaba %s", caso.ruta, got, caso.muestras)
+		}
+	}
+
+	// created_at del step es el inicio del intervalo
+	filas := pedirJSON(t, srv, "/api/registros?step=30m")
+	if got := columna(filas, "created_at"); got != texto(float64(hace(time.Hour)))+","+texto(float64(hace(30*time.Minute))) {
+		t.Errorf("created_at = %s", got)
+	}
+}
+
+func TestContainers(t *testing.T) {
+	srv := servidorPrueba(t)
+	casos := []struct {
+		ruta  string
+		desea string
+	}{
+		// Sin rango: la última muestra ordenada por CPU
+		{"/api/containers", "db:40:running,web:30:exited"},
+		{"/api/containers?status=running", "db:40:running"},
+		{"/api/containers?top=1", "db:40:running"},
+		// Con rango: un resumen por contenedor con el último status
+		{"/api/containers?from=now-1h", "db:45:running,web:20:exited"},
+		{"/api/containers?from=now-1h&agg=max&name=web", "web:30:exited"},
+		// Con step, top elige por la CPU de todo el rango y devuelve todos sus intervalos
+		{"/api/containers?step=30m&top=1", "db:50:running,db:40:running"},
+		{"/api/containers?step=30m", "db:50:running,web:10:running,db:40:running,web:30:exited"},
+	}
+	for _, caso := range casos {
+		var got []string
+		for _, f := range pedirJSON(t, srv, caso.ruta) {
+			got = append(got, texto(f["name"])+":"+texto(f["cpu"])+":"+texto(f["status"]))
+		}
+		if strings.Join(got, ",") != caso.desea {
+			t.Errorf("%s: %s; se esperaba %s", caso.ruta, strings.Join(got, ","), caso.desea)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	srv := servidorPrueba(t)
+	for _, resp := range []*http.Response{
+		pedir(t, srv, "/api/registros?step=30m&format=csv"),
+		pedir(t, srv, "/api/registros?step=30m", "Accept", "text/csv"),
+	} {
+		if tipo := resp.Header.Get("Content-Type"); tipo != "text/csv; charset=utf-8" {
+			t.Fatalf("Content-Type %q", tipo)
+		}
+		registros, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		desea := [][]string{
+			{"created_at", "total_ram", "ram_libre", "total_procesos", "muestras"},
+			{texto(hace(time.Hour)), "8000", "2500", "150", "2"},
+			{texto(hace(30 * time.Minute)), "8000", "4000", "300", "1"},
+		}
+		if len(registros) != len(desea) {
+			t.Fatalf("csv %v", registros)
+		}
+		for i := range desea {
+			if strings.Join(registros[i], ",") != strings.Join(desea[i], ",") {
+				t.Errorf("fila %d: %v; se esperaba %v", i, registros[i], desea[i])
+			}
+		}
+	}
+
+	// format=json gana sobre Accept
+	if resp := pedir(t, srv, "/api/registros?format=json", "Accept", "text/csv"); resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestErrores(t *testing.T) {
+	srv := servidorPrueba(t)
+	for _, ruta := range []string{
+		"/api/registros?from=now-1x",
+		"/api/registros?step=500ms",
+		"/api/registros?agg=sum",
+		"/api/containers?top=-1",
+		"/api/containers?from=now-1h&agg=median",
+	} {
+		resp := pedir(t, srv, ruta)
+		var cuerpo map[string]string
+		json.NewDecoder(resp.Body).Decode(&cuerpo)
+		if resp.StatusCode != http.StatusBadRequest || cuerpo["error"] == "" {
+			t.Errorf("%s: %s %v", ruta, resp.Status, cuerpo)
+		}
+	}
+
+	resp, err := srv.Client().Post(srv.URL+"/api/registros", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: %s", resp.Status)
+	}
+}
@@ -0,0 +1,126 @@
+package consulta
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rango es el intervalo [Desde, Hasta) que se consulta y, si Paso no es 0,
+// el ancho de los grupos en los que se agregan las muestras
+type Rango struct {
+	Desde time.Time
+	Hasta time.Time
+	Paso  time.Duration
+}
+
+// Filas máximas por respuesta; con más conviene pedir un step mayor
+const maxFilas = 10000
+
+// leerRango interpreta from, to y step. from y to aceptan lo mismo que
+// Grafana: "now", "now-1h", "now-7d", milisegundos unix o RFC3339. Por
+// defecto se consulta la última hora.
+func leerRango(q url.Values, ahora time.Time) (Rango, error) {
+	r := Rango{Desde: ahora.Add(-time.Hour), Hasta: ahora}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if r.Desde, err = leerTiempo(v, ahora); err != nil {
+			return r, fmt.Errorf("from inválido: %v", err)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if r.Hasta, err = leerTiempo(v, ahora); err != nil {
+			return r, fmt.Errorf("to inválido: %v", err)
+		}
+	}
+	if !r.Desde.Before(r.Hasta) {
+		return r, fmt.Errorf("from debe ser anterior a to")
+	}
+
+	if v := q.Get("step"); v != "" {
+		if r.Paso, err = leerDuracion(v); err != nil {
+			return r, fmt.Errorf("step inválido: %v", err)
+		}
+		if r.Paso < time.Second {
+			return r, fmt.Errorf("step debe ser de al menos 1s")
+		}
+		if grupos := r.Hasta.Sub(r.Desde) / r.Paso; grupos > maxFilas {
+			return r, fmt.Errorf("el rango tiene %d grupos de %s, el máximo es %d", grupos, r.Paso, maxFilas)
+		}
+	}
+	return r, nil
+}
+
+func leerTiempo(v string, ahora time.Time) (time.Time, error) {
+	if v == "now" {
+		return ahora, nil
+	}
+	if resto, ok := strings.CutPrefix(v, "now-"); ok {
+		d, err := leerDuracion(resto)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return ahora.Add(-d), nil
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// Unidades de Grafana que time.ParseDuration no conoce
+var unidadesLargas = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// leerDuracion acepta las duraciones de Go ("90m", "1h30m") y, como Grafana,
+// una cantidad entera de días o semanas ("1d", "2w")
+func leerDuracion(v string) (time.Duration, error) {
+	for sufijo, unidad := range unidadesLargas {
+		if cantidad, ok := strings.CutSuffix(v, sufijo); ok {
+			n, err := strconv.Atoi(cantidad)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("duración inválida %q", v)
+			}
+			return time.Duration(n) * unidad, nil
+		}
+	}
+	return time.ParseDuration(v)
+}
+
+// Funciones de agregación permitidas (agg=); el nombre nunca se copia de la
+// url a la consulta, se usa el de este mapa
+var agregaciones = map[string]string{
+	"avg": "AVG",
+	"min": "MIN",
+	"max": "MAX",
+}
+
+func leerAgregacion(q url.Values) (string, error) {
+	v := q.Get("agg")
+	if v == "" {
+		v = "avg"
+	}
+	f, ok := agregaciones[v]
+	if !ok {
+		return "", fmt.Errorf("agg debe ser avg, min o max")
+	}
+	return f, nil
+}
+
+// leerEntero lee un parámetro entero no negativo (0 si no viene)
+func leerEntero(q url.Values, nombre string) (int, error) {
+	v := q.Get(nombre)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s debe ser un entero no negativo", nombre)
+	}
+	return n, nil
+}
@@ -0,0 +1,75 @@
+package consulta
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeerDuracion(t *testing.T) {
+	casos := map[string]time.Duration{
+		"90s":   90 * time.Second,
+		"1h30m": 90 * time.Minute,
+		"1d":    24 * time.Hour,
+		"7d":    7 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"0d":    0,
+	}
+	for v, desea := range casos {
+		if got, err := leerDuracion(v); err != nil || got != desea {
+			t.Errorf("%s: %s, %v; se esperaba %s", v, got, err, desea)
+		}
+	}
+	for _, v := range []string{"", "d", "1.5d", "-1d", "1x", "ayer"} {
+		if got, err := leerDuracion(v); err == nil {
+			t.Errorf("%q debería ser inválida: %s", v, got)
+		}
+	}
+}
+
+func TestLeerRango(t *testing.T) {
+	ahora := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+	casos := []struct {
+		consulta string
+		desde    time.Time
+		hasta    time.Time
+		paso     time.Duration
+	}{
+		{"", ahora.Add(-time.Hour), ahora, 0},
+		{"from=now-6h&step=5m", ahora.Add(-6 * time.Hour), ahora, 5 * time.Minute},
+		{"from=now-1d&step=1h", ahora.Add(-24 * time.Hour), ahora, time.Hour},
+		{"from=now-7d&to=now-1d&step=1d", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC), 24 * time.Hour},
+		{"from=now-2w", ahora.Add(-14 * 24 * time.Hour), ahora, 0},
+		{"from=1704700800000&to=2024-01-08T10:00:00Z", time.UnixMilli(1704700800000), time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC), 0},
+	}
+	for _, caso := range casos {
+		q, _ := url.ParseQuery(caso.consulta)
+		r, err := leerRango(q, ahora)
+		if err != nil {
+			t.Errorf("%q: %v", caso.consulta, err)
+			continue
+		}
+		if !r.Desde.Equal(caso.desde) || !r.Hasta.Equal(caso.hasta) || r.Paso != caso.paso {
+			t.Errorf("%q: %+v; se esperaba desde %s hasta %s con paso %s", caso.consulta, r, caso.desde, caso.hasta, caso.paso)
+		}
+	}
+}
+
+func TestLeerRangoInvalido(t *testing.T) {
+	casos := map[string]string{
+		"from=now-1x":            "from inválido",
+		"to=ayer":                "to inválido",
+		"from=now&to=now-1h":     "from debe ser anterior a to",
+		"step=500ms":             "al menos 1s",
+		"step=1d2h":              "step inválido",
+		"from=now-7d&step=1s":    "el máximo es 10000",
+		"from=now-1w&step=cinco": "step inválido",
+	}
+	for consulta, desea := range casos {
+		q, _ := url.ParseQuery(consulta)
+		if _, err := leerRango(q, time.Now()); err == nil || !strings.Contains(err.Error(), desea) {
+			t.Errorf("%q: %v; se esperaba un error con %q", consulta, err, desea)
+		}
+	}
+}
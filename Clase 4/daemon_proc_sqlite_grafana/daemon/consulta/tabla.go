@@ -0,0 +1,123 @@
+package consulta
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// tabla es el resultado de una consulta con las columnas en el orden del SELECT
+type tabla struct {
+	columnas []string
+	filas    [][]any
+}
+
+func leerTabla(ctx context.Context, db *sql.DB, consulta string, args ...any) (*tabla, error) {
+	filas, err := db.QueryContext(ctx, consulta, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer filas.Close()
+
+	columnas, err := filas.Columns()
+	if err != nil {
+		return nil, err
+	}
+	t := &tabla{columnas: columnas, filas: [][]any{}}
+	for filas.Next() {
+		valores := make([]any, len(columnas))
+		punteros := make([]any, len(columnas))
+		for i := range valores {
+			punteros[i] = &valores[i]
+		}
+		if err := filas.Scan(punteros...); err != nil {
+			return nil, err
+		}
+		// go-sqlite3 devuelve los TEXT calculados como []byte
+		for i, v := range valores {
+			if b, ok := v.([]byte); ok {
+				valores[i] = string(b)
+			}
+		}
+		t.filas = append(t.filas, valores)
+	}
+	return t, filas.Err()
+}
+
+// escribirJSON escribe un arreglo con un objeto por fila, con las claves en el
+// orden de las columnas
+func (t *tabla) escribirJSON(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	b.WriteByte('[')
+	for i, fila := range t.filas {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString("\n  {")
+		for j, v := range fila {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			clave, _ := json.Marshal(t.columnas[j])
+			valor, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			b.Write(clave)
+			b.WriteByte(':')
+			b.Write(valor)
+		}
+		b.WriteByte('}')
+	}
+	if len(t.filas) > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString("]\n")
+	return b.Flush()
+}
+
+// escribirCSV escribe la fila de encabezado con los nombres de las columnas
+func (t *tabla) escribirCSV(w io.Writer) error {
+	c := csv.NewWriter(w)
+	if err := c.Write(t.columnas); err != nil {
+		return err
+	}
+	registro := make([]string, len(t.columnas))
+	for _, fila := range t.filas {
+		for i, v := range fila {
+			registro[i] = texto(v)
+		}
+		if err := c.Write(registro); err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+func texto(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case string:
+		return x
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+func responderError(w http.ResponseWriter, estado int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(estado)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
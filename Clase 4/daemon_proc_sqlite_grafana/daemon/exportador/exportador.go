@@ -0,0 +1,226 @@
+// Package exportador publica en /metrics (formato de texto de Prometheus) la
+// última muestra de cada métrica y el estado del daemon, para poder poner
+// alertas sobre los mismos datos que se guardan en SQLite.
+package exportador
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"daemon/almacen"
+	"daemon/recolector"
+)
+
+// Exportador guarda la última muestra de cada métrica. Implementa
+// almacen.MetricStore, así que recibe las muestras como cualquier otro destino.
+type Exportador struct {
+	// MaxProcesos limita las series por proceso: se exportan los que más CPU usan
+	// (y a igual CPU los de más memoria). El resto se cuenta en sopes1_procesos_omitidos.
+	MaxProcesos int
+
+	mu       sync.Mutex
+	totales  *recolector.Totales
+	procesos []recolector.Proceso
+	omitidos int
+	lecturas map[string]time.Time
+	erroresL map[string]int64
+	erroresI map[string]int64
+	destinos []almacen.MetricStore
+	iniciado time.Time
+}
+
+// Nuevo crea el exportador; destinos son los almacenes cuyos errores se exponen
+func Nuevo(maxProcesos int, destinos ...almacen.MetricStore) *Exportador {
+	e := &Exportador{
+		MaxProcesos: maxProcesos,
+		lecturas:    make(map[string]time.Time),
+		erroresL:    make(map[string]int64),
+		erroresI:    make(map[string]int64),
+		destinos:    destinos,
+		iniciado:    time.Now(),
+	}
+
+	// Los contadores empiezan en 0 para que rate() e increase() funcionen desde el primer scrape
+	for _, m := range []string{"totales", "procesos", "contenedores"} {
+		e.erroresL[m] = 0
+	}
+	for _, d := range destinos {
+		e.erroresI[d.Nombre()] = 0
+	}
+	return e
+}
+
+func (e *Exportador) Nombre() string {
+	return "exportador"
+}
+
+// Guardar reemplaza los valores de la métrica que trae el tick
+func (e *Exportador) Guardar(m almacen.Muestras) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if m.Totales != nil {
+		t := *m.Totales
+		e.totales = &t
+	}
+	if m.Procesos != nil {
+		e.procesos, e.omitidos = almacen.RecortarProcesos(m.Procesos, e.MaxProcesos)
+	}
+	return nil
+}
+
+func (e *Exportador) Close() error {
+	return nil
+}
+
+// LecturaExitosa anota la hora de la última lectura correcta de la métrica
+func (e *Exportador) LecturaExitosa(metrica string, t time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lecturas[metrica] = t
+}
+
+// ErrorLectura cuenta una lectura fallida (por ejemplo /proc/sysinfo no existe)
+func (e *Exportador) ErrorLectura(metrica string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.erroresL[metrica]++
+}
+
+// ErrorInsercion cuenta un Guardar que falló en el destino
+func (e *Exportador) ErrorInsercion(destino string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.erroresI[destino]++
+}
+
+// ServeHTTP responde /metrics
+func (e *Exportador) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.Escribir(w)
+}
+
+// Escribir genera las métricas en el formato de texto de Prometheus
+func (e *Exportador) Escribir(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var sb strings.Builder
+	if t := e.totales; t != nil {
+		metrica(&sb, "sopes1_ram_total", "gauge", "RAM total reportada por el módulo de kernel.")
+		muestra(&sb, "sopes1_ram_total", nil, float64(t.TotalRAM))
+		metrica(&sb, "sopes1_ram_libre", "gauge", "RAM libre reportada por el módulo de kernel.")
+		muestra(&sb, "sopes1_ram_libre", nil, float64(t.RAMLibre))
+		metrica(&sb, "sopes1_procesos_total", "gauge", "Cantidad de procesos reportada por el módulo de kernel.")
+		muestra(&sb, "sopes1_procesos_total", nil, float64(t.Procesos))
+	}
+
+	if e.procesos != nil {
+		metrica(&sb, "sopes1_proceso_cpu_porcentaje", "gauge", "Porcentaje de un núcleo usado por el proceso desde la muestra anterior.")
+		for _, p := range e.procesos {
+			muestra(&sb, "sopes1_proceso_cpu_porcentaje", etiquetasProceso(p), p.CPU)
+		}
+		metrica(&sb, "sopes1_proceso_rss_kb", "gauge", "Memoria residente del proceso en KB.")
+		for _, p := range e.procesos {
+			muestra(&sb, "sopes1_proceso_rss_kb", etiquetasProceso(p), float64(p.RSSKB))
+		}
+		metrica(&sb, "sopes1_procesos_omitidos", "gauge", "Procesos que no se exportan por el límite de series.")
+		muestra(&sb, "sopes1_procesos_omitidos", nil, float64(e.omitidos))
+	}
+
+	metrica(&sb, "sopes1_daemon_ultima_lectura_exitosa_segundos", "gauge", "Hora (unix) de la última lectura correcta de cada métrica.")
+	for _, m := range ordenar(e.lecturas) {
+		muestra(&sb, "sopes1_daemon_ultima_lectura_exitosa_segundos", []string{"metrica", m}, float64(e.lecturas[m].Unix()))
+	}
+	metrica(&sb, "sopes1_daemon_errores_lectura_total", "counter", "Lecturas fallidas de cada métrica.")
+	for _, m := range ordenar(e.erroresL) {
+		muestra(&sb, "sopes1_daemon_errores_lectura_total", []string{"metrica", m}, float64(e.erroresL[m]))
+	}
+
+	// Errores de inserción: los que devuelve Guardar más los de los destinos que escriben en segundo plano
+	insercion := make(map[string]int64)
+	descartados := make(map[string]int64)
+	for d, n := range e.erroresI {
+		insercion[d] += n
+	}
+	for _, d := range e.destinos {
+		if c, ok := d.(almacen.ConErrores); ok {
+			insercion[d.Nombre()] += c.Fallidos()
+			descartados[d.Nombre()] += c.Descartados()
+		}
+	}
+	metrica(&sb, "sopes1_daemon_errores_insercion_total", "counter", "Muestras que no se pudieron guardar en cada destino.")
+	for _, d := range ordenar(insercion) {
+		muestra(&sb, "sopes1_daemon_errores_insercion_total", []string{"destino", d}, float64(insercion[d]))
+	}
+	metrica(&sb, "sopes1_daemon_muestras_descartadas_total", "counter", "Ticks descartados porque la cola de escritura del destino estaba llena.")
+	for _, d := range ordenar(descartados) {
+		muestra(&sb, "sopes1_daemon_muestras_descartadas_total", []string{"destino", d}, float64(descartados[d]))
+	}
+
+	metrica(&sb, "sopes1_daemon_inicio_segundos", "gauge", "Hora (unix) en la que arrancó el daemon.")
+	muestra(&sb, "sopes1_daemon_inicio_segundos", nil, float64(e.iniciado.Unix()))
+
+	io.WriteString(w, sb.String())
+}
+
+func etiquetasProceso(p recolector.Proceso) []string {
+	return []string{"pid", strconv.Itoa(p.PID), "nombre", p.Nombre}
+}
+
+func metrica(sb *strings.Builder, nombre, tipo, ayuda string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", nombre, ayuda, nombre, tipo)
+}
+
+// muestra escribe "nombre{k="v",...} valor"; etiquetas son pares nombre, valor
+func muestra(sb *strings.Builder, nombre string, etiquetas []string, valor float64) {
+	sb.WriteString(nombre)
+	if len(etiquetas) > 0 {
+		sb.WriteByte('{')
+		for i := 0; i+1 < len(etiquetas); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(sb, "%s=\"%s\"", etiquetas[i], escapar(etiquetas[i+1]))
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.FormatFloat(valor, 'g', -1, 64))
+	sb.WriteByte('\n')
+}
+
+func escapar(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func ordenar[V any](m map[string]V) []string {
+	claves := make([]string, 0, len(m))
+	for k := range m {
+		claves = append(claves, k)
+	}
+	sort.Strings(claves)
+	return claves
+}
+
+// Servir atiende /metrics en la dirección indicada (por ejemplo ":9101")
+// hasta que se llame Shutdown en el servidor devuelto
+func (e *Exportador) Servir(direccion string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	srv := &http.Server{Addr: direccion, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("El exportador de métricas se detuvo", "direccion", direccion, "error", err)
+		}
+	}()
+	return srv
+}
@@ -0,0 +1,30 @@
+#!/bin/bash
+# Script que ejecuta el cronjob instalado por el daemon.
+# Crea algunos contenedores con carga de CPU y RAM para que el daemon
+# tenga información que leer en /proc. Los contenedores se eliminan solos
+# al terminar (--rm) y se etiquetan para poder identificarlos.
+
+IMAGEN="alpine"
+ETIQUETA="sopes1=generador"
+CANTIDAD=$(( (RANDOM % 3) + 1 ))
+
+echo "$(date '+%Y-%m-%d %H:%M:%S') Generando $CANTIDAD contenedor(es)"
+
+for i in $(seq 1 "$CANTIDAD"); do
+    case $(( RANDOM % 3 )) in
+        0)
+            # Consumo de CPU durante 45 segundos
+            docker run -d --rm --label "$ETIQUETA" "$IMAGEN" \
+                sh -c "timeout 45 sh -c 'while :; do :; done'"
+            ;;
+        1)
+            # Consumo de RAM (aprox. 60MB en /dev/shm) durante 45 segundos
+            docker run -d --rm --label "$ETIQUETA" "$IMAGEN" \
+                sh -c "dd if=/dev/zero of=/dev/shm/carga bs=1M count=60 2>/dev/null && sleep 45"
+            ;;
+        *)
+            # Contenedor con poca carga
+            docker run -d --rm --label "$ETIQUETA" "$IMAGEN" sleep 45
+            ;;
+    esac
+done
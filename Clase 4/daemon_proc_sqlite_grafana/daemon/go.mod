@@ -0,0 +1,12 @@
+module daemon
+
+go 1.25.5
+
+require (
+	cronjob v0.0.0
+	github.com/klauspost/compress v1.15.9
+	github.com/mattn/go-sqlite3 v1.14.32
+	google.golang.org/protobuf v1.36.10
+)
+
+replace cronjob => "../../../Clase 3/cronjob"
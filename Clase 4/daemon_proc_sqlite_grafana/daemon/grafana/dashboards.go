@@ -0,0 +1,226 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"daemon/basedatos"
+)
+
+// panel describe un panel y la consulta SQL que necesita. La consulta usa %[1]s
+// en lugar del nombre de la tabla: se usa la primera de Tablas que exista en el
+// esquema con todas las Columnas (las vistas *_historico si ya se migró la
+// retención, si no las tablas crudas). Si ninguna sirve el panel se omite.
+type panel struct {
+	Titulo   string
+	Tipo     string
+	Unidad   string
+	Tablas   []string
+	Columnas []string
+	Consulta string
+	Ancho    int
+	Alto     int
+}
+
+// dashboard es un archivo de dashboards/
+type dashboard struct {
+	UID     string
+	Titulo  string
+	Archivo string
+	Paneles []panel
+}
+
+// Filtro de rango con las variables globales de Grafana ($__from y $__to están
+// en milisegundos, igual que created_at). El plugin espera la columna de
+// tiempo en segundos, por eso se divide entre 1000.
+const rango = "created_at >= $__from AND created_at < $__to"
+
+var dashboards = []dashboard{
+	{
+		UID:     "sopes1-sistema",
+		Titulo:  "Daemon - Sistema",
+		Archivo: "sistema.json",
+		Paneles: []panel{
+			{
+				Titulo:   "RAM usada vs libre",
+				Tipo:     "timeseries",
+				Tablas:   []string{"registros_historico", "registros"},
+				Columnas: []string{"created_at", "total_ram", "ram_libre"},
+				Consulta: "SELECT created_at / 1000 AS time, total_ram - ram_libre AS ram_usada, ram_libre FROM %[1]s WHERE " + rango + " ORDER BY created_at",
+				Ancho:    12, Alto: 8,
+			},
+			{
+				Titulo:   "Cantidad de procesos",
+				Tipo:     "timeseries",
+				Tablas:   []string{"registros_historico", "registros"},
+				Columnas: []string{"created_at", "total_procesos"},
+				Consulta: "SELECT created_at / 1000 AS time, total_procesos FROM %[1]s WHERE " + rango + " ORDER BY created_at",
+				Ancho:    12, Alto: 8,
+			},
+			{
+				Titulo:   "Top 10 procesos por CPU (última muestra)",
+				Tipo:     "table",
+				Tablas:   []string{"procesos"},
+				Columnas: []string{"created_at", "pid", "nombre", "estado", "cpu", "rss_kb"},
+				Consulta: "SELECT pid, nombre, estado, ROUND(cpu, 2) AS cpu, rss_kb FROM %[1]s WHERE created_at = (SELECT MAX(created_at) FROM %[1]s) ORDER BY cpu DESC, rss_kb DESC LIMIT 10",
+				Ancho:    24, Alto: 10,
+			},
+			{
+				Titulo:   "Anomalías de RAM y procesos",
+				Tipo:     "table",
+				Tablas:   []string{"anomalias"},
+				Columnas: []string{"created_at", "serie", "valor", "esperado", "puntaje", "metodo"},
+				Consulta: "SELECT datetime(created_at / 1000, 'unixepoch', 'localtime') AS hora, serie, ROUND(valor, 0) AS valor, ROUND(esperado, 0) AS esperado, ROUND(puntaje, 1) AS puntaje, metodo FROM %[1]s WHERE " + rango + " ORDER BY created_at DESC LIMIT 50",
+				Ancho:    24, Alto: 8,
+			},
+		},
+	},
+	{
+		UID:     "sopes1-contenedores",
+		Titulo:  "Daemon - Contenedores",
+		Archivo: "contenedores.json",
+		Paneles: []panel{
+			{
+				Titulo:   "CPU de contenedores por estado",
+				Tipo:     "timeseries",
+				Unidad:   "percent",
+				Tablas:   []string{"containers_historico", "containers"},
+				Columnas: []string{"created_at", "status", "cpu"},
+				Consulta: "SELECT created_at / 1000 AS time, status, SUM(cpu) AS cpu FROM %[1]s WHERE " + rango + " GROUP BY created_at, status ORDER BY created_at",
+				Ancho:    12, Alto: 8,
+			},
+			{
+				Titulo:   "Memoria de contenedores por estado",
+				Tipo:     "timeseries",
+				Unidad:   "decmbytes",
+				Tablas:   []string{"containers_historico", "containers"},
+				Columnas: []string{"created_at", "status", "memory"},
+				Consulta: "SELECT created_at / 1000 AS time, status, SUM(memory) AS memory FROM %[1]s WHERE " + rango + " GROUP BY created_at, status ORDER BY created_at",
+				Ancho:    12, Alto: 8,
+			},
+			{
+				Titulo:   "Contenedores (última muestra)",
+				Tipo:     "table",
+				Tablas:   []string{"containers"},
+				Columnas: []string{"created_at", "name", "cpu", "memory", "status"},
+				Consulta: "SELECT name, status, ROUND(cpu, 2) AS cpu, ROUND(memory, 2) AS memory FROM %[1]s WHERE created_at = (SELECT MAX(created_at) FROM %[1]s) ORDER BY cpu DESC",
+				Ancho:    24, Alto: 10,
+			},
+		},
+	},
+}
+
+// GenerarDashboards escribe un JSON por dashboard en DirDashboards con las
+// consultas que admite el esquema actual. Se llama después de las migraciones:
+// un archivo solo se reescribe si cambió, y los .json que ya no corresponden
+// a ningún dashboard se borran.
+func (a *Aprovisionador) GenerarDashboards(esquema basedatos.Esquema, version int) error {
+	dir := a.DirDashboards()
+	generados := make(map[string]bool)
+
+	for _, d := range dashboards {
+		contenido, err := d.json(esquema, version)
+		if err != nil {
+			return err
+		}
+		destino := filepath.Join(dir, d.Archivo)
+		if err := escribirSiCambio(destino, contenido); err != nil {
+			return err
+		}
+		generados[d.Archivo] = true
+	}
+
+	viejos, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, v := range viejos {
+		if !generados[filepath.Base(v)] {
+			os.Remove(v)
+		}
+	}
+	return nil
+}
+
+func (d dashboard) json(esquema basedatos.Esquema, version int) (string, error) {
+	fuente := map[string]string{"type": "frser-sqlite-datasource", "uid": UIDDatasource}
+
+	paneles := []map[string]any{}
+	x, y, altoFila := 0, 0, 0
+	for _, p := range d.Paneles {
+		tabla := p.tabla(esquema)
+		if tabla == "" {
+			slog.Warn("Panel omitido: el esquema no tiene las columnas necesarias", "dashboard", d.Titulo, "panel", p.Titulo)
+			continue
+		}
+
+		// Los paneles se acomodan de izquierda a derecha en la grilla de 24 columnas
+		if x+p.Ancho > 24 {
+			x, y, altoFila = 0, y+altoFila, 0
+		}
+		consulta := fmt.Sprintf(p.Consulta, tabla)
+		objetivo := map[string]any{
+			"refId":        "A",
+			"datasource":   fuente,
+			"queryText":    consulta,
+			"rawQueryText": consulta,
+		}
+		if p.Tipo == "timeseries" {
+			objetivo["queryType"] = "time series"
+			objetivo["timeColumns"] = []string{"time"}
+		} else {
+			objetivo["queryType"] = "table"
+		}
+
+		panel := map[string]any{
+			"id":         len(paneles) + 1,
+			"type":       p.Tipo,
+			"title":      p.Titulo,
+			"datasource": fuente,
+			"gridPos":    map[string]int{"x": x, "y": y, "w": p.Ancho, "h": p.Alto},
+			"targets":    []any{objetivo},
+		}
+		if p.Unidad != "" {
+			panel["fieldConfig"] = map[string]any{"defaults": map[string]string{"unit": p.Unidad}, "overrides": []any{}}
+		}
+		paneles = append(paneles, panel)
+
+		x += p.Ancho
+		altoFila = max(altoFila, p.Alto)
+	}
+
+	b, err := json.MarshalIndent(map[string]any{
+		"uid":           d.UID,
+		"title":         d.Titulo,
+		"tags":          []string{"sopes1", fmt.Sprintf("esquema-v%d", version)},
+		"timezone":      "browser",
+		"refresh":       "10s",
+		"time":          map[string]string{"from": "now-1h", "to": "now"},
+		"schemaVersion": 39,
+		"editable":      false,
+		"panels":        paneles,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error generando dashboard %s: %v", d.Titulo, err)
+	}
+	return string(b) + "\n", nil
+}
+
+// tabla devuelve la primera tabla candidata que exista con las columnas del panel
+func (p panel) tabla(esquema basedatos.Esquema) string {
+	for _, t := range p.Tablas {
+		if esquema.Tiene(t, p.Columnas...) {
+			return t
+		}
+	}
+	return ""
+}
+
+// escribirSiCambio evita reescribir el archivo (y que Grafana recargue el
+// dashboard) cuando el contenido es el mismo
+func escribirSiCambio(destino, contenido string) error {
+	if actual, err := os.ReadFile(destino); err == nil && string(actual) == contenido {
+		return nil
+	}
+	return escribir(destino, contenido)
+}
@@ -0,0 +1,129 @@
+package grafana
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"daemon/basedatos"
+)
+
+// esquemaCrudo tiene solo las tablas sin la retención migrada
+func esquemaCrudo() basedatos.Esquema {
+	return basedatos.Esquema{
+		"registros":  {"id", "created_at", "total_ram", "ram_libre", "total_procesos"},
+		"procesos":   {"id", "created_at", "pid", "nombre", "estado", "cpu", "rss_kb"},
+		"containers": {"id", "created_at", "name", "cpu", "memory", "status"},
+	}
+}
+
+// dashboardGenerado es la parte del JSON que revisan las pruebas
+type dashboardGenerado struct {
+	UID    string   `json:"uid"`
+	Tags   []string `json:"tags"`
+	Panels []struct {
+		Title   string         `json:"title"`
+		GridPos map[string]int `json:"gridPos"`
+		Targets []struct {
+			QueryText string `json:"queryText"`
+			QueryType string `json:"queryType"`
+		} `json:"targets"`
+	} `json:"panels"`
+}
+
+func leerDashboard(t *testing.T, a *Aprovisionador, archivo string) dashboardGenerado {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(a.DirDashboards(), archivo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d dashboardGenerado
+	if err := json.Unmarshal(b, &d); err != nil {
+		t.Fatalf("%s no es JSON válido: %v", archivo, err)
+	}
+	return d
+}
+
+func TestGenerarDashboardsSegunEsquema(t *testing.T) {
+	a := Nuevo(t.TempDir(), "containers.db")
+	if err := a.GenerarDashboards(esquemaCrudo(), 1); err != nil {
+		t.Fatal(err)
+	}
+	sistema := leerDashboard(t, a, "sistema.json")
+	if sistema.UID != "sopes1-sistema" || sistema.Tags[1] != "esquema-v1" {
+		t.Fatalf("uid %q, tags %v", sistema.UID, sistema.Tags)
+	}
+	// Sin la tabla de anomalías ese panel se omite
+	if len(sistema.Panels) != 3 {
+		t.Fatalf("%d paneles; se esperaban 3", len(sistema.Panels))
+	}
+	ram := sistema.Panels[0].Targets[0]
+	if !strings.Contains(ram.QueryText, "FROM registros WHERE") || ram.QueryType != "time series" {
+		t.Fatalf("consulta de RAM: %+v", ram)
+	}
+	if top := sistema.Panels[2]; top.Targets[0].QueryType != "table" || top.GridPos["y"] != 8 || top.GridPos["w"] != 24 {
+		t.Fatalf("el top de procesos debería ir en su propia fila: %+v", top)
+	}
+
+	// Con la retención migrada se usan las vistas *_historico
+	esquema := esquemaCrudo()
+	esquema["registros_historico"] = esquema["registros"]
+	esquema["containers_historico"] = esquema["containers"]
+	esquema["anomalias"] = []string{"created_at", "serie", "valor", "esperado", "puntaje", "metodo"}
+	if err := a.GenerarDashboards(esquema, 3); err != nil {
+		t.Fatal(err)
+	}
+	sistema = leerDashboard(t, a, "sistema.json")
+	if len(sistema.Panels) != 4 || !strings.Contains(sistema.Panels[0].Targets[0].QueryText, "FROM registros_historico WHERE") {
+		t.Fatalf("paneles con la retención: %+v", sistema.Panels)
+	}
+	contenedores := leerDashboard(t, a, "contenedores.json")
+	if !strings.Contains(contenedores.Panels[0].Targets[0].QueryText, "FROM containers_historico") {
+		t.Fatalf("CPU de contenedores: %+v", contenedores.Panels[0])
+	}
+	// La última muestra siempre sale de la tabla cruda
+	if !strings.Contains(contenedores.Panels[2].Targets[0].QueryText, "FROM containers WHERE created_at = (SELECT MAX(created_at) FROM containers)") {
+		t.Fatalf("última muestra: %+v", contenedores.Panels[2])
+	}
+}
+
+func TestGenerarDashboardsSoloSiCambia(t *testing.T) {
+	a := Nuevo(t.TempDir(), "containers.db")
+	if err := a.GenerarDashboards(esquemaCrudo(), 1); err != nil {
+		t.Fatal(err)
+	}
+	ruta := filepath.Join(a.DirDashboards(), "sistema.json")
+	viejo := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(ruta, viejo, viejo); err != nil {
+		t.Fatal(err)
+	}
+	// Un JSON que ya no corresponde a ningún dashboard se borra
+	sobrante := filepath.Join(a.DirDashboards(), "viejo.json")
+	if err := os.WriteFile(sobrante, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.GenerarDashboards(esquemaCrudo(), 1); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(viejo) {
+		t.Error("sin cambios en el esquema el dashboard no debería reescribirse")
+	}
+	if _, err := os.Stat(sobrante); !os.IsNotExist(err) {
+		t.Errorf("viejo.json debería borrarse: %v", err)
+	}
+
+	if err := a.GenerarDashboards(esquemaCrudo(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(ruta); info.ModTime().Equal(viejo) {
+		t.Error("con otra versión del esquema el dashboard debería reescribirse")
+	}
+}
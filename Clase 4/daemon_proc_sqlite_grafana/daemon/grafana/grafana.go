@@ -0,0 +1,262 @@
+// Package grafana levanta el Grafana del daemon con docker compose.
+//
+// El docker-compose.yaml y el datasource se generan desde las plantillas de
+// grafana/plantillas con las rutas reales del daemon (la carpeta de
+// containers.db), así no dependen del directorio desde el que se ejecute ni de
+// rutas de un equipo en particular. Los dashboards se generan a partir de las
+// tablas que existen en la base.
+package grafana
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed plantillas/*.tmpl
+var plantillas embed.FS
+
+// Ejecutor corre docker compose con los argumentos dados; se puede reemplazar
+// para probar sin Docker
+type Ejecutor func(args ...string) (string, error)
+
+// DockerCompose usa el plugin "docker compose" y, si no está, el binario docker-compose
+func DockerCompose(args ...string) (string, error) {
+	comando := []string{"docker", "compose"}
+	if exec.Command("docker", "compose", "version").Run() != nil {
+		if _, err := exec.LookPath("docker-compose"); err == nil {
+			comando = []string{"docker-compose"}
+		}
+	}
+	output, err := exec.Command(comando[0], append(comando[1:], args...)...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+// Aprovisionador genera los archivos de Grafana y administra el contenedor
+type Aprovisionador struct {
+	// Dir es donde se escriben docker-compose.yaml, provisioning/ y dashboards/
+	Dir string
+	// RutaDB es la ruta absoluta de containers.db en el host. Su carpeta se
+	// monta completa y con escritura en el contenedor, así que solo debe tener
+	// la base y sus archivos -wal y -shm.
+	RutaDB   string
+	Proyecto string
+	Imagen   string
+	Puerto   int
+	Usuario  string
+	Clave    string
+
+	Compose Ejecutor
+	Cliente *http.Client
+}
+
+// Nuevo crea un aprovisionador con los valores por defecto
+func Nuevo(dir, rutaDB string) *Aprovisionador {
+	return &Aprovisionador{
+		Dir:      dir,
+		RutaDB:   rutaDB,
+		Proyecto: "sopes1-daemon",
+		Imagen:   "grafana/grafana:11.0.0",
+		Puerto:   3000,
+		Usuario:  "admin",
+		Clave:    "admin",
+		Compose:  DockerCompose,
+		Cliente:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// UID fijo del datasource para que los dashboards lo puedan referenciar
+const UIDDatasource = "sopes1-sqlite"
+
+// Usuario y grupo con los que corre la imagen oficial de Grafana
+const (
+	UIDGrafana = 472
+	GIDGrafana = 0
+)
+
+// datos son los valores que usan las plantillas
+type datos struct {
+	*Aprovisionador
+	DirDB           string
+	ArchivoDB       string
+	DirProvisioning string
+	DirDashboards   string
+	UIDDatasource   string
+	// UsuarioContenedor es el "uid:gid" con el que corre Grafana, el dueño
+	// de la carpeta de datos (ver compartirDatos)
+	UsuarioContenedor string
+}
+
+// geteuid se reemplaza en las pruebas para generar como otro usuario
+var geteuid = os.Geteuid
+
+// RutaCompose devuelve la ruta del docker-compose.yaml generado
+func (a *Aprovisionador) RutaCompose() string {
+	return filepath.Join(a.Dir, "docker-compose.yaml")
+}
+
+// DirDashboards devuelve la carpeta de los dashboards que carga Grafana
+func (a *Aprovisionador) DirDashboards() string {
+	return filepath.Join(a.Dir, "dashboards")
+}
+
+// Generar escribe el docker-compose.yaml y el provisioning a partir de las
+// plantillas. Los dashboards se generan aparte, desde el esquema de la base
+// (ver GenerarDashboards).
+func (a *Aprovisionador) Generar() error {
+	rutaDB, err := filepath.Abs(a.RutaDB)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(a.Dir)
+	if err != nil {
+		return err
+	}
+
+	d := datos{
+		Aprovisionador:  a,
+		DirDB:           filepath.Dir(rutaDB),
+		ArchivoDB:       filepath.Base(rutaDB),
+		DirProvisioning: filepath.Join(dir, "provisioning"),
+		DirDashboards:   filepath.Join(dir, "dashboards"),
+		UIDDatasource:   UIDDatasource,
+	}
+	d.UsuarioContenedor = compartirDatos(d.DirDB, rutaDB)
+
+	archivos := map[string]string{
+		"docker-compose.yaml.tmpl": a.RutaCompose(),
+		"datasource.yaml.tmpl":     filepath.Join(dir, "provisioning", "datasources", "sqlite.yaml"),
+		"dashboards.yaml.tmpl":     filepath.Join(dir, "provisioning", "dashboards", "daemon.yaml"),
+	}
+	for plantilla, destino := range archivos {
+		if err := renderizar(plantilla, destino, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderizar(plantilla, destino string, d datos) error {
+	t, err := template.New(plantilla).Funcs(template.FuncMap{"citar": citar}).ParseFS(plantillas, "plantillas/"+plantilla)
+	if err != nil {
+		return fmt.Errorf("error leyendo plantilla %s: %v", plantilla, err)
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, d); err != nil {
+		return fmt.Errorf("error generando %s: %v", destino, err)
+	}
+	return escribir(destino, sb.String())
+}
+
+// compartirDatos deja que Grafana escriba en la carpeta de datos y devuelve el
+// "uid:gid" con el que debe correr el contenedor. En modo WAL Grafana tiene que
+// poder escribir en la carpeta para abrir containers.db-shm. Como root, la
+// carpeta y la base se le asignan al usuario de la imagen de Grafana; SQLite,
+// si corre como root, crea los -wal y -shm siguientes con el mismo dueño que la
+// base. Si no, el daemon no puede cambiar dueños y el contenedor corre con su
+// mismo usuario, que ya es el dueño de la carpeta (la imagen deja
+// /var/lib/grafana con escritura para cualquier usuario).
+func compartirDatos(dir, rutaDB string) string {
+	if euid := geteuid(); euid != 0 {
+		return fmt.Sprintf("%d:%d", euid, os.Getegid())
+	}
+	for _, ruta := range []string{dir, rutaDB, rutaDB + "-wal", rutaDB + "-shm"} {
+		if err := os.Chown(ruta, UIDGrafana, GIDGrafana); err != nil && !os.IsNotExist(err) {
+			log.Printf("No se pudo asignar %s a Grafana: %v", ruta, err)
+		}
+	}
+	return fmt.Sprintf("%d:%d", UIDGrafana, GIDGrafana)
+}
+
+// escribir crea las carpetas y reemplaza el archivo de forma atómica, para que
+// Grafana nunca lea un dashboard a medio escribir
+func escribir(destino, contenido string) error {
+	if err := os.MkdirAll(filepath.Dir(destino), 0755); err != nil {
+		return fmt.Errorf("error creando %s: %v", filepath.Dir(destino), err)
+	}
+	temporal := destino + ".tmp"
+	if err := os.WriteFile(temporal, []byte(contenido), 0644); err != nil {
+		return fmt.Errorf("error escribiendo %s: %v", destino, err)
+	}
+	return os.Rename(temporal, destino)
+}
+
+// citar escribe el valor como string de YAML (un string de JSON es YAML válido);
+// hace falta para rutas con espacios como "Clase 4"
+func citar(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Levantar ejecuta "docker compose up -d" con el archivo generado
+func (a *Aprovisionador) Levantar() error {
+	output, err := a.Compose("-p", a.Proyecto, "-f", a.RutaCompose(), "up", "-d")
+	if err != nil {
+		return fmt.Errorf("docker compose up: %v: %s", err, output)
+	}
+	return nil
+}
+
+// Bajar detiene el contenedor; el volumen con los datos de Grafana se conserva
+func (a *Aprovisionador) Bajar() error {
+	output, err := a.Compose("-p", a.Proyecto, "-f", a.RutaCompose(), "down")
+	if err != nil {
+		return fmt.Errorf("docker compose down: %v: %s", err, output)
+	}
+	return nil
+}
+
+// EsperarSalud consulta /api/health hasta que Grafana responda con la base
+// lista, con hasta intentos consultas separadas por espera
+func (a *Aprovisionador) EsperarSalud(ctx context.Context, intentos int, espera time.Duration) error {
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/health", a.Puerto)
+
+	var ultimo error
+	for i := 1; i <= intentos; i++ {
+		if ultimo = a.salud(ctx, url); ultimo == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(espera):
+		}
+	}
+	return fmt.Errorf("grafana no respondió después de %d intentos: %v", intentos, ultimo)
+}
+
+func (a *Aprovisionador) salud(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.Cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var estado struct {
+		Database string `json:"database"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("respondió %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&estado); err != nil {
+		return err
+	}
+	if estado.Database != "ok" {
+		return fmt.Errorf("base de datos de grafana: %q", estado.Database)
+	}
+	return nil
+}
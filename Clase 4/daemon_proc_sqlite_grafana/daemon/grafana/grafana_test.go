@@ -0,0 +1,190 @@
+package grafana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// generar escribe los archivos en una carpeta temporal como si el daemon
+// corriera con el uid dado y devuelve el docker-compose.yaml
+func generar(t *testing.T, uid int) (*Aprovisionador, string) {
+	t.Helper()
+	anterior := geteuid
+	geteuid = func() int { return uid }
+	t.Cleanup(func() { geteuid = anterior })
+
+	dir := t.TempDir()
+	datos := filepath.Join(dir, "mis datos")
+	if err := os.MkdirAll(datos, 0755); err != nil {
+		t.Fatal(err)
+	}
+	a := Nuevo(filepath.Join(dir, "grafana"), filepath.Join(datos, "containers.db"))
+	if err := a.Generar(); err != nil {
+		t.Fatal(err)
+	}
+	compose, err := os.ReadFile(a.RutaCompose())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, string(compose)
+}
+
+func TestGenerarUsuarioContenedor(t *testing.T) {
+	// Sin root no se puede cambiar el dueño de los datos: Grafana corre
+	// con el usuario del daemon
+	_, compose := generar(t, 1000)
+	desea := fmt.Sprintf(`user: "1000:%d"`, os.Getegid())
+	if !strings.Contains(compose, desea) {
+		t.Fatalf("el compose debería tener %s:\n%s", desea, compose)
+	}
+
+	if os.Geteuid() != 0 {
+		t.Skip("hace falta root para asignarle los datos a Grafana")
+	}
+	a, compose := generar(t, 0)
+	if !strings.Contains(compose, `user: "472:0"`) {
+		t.Fatalf("como root Grafana debería correr con su usuario:\n%s", compose)
+	}
+	info, err := os.Stat(filepath.Dir(a.RutaDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := info.Sys().(*syscall.Stat_t); st.Uid != UIDGrafana || st.Gid != GIDGrafana {
+		t.Fatalf("la carpeta de datos es de %d:%d", st.Uid, st.Gid)
+	}
+}
+
+func TestGenerarProvisioning(t *testing.T) {
+	a, compose := generar(t, 1000)
+	dirDB := filepath.Dir(a.RutaDB)
+
+	// Las rutas con espacios van citadas para que YAML no las corte
+	for _, desea := range []string{
+		"image: grafana/grafana:11.0.0",
+		`"3000:3000"`,
+		fmt.Sprintf("%q", dirDB+":/db"),
+		fmt.Sprintf("%q", filepath.Join(a.Dir, "provisioning")+":/etc/grafana/provisioning:ro"),
+		fmt.Sprintf("%q", a.DirDashboards()+":/etc/grafana/dashboards:ro"),
+		`"GF_SECURITY_ADMIN_PASSWORD=admin"`,
+	} {
+		if !strings.Contains(compose, desea) {
+			t.Errorf("el compose no tiene %s:\n%s", desea, compose)
+		}
+	}
+
+	fuente, err := os.ReadFile(filepath.Join(a.Dir, "provisioning", "datasources", "sqlite.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, desea := range []string{"uid: " + UIDDatasource, "path: /db/containers.db"} {
+		if !strings.Contains(string(fuente), desea) {
+			t.Errorf("el datasource no tiene %q:\n%s", desea, fuente)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(a.Dir, "provisioning", "dashboards", "daemon.yaml")); err != nil {
+		t.Error(err)
+	}
+
+	// No quedan temporales de la escritura atómica
+	temporales, _ := filepath.Glob(filepath.Join(a.Dir, "*", "*", "*.tmp"))
+	if len(temporales) > 0 {
+		t.Errorf("quedaron temporales: %v", temporales)
+	}
+}
+
+func TestLevantarYBajar(t *testing.T) {
+	a := Nuevo(t.TempDir(), "containers.db")
+	var llamadas [][]string
+	a.Compose = func(args ...string) (string, error) {
+		llamadas = append(llamadas, args)
+		return "", nil
+	}
+	if err := a.Levantar(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Bajar(); err != nil {
+		t.Fatal(err)
+	}
+	base := []string{"-p", "sopes1-daemon", "-f", a.RutaCompose()}
+	desea := [][]string{append(slices.Clone(base), "up", "-d"), append(slices.Clone(base), "down")}
+	if !slices.EqualFunc(llamadas, desea, slices.Equal) {
+		t.Fatalf("se llamó %v; se esperaba %v", llamadas, desea)
+	}
+
+	// El error incluye la salida de docker compose
+	a.Compose = func(args ...string) (string, error) {
+		return "no such image", errors.New("exit status 1")
+	}
+	if err := a.Levantar(); err == nil || !strings.Contains(err.Error(), "no such image") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// grafanaPrueba responde /api/health con los estados dados en orden; el
+// último se repite
+func grafanaPrueba(t *testing.T, estados ...string) (*Aprovisionador, *atomic.Int32) {
+	t.Helper()
+	var consultas atomic.Int32
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/health" {
+			http.NotFound(w, r)
+			return
+		}
+		i := min(int(consultas.Add(1)), len(estados)) - 1
+		if estados[i] == "caido" {
+			http.Error(w, "iniciando", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"database": %q}`, estados[i])
+	}))
+	t.Cleanup(servidor.Close)
+
+	u, err := url.Parse(servidor.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Nuevo(t.TempDir(), "containers.db")
+	if a.Puerto, err = strconv.Atoi(u.Port()); err != nil {
+		t.Fatal(err)
+	}
+	a.Cliente = servidor.Client()
+	return a, &consultas
+}
+
+func TestEsperarSalud(t *testing.T) {
+	a, consultas := grafanaPrueba(t, "caido", "migrando", "ok")
+	if err := a.EsperarSalud(context.Background(), 5, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if n := consultas.Load(); n != 3 {
+		t.Fatalf("se consultó %d veces; se esperaba 3", n)
+	}
+
+	a, consultas = grafanaPrueba(t, "caido")
+	err := a.EsperarSalud(context.Background(), 3, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("err = %v", err)
+	}
+	if n := consultas.Load(); n != 3 {
+		t.Fatalf("se consultó %d veces; se esperaba 3", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := a.EsperarSalud(ctx, 3, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelado: err = %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"math/rand"
 	"os"
@@ -9,28 +11,98 @@ import (
 	"syscall"
 	"time"
 	"fmt"
-	"io"
+	"log/slog"
 	"os/exec"
 	"path/filepath"
-	"encoding/json"
+	"strings"
+
+	"cronjob/crontab"
+	"cronjob/programador"
+	"cronjob/tarea"
+	"daemon/basedatos"
+	"daemon/config"
+	"daemon/consulta"
+	"daemon/exportador"
+	"daemon/grafana"
+	"daemon/recolector"
+	"daemon/systemd"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Marca con la que se identifican en el crontab las entradas de este daemon
+const marcaCronJob = "sopes1-daemon-proc"
+
 
 
+// Uso:
+//
+//	daemon [run] [-programador auto|crontab|nativo]
+//	daemon install [-nombre N] [-raiz /] [-- flags de run]
+//	daemon uninstall [-nombre N] [-raiz /]
+//	daemon status [-nombre N] [-raiz /]
 func main() {
+	// Logs estructurados; bajo systemd llevan la prioridad que entiende journald
+	slog.SetDefault(systemd.NuevoLogger(os.Stderr, slog.LevelInfo))
+
+	subcomando, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcomando, args = args[0], args[1:]
+	}
+
+	switch subcomando {
+	case "run":
+		// El error se informa después de que ejecutarDaemon corrió sus defer
+		// (quitar el cronjob, bajar Grafana, cerrar la base)
+		if err := ejecutarDaemon(args); err != nil {
+			log.Fatal(err)
+		}
+	case "install":
+		instalarServicio(args)
+	case "uninstall":
+		desinstalarServicio(args)
+	case "status":
+		estadoServicio(args)
+	default:
+		log.Fatalf("Subcomando desconocido %q (use run, install, uninstall o status)", subcomando)
+	}
+}
+
+// ejecutarDaemon es el daemon en sí: lectura de /proc y almacenamiento en SQLite.
+// Los errores se devuelven en lugar de terminar con log.Fatal para que se
+// ejecuten los defer de lo que ya se inició.
+func ejecutarDaemon(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	modoProgramador := fs.String("programador", "auto", "cómo ejecutar el generador de contenedores: crontab, nativo o auto (nativo si no existe crontab)")
+	rutaConfig := fs.String("config", "", "archivo JSON de configuración (por defecto daemon.json junto al ejecutable)")
+	fs.Parse(args)
+
 	// Inicializar aleatoriedad
 	rand.Seed(time.Now().UnixNano())
 
 	// Obtener directorio del ejecutable
 	exePath, err := os.Executable()
 	if err != nil {
-		log.Fatal("Error obteniendo ruta del ejecutable:", err)
+		return fmt.Errorf("error obteniendo ruta del ejecutable: %v", err)
 	}
 	exeDir := filepath.Dir(exePath)
-	
-	// Crear ruta absoluta para la DB
-	dbPath := filepath.Join(exeDir, "containers.db")
+
+	// Configuración de los intervalos de muestreo (se puede recargar con SIGHUP)
+	if *rutaConfig == "" {
+		*rutaConfig = filepath.Join(exeDir, "daemon.json")
+	}
+	cfg, err := config.Cargar(*rutaConfig)
+	if err != nil {
+		return err
+	}
+
+	// Crear ruta absoluta para la DB. Va en su propia carpeta porque Grafana la
+	// monta completa (ver grafana.Aprovisionador): no debe ver el binario ni daemon.json.
+	dirDatos := filepath.Join(exeDir, "datos")
+	if err := os.MkdirAll(dirDatos, 0755); err != nil {
+		return fmt.Errorf("error creando carpeta de datos: %v", err)
+	}
+	dbPath := filepath.Join(dirDatos, "containers.db")
+	moverBaseAnterior(filepath.Join(exeDir, "containers.db"), dbPath)
 	log.Println("Usando base de datos en:", dbPath)
 
 	// IMPORTANTE: Eliminar si existe como directorio
@@ -44,37 +116,64 @@ func main() {
 		log.Println("Creando archivo de base de datos...")
 		file, err := os.Create(dbPath)
 		if err != nil {
-			log.Fatal("Error creando archivo DB:", err)
+			return fmt.Errorf("error creando archivo DB: %v", err)
 		}
 		file.Close()
 		log.Println("Archivo containers.db creado")
 	}
 
-	// Conexión a SQLite
-	db, err := sql.Open("sqlite3", dbPath)
+	// Conexión a SQLite (modo WAL y busy timeout, ver basedatos.Abrir)
+	db, err := basedatos.Abrir(dbPath)
 	if err != nil {
-		log.Fatal("Error abriendo base de datos:", err)
+		return err
 	}
 	defer db.Close()
 
-	// Crear tabla si no existe
-	createTable := `
-	CREATE TABLE IF NOT EXISTS registros (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		total_ram REAL,
-		ram_libre REAL,
-		total_procesos REAL,
-		created_at INTEGER
-	);`
-	_, err = db.Exec(createTable)
+	// Crear o actualizar el esquema (tablas, índices y promedios de retención)
+	version, err := basedatos.Migrar(db)
 	if err != nil {
-		log.Fatal("Error creando tabla:", err)
+		return fmt.Errorf("error migrando la base de datos: %v", err)
 	}
+	slog.Info("Esquema de la base de datos", "version", version)
 
-	// ====================================================  1. EJECUCIÓN DEL DOCKER COMPOSE DE GRAFANA ====================================================
-	ejecutarDockerCompose()
+	// ====================================================  1. GRAFANA (DOCKER COMPOSE GENERADO) ====================================================
+	// Es opcional: si Docker o Grafana fallan el daemon sigue recolectando
+	if cfg.Grafana.Activo {
+		// Los dashboards se generan desde el esquema ya migrado, así siempre coinciden con las tablas
+		esquema, err := basedatos.LeerEsquema(db)
+		if err != nil {
+			return err
+		}
+		detenerGrafana := iniciarGrafana(cfg.Grafana, filepath.Join(exeDir, "grafana-generado"), dbPath, esquema, version)
+		defer detenerGrafana()
+	}
 
 	// ==================================================== 2. CREACIÓN DEL CRONJOB A PARTIR DEL .SH ====================================================
+	rutaGenerador := filepath.Join(exeDir, "generador_contenedores.sh")
+	nativo, err := usarProgramadorNativo(*modoProgramador)
+	if err != nil {
+		return err
+	}
+	if nativo {
+		// Sin crontab (por ejemplo en un contenedor mínimo) el script se ejecuta desde el propio daemon
+		ctxProgramador, detenerProgramador := context.WithCancel(context.Background())
+		terminado, err := iniciarProgramador(ctxProgramador, db, rutaGenerador)
+		if err != nil {
+			detenerProgramador()
+			return err
+		}
+		defer func() {
+			detenerProgramador()
+			<-terminado
+		}()
+	} else {
+		if err := crearCronJob(rutaGenerador); err != nil {
+			return err
+		}
+		// Al detener el daemon se eliminan solo las entradas que él agregó,
+		// también si algo de lo que sigue falla al iniciar
+		defer eliminarCronJob()
+	}
 
 	// ==================================================== 3. CARGA DE MODULOS DE KERNEL ====================================================
 
@@ -88,37 +187,116 @@ func main() {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-	// Se define el ticker de 20 segundos
-	ticker := time.NewTicker(20 * time.Second)
-	// 'defer ticker.Stop()' asegura que el temporizador se detenga correctamente cuando el programa termine.
-	defer ticker.Stop()
+	// SIGHUP recarga la configuración sin reiniciar el daemon
+	recargar := make(chan os.Signal, 1)
+	signal.Notify(recargar, syscall.SIGHUP)
+
+	// Un reloj por métrica, cada uno con su intervalo y alineado a la hora
+	// (con 10s los ticks caen en :00, :10, :20...) para que las muestras de varios equipos coincidan
+	relojes := nuevosRelojes(cfg)
+	// 'defer relojes.detener()' asegura que los temporizadores se detengan cuando el programa termine.
+	defer func() { relojes.detener() }()
+
+	// Destinos de las muestras (SQLite, JSON lines, InfluxDB, Prometheus). Cada uno
+	// escribe desde su propia cola, así que un destino bloqueado no atrasa los ticks
+	destinos, err := abrirAlmacenes(cfg.Almacenes, db)
+	if err != nil {
+		return fmt.Errorf("error abriendo almacenes: %v", err)
+	}
+	defer destinos.Close()
+
+	// /metrics para Prometheus: recibe las muestras como un destino más y además
+	// expone el estado del daemon (últimas lecturas y errores)
+	metricas := exportador.Nuevo(cfg.Exportador.MaxProcesos, destinos...)
+	if cfg.Exportador.Direccion != "" {
+		srv := metricas.Servir(cfg.Exportador.Direccion)
+		defer srv.Close()
+		slog.Info("Exportador de métricas activo", "direccion", cfg.Exportador.Direccion)
+	}
+
+	// API de consulta sobre el historial, con su propia conexión de solo lectura
+	if cfg.API.Direccion != "" {
+		lectura, err := basedatos.AbrirLectura(dbPath)
+		if err != nil {
+			return fmt.Errorf("error abriendo la base para la API: %v", err)
+		}
+		defer lectura.Close()
+		srv := consulta.Nueva(lectura).Servir(cfg.API.Direccion)
+		defer srv.Close()
+		slog.Info("API de consulta activa", "direccion", cfg.API.Direccion)
+	}
+	muestras := &muestreador{
+		destinos:   append(destinos, metricas),
+		procesos:   recolector.NuevoProcesos(),
+		cgroups:    recolector.NuevoCgroups(),
+		exportador: metricas,
+
+		fuenteContenedores: cfg.FuenteContenedores,
+	}
+	// El detector de anomalías recibe los totales como un destino más
+	if cfg.Anomalias.Activo {
+		detector := abrirDetector(cfg.Anomalias, db)
+		defer detector.Close()
+		muestras.destinos = append(muestras.destinos, detector)
+	}
+
+	// La compactación corre en su propio goroutine para no atrasar las muestras
+	compactador := nuevoCompactador(db)
+	compactador.iniciar(cfg.Retencion)
+	defer compactador.esperar()
+
+	// Si systemd tiene el watchdog activo (WatchdogSec) hay que avisarle periódicamente que
+	// el loop sigue vivo; si no, el canal queda en nil y ese caso del select nunca se activa
+	var latidos <-chan time.Time
+	if intervalo := systemd.IntervaloWatchdog(); intervalo > 0 {
+		watchdog := time.NewTicker(intervalo)
+		defer watchdog.Stop()
+		latidos = watchdog.C
+		slog.Info("Watchdog de systemd activo", "intervalo", intervalo)
+	}
+
+	// Todo está inicializado: con Type=notify systemd espera este aviso
+	if _, err := systemd.Listo(); err != nil {
+		slog.Warn("No se pudo notificar a systemd", "error", err)
+	}
+	defer systemd.Deteniendo()
 
 	// Este bucle infinito permite que el daemon esté en ejecución constante,
-	// esperando eventos del ticker o señales del sistema operativo.
+	// esperando eventos de los relojes o señales del sistema operativo.
 loop:
-	// Se lee el archivo de proc cada 20 segundos a través de un for select
 	for {
 		select {
-		case <-ticker.C:
-			// Este caso se ejecuta cada vez que el ticker genera un evento (cada 20 segundos).
-			lectura_sysinfo, err := leerProc("/proc/sysinfo")
-			if err != nil {
-				fmt.Println(err)
-				return
-			}
+		case t := <-relojes.totales.C:
+			// Totales de RAM y procesos del módulo de kernel
+			muestras.totales(t)
+
+		case t := <-relojes.procesos.C:
+			// Tabla de procesos leída de /proc/<pid>/stat
+			muestras.tablaProcesos(t)
 
-			// Extraer los valores directamente del map
-			totalram := int64(lectura_sysinfo["Totalram"].(float64))
-			freeram := int64(lectura_sysinfo["Freeram"].(float64))
-			procs := int(lectura_sysinfo["Procs"].(float64))
+		case t := <-relojes.contenedores.C:
+			// Estado de los contenedores
+			muestras.contenedores(t)
 
-			// Insertar una sola fila con los valores del sistema
-			_, err = db.Exec("INSERT INTO registros (total_ram, ram_libre, total_procesos, created_at) VALUES (?, ?, ?, ?)",
-				totalram, freeram, procs, time.Now().UnixMilli())
+		case <-relojes.compactacion.C:
+			compactador.iniciar(cfg.Retencion)
+
+		case <-recargar:
+			nueva, err := config.Cargar(*rutaConfig)
 			if err != nil {
-				// Si ocurre un error al insertar, se registra en los logs.
-				log.Println("Error insertando:", err)
+				slog.Error("No se pudo recargar la configuración, se mantiene la anterior", "error", err)
+				continue
+			}
+			if *nueva != *cfg {
+				relojes.detener()
+				relojes = nuevosRelojes(nueva)
 			}
+			cfg = nueva
+			muestras.fuenteContenedores = cfg.FuenteContenedores
+			slog.Info("Configuración recargada", "archivo", *rutaConfig)
+
+		case <-latidos:
+			systemd.Latido()
 
 		case <-sigs:
 			// Este caso se ejecuta cuando se recibe una señal de interrupción o terminación.
@@ -127,41 +305,161 @@ loop:
 			break loop
 		}
 	}
+	return nil
 }
 
 // ======================================================= FUNCIONES COMPLEMENTARIAS PARA EL MAIN ==============================
-func ejecutarDockerCompose() {
-	composeFilePath := "../grafana/docker-compose.yaml"
+// iniciarGrafana genera los archivos de Grafana con la ruta real de la base y los
+// dashboards del esquema actual, y lo levanta en segundo plano, esperando a que responda. Devuelve la función que
+// lo detiene al salir (solo baja el contenedor si así está configurado).
+func iniciarGrafana(cfg config.Grafana, dir, dbPath string, esquema basedatos.Esquema, version int) func() {
+	aprovisionador := grafana.Nuevo(dir, dbPath)
+	aprovisionador.Puerto = cfg.Puerto
+	aprovisionador.Usuario = cfg.Usuario
+	aprovisionador.Clave = cfg.Clave
 
-	cmd := exec.Command("docker", "compose", "-f", composeFilePath, "up", "-d")
-	output, err := cmd.CombinedOutput()
-    	if err != nil {
-    		log.Fatalf("Error al ejecutar el docker compose: %v\nOutput: %s", err, output)
-    	}
+	ctx, cancelar := context.WithCancel(context.Background())
+	terminado := make(chan struct{})
+	go func() {
+		defer close(terminado)
+		if err := aprovisionador.Generar(); err != nil {
+			slog.Warn("No se pudieron generar los archivos de Grafana", "error", err)
+			return
+		}
+		if err := aprovisionador.GenerarDashboards(esquema, version); err != nil {
+			slog.Warn("No se pudieron generar los dashboards de Grafana", "error", err)
+			return
+		}
+		if err := aprovisionador.Levantar(); err != nil {
+			slog.Warn("No se pudo levantar Grafana, el daemon sigue sin él", "error", err)
+			return
+		}
+		if err := aprovisionador.EsperarSalud(ctx, 30, 2*time.Second); err != nil {
+			slog.Warn("Grafana no está respondiendo", "error", err)
+			return
+		}
+		slog.Info("Grafana listo", "url", fmt.Sprintf("http://localhost:%d", cfg.Puerto), "compose", aprovisionador.RutaCompose())
+	}()
 
-    	fmt.Printf("Docker Compose Up exitoso:\n%s\n", output)
+	return func() {
+		cancelar()
+		<-terminado
+		if cfg.BajarAlSalir {
+			if err := aprovisionador.Bajar(); err != nil {
+				slog.Warn("No se pudo detener Grafana", "error", err)
+			}
+		}
+	}
 }
 
-func leerProc(ruta string) (map[string]interface{}, error) {
-	// Se abre el archivo
-	file, err := os.Open(ruta)
+// crearCronJob registra en el crontab el script generador de contenedores.
+// Si la entrada ya existe (por ejemplo tras un reinicio del daemon) no se duplica.
+func crearCronJob(rutaScript string) error {
+	if err := crontab.HacerEjecutable(rutaScript); err != nil {
+		return err
+	}
+
+	entrada := crontab.Entrada{
+		Nombre:    "generador_contenedores",
+		Expresion: "* * * * *",
+		Comando:   fmt.Sprintf("%s >> %s.log 2>&1", rutaScript, rutaScript),
+	}
+	res, err := crontab.Nuevo(marcaCronJob).Agregar(entrada)
 	if err != nil {
-		return nil, fmt.Errorf("error al abrir el archivo %s: %v", ruta, err)
+		return fmt.Errorf("error creando cronjob: %v", err)
+	}
+
+	if res.Cambio {
+		log.Println("Cronjob agregado:", entrada.Linea())
+	} else {
+		log.Println("El cronjob ya estaba registrado:", entrada.Linea())
 	}
-	defer file.Close()
+	return nil
+}
+
+// usarProgramadorNativo decide entre crontab y el programador interno
+func usarProgramadorNativo(modo string) (bool, error) {
+	switch modo {
+	case "nativo":
+		return true, nil
+	case "crontab":
+		return false, nil
+	case "auto":
+		if _, err := exec.LookPath("crontab"); err != nil {
+			log.Println("No se encontró el comando crontab, se usará el programador interno")
+			return true, nil
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("modo de programador inválido %q (use crontab, nativo o auto)", modo)
+	}
+}
 
-	// Se lee todo el contenido del archivo
-	contenido, err := io.ReadAll(file)
+// iniciarProgramador ejecuta el generador cada minuto dentro del daemon y guarda
+// cada ejecución en la tabla ejecuciones. El canal devuelto se cierra al terminar.
+func iniciarProgramador(ctx context.Context, db *sql.DB, rutaScript string) (<-chan struct{}, error) {
+	t := &tarea.Tarea{
+		Nombre:       "generador_contenedores",
+		Script:       rutaScript,
+		Horario:      "* * * * *",
+		LogMaxMB:     tarea.LogMaxMBPorDefecto,
+		LogRespaldos: tarea.LogRespaldosPorDefecto,
+		// Cada ejecución debe terminar antes de que empiece la siguiente
+		TimeoutSegundos: 55,
+	}
+	if err := t.Preparar(); err != nil {
+		return nil, err
+	}
+	if err := crontab.HacerEjecutable(t.Script); err != nil {
+		return nil, err
+	}
+
+	registro, err := programador.NuevoRegistroSQLite(db)
 	if err != nil {
-		return nil, fmt.Errorf("error al leer el archivo: %v", err)
+		return nil, err
+	}
+	p := programador.Nuevo(registro)
+	if err := p.Agregar(t); err != nil {
+		return nil, err
 	}
 
-	// Se parsea el JSON a un map genérico
-	var datos map[string]interface{}
-	err = json.Unmarshal(contenido, &datos)
+	terminado := make(chan struct{})
+	go func() {
+		defer close(terminado)
+		if err := p.Ejecutar(ctx); err != nil {
+			log.Println("Error en el programador:", err)
+		}
+	}()
+	return terminado, nil
+}
+
+// eliminarCronJob quita del crontab el bloque de entradas creado por el daemon
+func eliminarCronJob() {
+	res, err := crontab.Nuevo(marcaCronJob).QuitarTodas()
 	if err != nil {
-		return nil, fmt.Errorf("error al parsear JSON: %v", err)
+		log.Println("Error eliminando cronjob:", err)
+		return
 	}
+	if res.Cambio {
+		log.Println("Cronjobs del daemon eliminados")
+	}
+}
 
-	return datos, nil
-}
\ No newline at end of file
+// moverBaseAnterior lleva la base de una versión anterior del daemon (junto al
+// ejecutable) a la carpeta de datos, con sus archivos -wal y -shm, para no perder
+// el historial. Si ya existe una base en la carpeta nueva no se toca nada.
+func moverBaseAnterior(anterior, nueva string) {
+	if info, err := os.Stat(anterior); err != nil || info.IsDir() {
+		return
+	}
+	if _, err := os.Stat(nueva); err == nil {
+		return
+	}
+	for _, sufijo := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(anterior+sufijo, nueva+sufijo); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error moviendo %s a la carpeta de datos: %v", anterior+sufijo, err)
+			return
+		}
+	}
+	log.Println("Base de datos movida a", nueva)
+}
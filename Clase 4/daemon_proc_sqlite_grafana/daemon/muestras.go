@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"daemon/almacen"
+	"daemon/anomalias"
+	"daemon/config"
+	"daemon/exportador"
+	"daemon/muestreo"
+	"daemon/recolector"
+)
+
+// Archivos que publican los módulos de kernel
+const (
+	rutaSysinfo  = "/proc/sysinfo"
+	rutaContinfo = "/proc/continfo"
+)
+
+// relojes agrupa el reloj de cada métrica
+type relojes struct {
+	totales      *muestreo.Reloj
+	procesos     *muestreo.Reloj
+	contenedores *muestreo.Reloj
+	compactacion *muestreo.Reloj
+}
+
+func nuevosRelojes(cfg *config.Config) *relojes {
+	iv := cfg.Intervalos
+	slog.Info("Intervalos de muestreo",
+		"totales", time.Duration(iv.Totales),
+		"procesos", time.Duration(iv.Procesos),
+		"contenedores", time.Duration(iv.Contenedores))
+	return &relojes{
+		totales:      muestreo.NuevoReloj(time.Duration(iv.Totales)),
+		procesos:     muestreo.NuevoReloj(time.Duration(iv.Procesos)),
+		contenedores: muestreo.NuevoReloj(time.Duration(iv.Contenedores)),
+		compactacion: muestreo.NuevoReloj(time.Duration(cfg.Retencion.Compactacion)),
+	}
+}
+
+func (r *relojes) detener() {
+	r.totales.Detener()
+	r.procesos.Detener()
+	r.contenedores.Detener()
+	r.compactacion.Detener()
+}
+
+// Ticks que pueden acumular las colas de escritura (unos minutos de muestras)
+const capacidadCola = 64
+
+// abrirAlmacenes crea los destinos activos en la configuración
+func abrirAlmacenes(a config.Almacenes, db *sql.DB) (almacen.Varios, error) {
+	var destinos almacen.Varios
+	if a.SQLite {
+		destinos = append(destinos, almacen.NuevoSQLite(db, capacidadCola))
+	}
+	if a.JSONL != "" {
+		j, err := almacen.NuevoJSONL(a.JSONL, 50<<20, 3)
+		if err != nil {
+			destinos.Close()
+			return nil, err
+		}
+		destinos = append(destinos, j)
+	}
+	// Los destinos HTTP se envían en segundo plano para que un servidor lento no atrase el muestreo
+	if a.InfluxDB != "" {
+		influx := almacen.NuevoInfluxDB(a.InfluxDB, a.InfluxDBToken)
+		influx.MaxProcesos = a.MaxProcesos
+		destinos = append(destinos, almacen.EnSegundoPlano(influx, capacidadCola))
+	}
+	if a.PrometheusRemoteWrite != "" {
+		remoto := almacen.NuevoPrometheusRemoteWrite(a.PrometheusRemoteWrite)
+		remoto.MaxProcesos = a.MaxProcesos
+		destinos = append(destinos, almacen.EnSegundoPlano(remoto, capacidadCola))
+	}
+
+	for _, d := range destinos {
+		slog.Info("Almacén activo", "destino", d.Nombre())
+	}
+	return destinos, nil
+}
+
+// abrirDetector crea el detector de anomalías con la línea base precargada
+// desde registros. Cada anomalía se registra en el log y en la tabla anomalias,
+// y en el webhook si está configurado.
+func abrirDetector(an config.Anomalias, db *sql.DB) *anomalias.Detector {
+	linea := func() anomalias.Linea { return anomalias.NuevoMAD(an.Ventana) }
+	if an.Metodo == config.MetodoEWMA {
+		linea = func() anomalias.Linea { return anomalias.NuevoEWMA(an.Ventana) }
+	}
+
+	notificadores := []anomalias.Notificador{anomalias.Log{}, anomalias.SQLite{DB: db}}
+	if an.Webhook != "" {
+		notificadores = append(notificadores, anomalias.NuevoWebhook(an.Webhook))
+	}
+
+	d := anomalias.Nuevo(anomalias.Parametros{Linea: linea, Umbral: an.Umbral, Consecutivas: an.Consecutivas}, notificadores...)
+	if err := d.Precargar(db, an.Ventana); err != nil {
+		slog.Warn("No se pudo precargar la línea base de anomalías", "error", err)
+	}
+	slog.Info("Detección de anomalías activa", "metodo", an.Metodo, "ventana", an.Ventana, "umbral", an.Umbral)
+	return d
+}
+
+// muestreador lee cada métrica en su tick y la envía a los destinos.
+// Las lecturas y los errores quedan registrados en el exportador (/metrics).
+type muestreador struct {
+	destinos   almacen.Varios
+	procesos   *recolector.Procesos
+	cgroups    *recolector.Cgroups
+	exportador *exportador.Exportador
+	// fuenteContenedores es config.FuenteCgroups o config.FuenteContinfo
+	fuenteContenedores string
+}
+
+// guardar envía las muestras a cada destino y cuenta los que fallen
+func (m *muestreador) guardar(metrica string, muestras almacen.Muestras) {
+	m.exportador.LecturaExitosa(metrica, muestras.Tiempo)
+	for _, d := range m.destinos {
+		if err := d.Guardar(muestras); err != nil {
+			m.exportador.ErrorInsercion(d.Nombre())
+			slog.Error("Error guardando muestras", "metrica", metrica, "destino", d.Nombre(), "error", err)
+		}
+	}
+}
+
+func (m *muestreador) errorLectura(metrica string, err error) {
+	m.exportador.ErrorLectura(metrica)
+	slog.Error("Error leyendo muestras", "metrica", metrica, "error", err)
+}
+
+// totales guarda los totales de RAM y procesos con la hora alineada del tick
+func (m *muestreador) totales(t time.Time) {
+	totales, err := recolector.LeerSysinfo(rutaSysinfo)
+	if err != nil {
+		m.errorLectura("totales", err)
+		return
+	}
+	m.guardar("totales", almacen.Muestras{Tiempo: t, Totales: &totales})
+}
+
+// tablaProcesos guarda la tabla de procesos completa con la misma marca de tiempo
+func (m *muestreador) tablaProcesos(t time.Time) {
+	procesos, err := m.procesos.Leer()
+	if err != nil {
+		m.errorLectura("procesos", err)
+		return
+	}
+	m.guardar("procesos", almacen.Muestras{Tiempo: t, Procesos: procesos})
+}
+
+// contenedores guarda el estado de cada contenedor, leído de cgroups o del módulo de kernel
+func (m *muestreador) contenedores(t time.Time) {
+	var contenedores []recolector.Container
+	var err error
+	if m.fuenteContenedores == config.FuenteContinfo {
+		contenedores, err = recolector.LeerContinfo(rutaContinfo)
+	} else {
+		contenedores, err = m.cgroups.Leer()
+	}
+	if err != nil {
+		m.errorLectura("contenedores", err)
+		return
+	}
+	m.guardar("contenedores", almacen.Muestras{Tiempo: t, Contenedores: contenedores})
+}
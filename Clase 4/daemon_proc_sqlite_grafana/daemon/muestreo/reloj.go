@@ -0,0 +1,62 @@
+// Package muestreo genera los ticks de cada métrica alineados al reloj.
+//
+// Con un intervalo de 10s los ticks caen en :00, :10, :20... en lugar de
+// depender de la hora en la que arrancó el daemon, así las muestras de
+// varios equipos coinciden en los mismos instantes en Grafana.
+package muestreo
+
+import (
+	"time"
+)
+
+// Reloj es un ticker alineado a múltiplos del intervalo (en UTC)
+type Reloj struct {
+	// C recibe la hora exacta del tick (el múltiplo del intervalo), no la hora
+	// a la que se despertó el goroutine; se puede usar como marca de tiempo
+	C         <-chan time.Time
+	Intervalo time.Duration
+
+	detener chan struct{}
+}
+
+// NuevoReloj empieza a emitir ticks en el próximo múltiplo del intervalo
+func NuevoReloj(intervalo time.Duration) *Reloj {
+	c := make(chan time.Time, 1)
+	r := &Reloj{C: c, Intervalo: intervalo, detener: make(chan struct{})}
+	go r.correr(c)
+	return r
+}
+
+// Detener libera el goroutine del reloj
+func (r *Reloj) Detener() {
+	close(r.detener)
+}
+
+// Alinear devuelve el próximo múltiplo del intervalo posterior a t
+func Alinear(t time.Time, intervalo time.Duration) time.Time {
+	return t.Truncate(intervalo).Add(intervalo)
+}
+
+func (r *Reloj) correr(c chan<- time.Time) {
+	siguiente := Alinear(time.Now(), r.Intervalo)
+	timer := time.NewTimer(time.Until(siguiente))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-r.detener:
+			return
+		case <-timer.C:
+		}
+
+		// Si el receptor está ocupado se descarta el tick, igual que time.Ticker
+		select {
+		case c <- siguiente:
+		default:
+		}
+
+		// Si el proceso estuvo detenido (suspensión, pausa) se saltan los ticks perdidos
+		siguiente = Alinear(time.Now(), r.Intervalo)
+		timer.Reset(time.Until(siguiente))
+	}
+}
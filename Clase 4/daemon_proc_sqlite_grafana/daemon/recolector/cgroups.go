@@ -0,0 +1,265 @@
+package recolector
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cgroups lee CPU, memoria y estado de cada contenedor desde cgroup v2.
+//
+// Los contenedores se reconocen por el nombre de su cgroup:
+//   - Docker con systemd:      system.slice/docker-<id>.scope
+//   - Docker con cgroupfs:     docker/<id>
+//   - containerd (Kubernetes): kubepods.slice/.../cri-containerd-<id>.scope
+//   - containerd (nerdctl):    .../nerdctl-<id>.scope o <namespace>/<id>
+//
+// El nombre se busca en la configuración del runtime; si no se encuentra se
+// usan los primeros 12 caracteres del id, como hace "docker ps".
+type Cgroups struct {
+	// Raiz del árbol cgroup v2 (/sys/fs/cgroup)
+	Raiz string
+	// DirDocker tiene una carpeta por contenedor con config.v2.json
+	DirDocker string
+	// DirContainerd tiene los bundles OCI de containerd (<namespace>/<id>/config.json)
+	DirContainerd string
+
+	anterior      map[string]uint64
+	lecturaPrevia time.Time
+	nombres       map[string]string
+}
+
+// NuevoCgroups crea el recolector con las rutas por defecto de Linux
+func NuevoCgroups() *Cgroups {
+	return &Cgroups{
+		Raiz:          "/sys/fs/cgroup",
+		DirDocker:     "/var/lib/docker/containers",
+		DirContainerd: "/run/containerd/io.containerd.runtime.v2.task",
+	}
+}
+
+// Runtimes que se reconocen
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+)
+
+var patronContenedor = regexp.MustCompile(`^(docker-|cri-containerd-|containerd-|nerdctl-)?([0-9a-f]{64})(\.scope)?$`)
+
+// CgroupContenedor es un contenedor encontrado en el árbol de cgroups
+type CgroupContenedor struct {
+	ID      string
+	Runtime string
+	Ruta    string
+}
+
+// Buscar recorre el árbol y devuelve los cgroups que pertenecen a contenedores
+func (c *Cgroups) Buscar() ([]CgroupContenedor, error) {
+	if _, err := os.Stat(filepath.Join(c.Raiz, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("%s no es un árbol cgroup v2: %v", c.Raiz, err)
+	}
+
+	var encontrados []CgroupContenedor
+	err := filepath.WalkDir(c.Raiz, func(ruta string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Un cgroup puede desaparecer mientras se recorre el árbol
+			return nil
+		}
+		if !d.IsDir() || ruta == c.Raiz {
+			return nil
+		}
+		m := patronContenedor.FindStringSubmatch(d.Name())
+		if m == nil {
+			return nil
+		}
+
+		runtime := RuntimeContainerd
+		if m[1] == "docker-" || filepath.Base(filepath.Dir(ruta)) == "docker" {
+			runtime = RuntimeDocker
+		}
+		encontrados = append(encontrados, CgroupContenedor{ID: m[2], Runtime: runtime, Ruta: ruta})
+		// Los cgroups hijos (por ejemplo init.scope) son parte del mismo contenedor
+		return fs.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(encontrados, func(i, j int) bool { return encontrados[i].ID < encontrados[j].ID })
+	return encontrados, nil
+}
+
+// Leer devuelve un Container por contenedor. El CPU es el porcentaje de un
+// núcleo usado desde la lectura anterior (0 la primera vez).
+func (c *Cgroups) Leer() ([]Container, error) {
+	cgroups, err := c.Buscar()
+	if err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	transcurrido := ahora.Sub(c.lecturaPrevia).Seconds()
+	actual := make(map[string]uint64)
+	nombres := make(map[string]string)
+
+	var contenedores []Container
+	for _, cg := range cgroups {
+		uso, err := leerUsoCPU(filepath.Join(cg.Ruta, "cpu.stat"))
+		if err != nil {
+			// El contenedor terminó entre Buscar y la lectura
+			continue
+		}
+		memoria, _ := leerEntero(filepath.Join(cg.Ruta, "memory.current"))
+
+		contenedor := Container{
+			Name:   c.nombre(cg),
+			Memory: float64(memoria) / (1024 * 1024),
+			Status: estadoCgroup(cg.Ruta),
+		}
+		actual[cg.ID] = uso
+		nombres[cg.ID] = contenedor.Name
+		if previo, ok := c.anterior[cg.ID]; ok && transcurrido > 0 && uso >= previo {
+			contenedor.CPU = float64(uso-previo) / 1e6 / transcurrido * 100
+		}
+		contenedores = append(contenedores, contenedor)
+	}
+
+	c.anterior = actual
+	c.lecturaPrevia = ahora
+	c.nombres = nombres
+	return contenedores, nil
+}
+
+// nombre resuelve el nombre del contenedor; se guarda entre lecturas para no
+// releer la configuración del runtime en cada tick
+func (c *Cgroups) nombre(cg CgroupContenedor) string {
+	if n, ok := c.nombres[cg.ID]; ok {
+		return n
+	}
+
+	var nombre string
+	switch cg.Runtime {
+	case RuntimeDocker:
+		nombre = nombreDocker(filepath.Join(c.DirDocker, cg.ID, "config.v2.json"))
+	case RuntimeContainerd:
+		nombre = nombreContainerd(c.DirContainerd, cg.ID)
+	}
+	if nombre == "" {
+		nombre = cg.ID[:12]
+	}
+	return nombre
+}
+
+// nombreDocker lee "Name" de config.v2.json (Docker lo guarda con "/" adelante)
+func nombreDocker(ruta string) string {
+	contenido, err := os.ReadFile(ruta)
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		Name string `json:"Name"`
+	}
+	if json.Unmarshal(contenido, &cfg) != nil {
+		return ""
+	}
+	return strings.TrimPrefix(cfg.Name, "/")
+}
+
+// nombreContainerd busca el bundle del contenedor en cualquier namespace de
+// containerd (k8s.io, default, moby...) y lee las anotaciones del config.json OCI
+func nombreContainerd(dir, id string) string {
+	bundles, _ := filepath.Glob(filepath.Join(dir, "*", id, "config.json"))
+	for _, b := range bundles {
+		contenido, err := os.ReadFile(b)
+		if err != nil {
+			continue
+		}
+		var spec struct {
+			Annotations map[string]string `json:"annotations"`
+		}
+		if json.Unmarshal(contenido, &spec) != nil {
+			continue
+		}
+		a := spec.Annotations
+		// Kubernetes (CRI): pod/contenedor
+		if n := a["io.kubernetes.cri.container-name"]; n != "" {
+			if pod := a["io.kubernetes.cri.sandbox-name"]; pod != "" {
+				return pod + "/" + n
+			}
+			return n
+		}
+		// nerdctl
+		if n := a["nerdctl/name"]; n != "" {
+			return n
+		}
+	}
+	return ""
+}
+
+// estadoCgroup usa los mismos estados que docker ps: un cgroup congelado es un
+// contenedor pausado y uno sin procesos ya terminó
+func estadoCgroup(ruta string) string {
+	eventos, err := leerClaves(filepath.Join(ruta, "cgroup.events"))
+	if err != nil {
+		return "running"
+	}
+	if eventos["frozen"] == 1 {
+		return "paused"
+	}
+	if v, ok := eventos["populated"]; ok && v == 0 {
+		return "exited"
+	}
+	return "running"
+}
+
+// leerUsoCPU devuelve usage_usec de cpu.stat (microsegundos de CPU acumulados)
+func leerUsoCPU(ruta string) (uint64, error) {
+	claves, err := leerClaves(ruta)
+	if err != nil {
+		return 0, err
+	}
+	uso, ok := claves["usage_usec"]
+	if !ok {
+		return 0, fmt.Errorf("%s no tiene usage_usec", ruta)
+	}
+	return uso, nil
+}
+
+// leerClaves parsea archivos de cgroup con líneas "clave valor"
+func leerClaves(ruta string) (map[string]uint64, error) {
+	f, err := os.Open(ruta)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	claves := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		campos := strings.Fields(s.Text())
+		if len(campos) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(campos[1], 10, 64); err == nil {
+			claves[campos[0]] = v
+		}
+	}
+	return claves, s.Err()
+}
+
+// leerEntero lee archivos de un solo valor como memory.current
+func leerEntero(ruta string) (uint64, error) {
+	contenido, err := os.ReadFile(ruta)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(contenido)), 10, 64)
+}
@@ -0,0 +1,168 @@
+package recolector
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func id(c byte) string {
+	return strings.Repeat(string(c), 64)
+}
+
+// escribirArchivo crea el archivo y las carpetas que falten
+func escribirArchivo(t *testing.T, ruta, contenido string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(ruta), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ruta, []byte(contenido), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// cgroupContenedor crea los archivos de un cgroup v2 como los expone el kernel
+func cgroupContenedor(t *testing.T, dir string, usoUsec, memoria int, eventos string) {
+	t.Helper()
+	escribirArchivo(t, filepath.Join(dir, "cpu.stat"),
+		"usage_usec "+strconv.Itoa(usoUsec)+"\nuser_usec 100\nsystem_usec 50\nnr_periods 0\n")
+	escribirArchivo(t, filepath.Join(dir, "memory.current"), strconv.Itoa(memoria)+"\n")
+	escribirArchivo(t, filepath.Join(dir, "cgroup.events"), eventos)
+}
+
+// arbolPrueba arma un árbol cgroup v2 con un contenedor de cada runtime:
+// Docker con systemd, Docker con cgroupfs, Kubernetes y nerdctl, más cgroups
+// que no son contenedores
+func arbolPrueba(t *testing.T) *Cgroups {
+	base := t.TempDir()
+	c := &Cgroups{
+		Raiz:          filepath.Join(base, "cgroup"),
+		DirDocker:     filepath.Join(base, "docker"),
+		DirContainerd: filepath.Join(base, "containerd"),
+	}
+	escribirArchivo(t, filepath.Join(c.Raiz, "cgroup.controllers"), "cpu memory pids\n")
+
+	// Cgroups del sistema que se deben ignorar
+	escribirArchivo(t, filepath.Join(c.Raiz, "system.slice", "ssh.service", "cpu.stat"), "usage_usec 1\n")
+	escribirArchivo(t, filepath.Join(c.Raiz, "user.slice", "user-1000.slice", "cpu.stat"), "usage_usec 1\n")
+
+	docker := filepath.Join(c.Raiz, "system.slice", "docker-"+id('a')+".scope")
+	cgroupContenedor(t, docker, 1000000, 64*1024*1024, "populated 1\nfrozen 0\n")
+	// Un cgroup hijo del contenedor no es otro contenedor
+	escribirArchivo(t, filepath.Join(docker, "init.scope", "cpu.stat"), "usage_usec 5\n")
+	escribirArchivo(t, filepath.Join(c.DirDocker, id('a'), "config.v2.json"), `{"Name":"/web"}`)
+
+	// Docker con cgroupfs, pausado y sin config.v2.json
+	cgroupContenedor(t, filepath.Join(c.Raiz, "docker", id('b')), 0, 1024*1024, "populated 1\nfrozen 1\n")
+
+	k8s := filepath.Join(c.Raiz, "kubepods.slice", "kubepods-burstable.slice",
+		"kubepods-burstable-pod1234.slice", "cri-containerd-"+id('c')+".scope")
+	cgroupContenedor(t, k8s, 0, 0, "populated 0\nfrozen 0\n")
+	escribirArchivo(t, filepath.Join(c.DirContainerd, "k8s.io", id('c'), "config.json"),
+		`{"annotations":{"io.kubernetes.cri.container-name":"api","io.kubernetes.cri.sandbox-name":"api-7d9f"}}`)
+
+	// nerdctl, sin cgroup.events (kernels viejos)
+	nerdctl := filepath.Join(c.Raiz, "system.slice", "nerdctl-"+id('d')+".scope")
+	escribirArchivo(t, filepath.Join(nerdctl, "cpu.stat"), "usage_usec 0\n")
+	escribirArchivo(t, filepath.Join(nerdctl, "memory.current"), "0\n")
+	escribirArchivo(t, filepath.Join(c.DirContainerd, "default", id('d'), "config.json"),
+		`{"annotations":{"nerdctl/name":"redis"}}`)
+
+	return c
+}
+
+func TestCgroupsBuscar(t *testing.T) {
+	c := arbolPrueba(t)
+	encontrados, err := c.Buscar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperados := []struct{ id, runtime string }{
+		{id('a'), RuntimeDocker},
+		{id('b'), RuntimeDocker},
+		{id('c'), RuntimeContainerd},
+		{id('d'), RuntimeContainerd},
+	}
+	if len(encontrados) != len(esperados) {
+		t.Fatalf("encontrados = %+v", encontrados)
+	}
+	for i, e := range esperados {
+		if encontrados[i].ID != e.id || encontrados[i].Runtime != e.runtime {
+			t.Errorf("contenedor %d = %+v, se esperaba %s (%s)", i, encontrados[i], e.id[:12], e.runtime)
+		}
+	}
+}
+
+func TestCgroupsLeer(t *testing.T) {
+	c := arbolPrueba(t)
+	contenedores, err := c.Leer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	esperados := map[string]Container{
+		"web":          {Name: "web", Memory: 64, Status: "running"},
+		id('b')[:12]:   {Name: id('b')[:12], Memory: 1, Status: "paused"},
+		"api-7d9f/api": {Name: "api-7d9f/api", Status: "exited"},
+		"redis":        {Name: "redis", Status: "running"},
+	}
+	if len(contenedores) != len(esperados) {
+		t.Fatalf("contenedores = %+v", contenedores)
+	}
+	for _, got := range contenedores {
+		if esperado, ok := esperados[got.Name]; !ok || got != esperado {
+			t.Errorf("contenedor = %+v, se esperaba %+v", got, esperado)
+		}
+	}
+}
+
+func TestCgroupsCPU(t *testing.T) {
+	c := arbolPrueba(t)
+	if _, err := c.Leer(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Medio segundo de CPU en un segundo: 50% de un núcleo
+	docker := filepath.Join(c.Raiz, "system.slice", "docker-"+id('a')+".scope")
+	cgroupContenedor(t, docker, 1500000, 64*1024*1024, "populated 1\nfrozen 0\n")
+	c.lecturaPrevia = time.Now().Add(-time.Second)
+
+	contenedores, err := c.Leer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cont := range contenedores {
+		switch {
+		case cont.Name == "web" && math.Abs(cont.CPU-50) > 1:
+			t.Errorf("CPU de web = %.2f, se esperaba ~50", cont.CPU)
+		case cont.Name != "web" && cont.CPU != 0:
+			t.Errorf("CPU de %s = %.2f, se esperaba 0", cont.Name, cont.CPU)
+		}
+	}
+}
+
+func TestCgroupsContenedorQueTermina(t *testing.T) {
+	c := arbolPrueba(t)
+	// Sin cpu.stat el cgroup se está borrando: se omite sin fallar
+	if err := os.Remove(filepath.Join(c.Raiz, "docker", id('b'), "cpu.stat")); err != nil {
+		t.Fatal(err)
+	}
+	contenedores, err := c.Leer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contenedores) != 3 {
+		t.Fatalf("contenedores = %+v", contenedores)
+	}
+}
+
+func TestCgroupsSinV2(t *testing.T) {
+	c := &Cgroups{Raiz: t.TempDir()}
+	if _, err := c.Leer(); err == nil {
+		t.Fatal("un árbol sin cgroup.controllers debería fallar")
+	}
+}
@@ -0,0 +1,33 @@
+package recolector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Container es una fila de la tabla containers (mismo formato que el daemon de daemon_grafana_sqlite)
+type Container struct {
+	Name   string  `json:"name"`
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+	Status string  `json:"status"`
+}
+
+// LeerContinfo lee el JSON del módulo de kernel de contenedores (/proc/continfo).
+// Se espera un objeto con la lista "containers"; cada elemento tiene name, cpu,
+// memory (MB) y status.
+func LeerContinfo(ruta string) ([]Container, error) {
+	contenido, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el archivo %s: %v", ruta, err)
+	}
+
+	var datos struct {
+		Containers []Container `json:"containers"`
+	}
+	if err := json.Unmarshal(contenido, &datos); err != nil {
+		return nil, fmt.Errorf("error al parsear JSON de %s: %v", ruta, err)
+	}
+	return datos.Containers, nil
+}
@@ -0,0 +1,109 @@
+package recolector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Proceso es una fila de la tabla de procesos
+type Proceso struct {
+	PID    int
+	Nombre string
+	Estado string
+	RSSKB  int64
+	// CPU es el porcentaje de un núcleo usado desde la muestra anterior
+	CPU float64
+}
+
+// Ticks de reloj por segundo de /proc/<pid>/stat (USER_HZ, 100 en Linux)
+const ticksPorSegundo = 100
+
+// Procesos lee /proc/<pid>/stat de todos los procesos. Guarda el tiempo de CPU
+// de cada lectura para calcular el porcentaje en la siguiente.
+type Procesos struct {
+	Raiz string
+
+	anterior      map[int]uint64
+	lecturaPrevia time.Time
+}
+
+// NuevoProcesos crea el recolector sobre /proc
+func NuevoProcesos() *Procesos {
+	return &Procesos{Raiz: "/proc"}
+}
+
+// Leer devuelve los procesos actuales. En la primera lectura el CPU es 0
+// porque todavía no hay con qué comparar.
+func (p *Procesos) Leer() ([]Proceso, error) {
+	entradas, err := os.ReadDir(p.Raiz)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %v", p.Raiz, err)
+	}
+
+	ahora := time.Now()
+	transcurrido := ahora.Sub(p.lecturaPrevia).Seconds()
+	actual := make(map[int]uint64)
+	paginaKB := int64(os.Getpagesize() / 1024)
+
+	var procesos []Proceso
+	for _, e := range entradas {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+
+		// El proceso puede terminar entre ReadDir y la lectura; se ignora
+		contenido, err := os.ReadFile(filepath.Join(p.Raiz, e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		proc, ticks, err := parsearStat(string(contenido))
+		if err != nil {
+			continue
+		}
+		proc.PID = pid
+		proc.RSSKB *= paginaKB
+
+		actual[pid] = ticks
+		if previo, ok := p.anterior[pid]; ok && transcurrido > 0 && ticks >= previo {
+			proc.CPU = float64(ticks-previo) / ticksPorSegundo / transcurrido * 100
+		}
+		procesos = append(procesos, proc)
+	}
+
+	p.anterior = actual
+	p.lecturaPrevia = ahora
+	return procesos, nil
+}
+
+// parsearStat extrae nombre, estado, utime+stime y rss (en páginas) de /proc/<pid>/stat.
+// El nombre va entre paréntesis y puede tener espacios, por eso se busca el último ')'.
+func parsearStat(stat string) (Proceso, uint64, error) {
+	inicio := strings.IndexByte(stat, '(')
+	fin := strings.LastIndexByte(stat, ')')
+	if inicio < 0 || fin < inicio {
+		return Proceso{}, 0, fmt.Errorf("formato de stat inválido")
+	}
+
+	// Campos a partir del 3 (estado); utime=14, stime=15, rss=24
+	campos := strings.Fields(stat[fin+1:])
+	if len(campos) < 22 {
+		return Proceso{}, 0, fmt.Errorf("stat con muy pocos campos")
+	}
+	utime, err1 := strconv.ParseUint(campos[11], 10, 64)
+	stime, err2 := strconv.ParseUint(campos[12], 10, 64)
+	rss, err3 := strconv.ParseInt(campos[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return Proceso{}, 0, fmt.Errorf("stat con valores inválidos")
+	}
+
+	return Proceso{
+		Nombre: stat[inicio+1 : fin],
+		Estado: campos[0],
+		RSSKB:  rss,
+	}, utime + stime, nil
+}
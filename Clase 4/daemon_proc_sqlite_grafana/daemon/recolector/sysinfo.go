@@ -0,0 +1,63 @@
+// Package recolector lee las métricas que guarda el daemon: los totales del
+// módulo de kernel (/proc/sysinfo), la tabla de procesos y los contenedores.
+package recolector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Totales es lo que publica el módulo de kernel en /proc/sysinfo
+type Totales struct {
+	TotalRAM int64
+	RAMLibre int64
+	Procesos int
+}
+
+// LeerSysinfo lee y parsea el JSON del módulo de kernel
+func LeerSysinfo(ruta string) (Totales, error) {
+	datos, err := leerJSON(ruta)
+	if err != nil {
+		return Totales{}, err
+	}
+
+	// Extraer los valores directamente del map
+	totalram, ok1 := datos["Totalram"].(float64)
+	freeram, ok2 := datos["Freeram"].(float64)
+	procs, ok3 := datos["Procs"].(float64)
+	if !ok1 || !ok2 || !ok3 {
+		return Totales{}, fmt.Errorf("%s no tiene los campos Totalram, Freeram y Procs", ruta)
+	}
+
+	return Totales{
+		TotalRAM: int64(totalram),
+		RAMLibre: int64(freeram),
+		Procesos: int(procs),
+	}, nil
+}
+
+func leerJSON(ruta string) (map[string]interface{}, error) {
+	// Se abre el archivo
+	file, err := os.Open(ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el archivo %s: %v", ruta, err)
+	}
+	defer file.Close()
+
+	// Se lee todo el contenido del archivo
+	contenido, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo: %v", err)
+	}
+
+	// Se parsea el JSON a un map genérico
+	var datos map[string]interface{}
+	err = json.Unmarshal(contenido, &datos)
+	if err != nil {
+		return nil, fmt.Errorf("error al parsear JSON: %v", err)
+	}
+
+	return datos, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"daemon/systemd"
+)
+
+// Nombre por defecto del servicio de systemd
+const nombreServicio = "grafana-db-daemon"
+
+// flagsServicio son los flags comunes a install, uninstall y status
+func flagsServicio(subcomando string) (*flag.FlagSet, *string, *string) {
+	fs := flag.NewFlagSet(subcomando, flag.ExitOnError)
+	nombre := fs.String("nombre", nombreServicio, "nombre del servicio de systemd")
+	raiz := fs.String("raiz", "/", "directorio raíz donde se escribe la unidad (para pruebas, un directorio temporal)")
+	return fs, nombre, raiz
+}
+
+// instalarServicio genera /etc/systemd/system/<nombre>.service con las rutas
+// absolutas de este ejecutable y lo habilita. Los argumentos después de "--"
+// se pasan al daemon en ExecStart (por ejemplo: -- -programador nativo).
+func instalarServicio(args []string) {
+	fs, nombre, raiz := flagsServicio("install")
+	watchdog := fs.Int("watchdog", 60, "segundos de WatchdogSec (0 para desactivar)")
+	fs.Parse(args)
+
+	ejecutable, err := os.Executable()
+	if err != nil {
+		log.Fatal("Error obteniendo ruta del ejecutable:", err)
+	}
+	if ejecutable, err = filepath.EvalSymlinks(ejecutable); err != nil {
+		log.Fatal("Error resolviendo ruta del ejecutable:", err)
+	}
+
+	unidad := systemd.Unidad{
+		Nombre:            *nombre,
+		Descripcion:       "Daemon en Go - lectura de /proc y registro en SQLite para Grafana",
+		Ejecutable:        ejecutable,
+		Args:              append([]string{"run"}, fs.Args()...),
+		DirectorioTrabajo: filepath.Dir(ejecutable),
+		WatchdogSeg:       *watchdog,
+	}
+
+	instalador := systemd.NuevoInstalador(*raiz)
+	if err := instalador.Instalar(unidad); err != nil {
+		log.Fatalf("Error instalando el servicio: %v", err)
+	}
+
+	log.Printf("Servicio instalado en %s", instalador.RutaUnidad(*nombre))
+	fmt.Print(unidad.Texto())
+}
+
+// desinstalarServicio detiene, deshabilita y elimina la unidad
+func desinstalarServicio(args []string) {
+	fs, nombre, raiz := flagsServicio("uninstall")
+	fs.Parse(args)
+
+	instalador := systemd.NuevoInstalador(*raiz)
+	if err := instalador.Desinstalar(*nombre); err != nil {
+		log.Fatalf("Error desinstalando el servicio: %v", err)
+	}
+	log.Printf("Servicio %s desinstalado", *nombre)
+}
+
+// estadoServicio muestra si la unidad existe, está habilitada y activa
+func estadoServicio(args []string) {
+	fs, nombre, raiz := flagsServicio("status")
+	fs.Parse(args)
+
+	estado := systemd.NuevoInstalador(*raiz).Consultar(*nombre)
+	fmt.Printf("Servicio:   %s\n", *nombre)
+	fmt.Printf("Unidad:     %s\n", estado.Ruta)
+	fmt.Printf("Instalado:  %t\n", estado.Instalado)
+	fmt.Printf("Habilitado: %s\n", estado.Habilitado)
+	fmt.Printf("Activo:     %s\n", estado.Activo)
+
+	if !estado.Instalado {
+		os.Exit(3)
+	}
+}
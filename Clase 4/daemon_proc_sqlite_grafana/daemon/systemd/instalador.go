@@ -0,0 +1,148 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Ejecutor corre systemctl; se puede reemplazar para probar sin systemd
+type Ejecutor func(args ...string) (string, error)
+
+// Systemctl ejecuta el comando systemctl real
+func Systemctl(args ...string) (string, error) {
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+// Instalador escribe y administra unidades bajo Raiz. Con Raiz distinta de "/"
+// (por ejemplo un directorio temporal) solo se modifican archivos y se usa
+// "systemctl --root", sin tocar el systemd que está corriendo.
+type Instalador struct {
+	Raiz      string
+	Systemctl Ejecutor
+}
+
+// NuevoInstalador crea un instalador sobre la raíz indicada ("" equivale a "/")
+func NuevoInstalador(raiz string) *Instalador {
+	if raiz == "" {
+		raiz = "/"
+	}
+	return &Instalador{Raiz: raiz, Systemctl: Systemctl}
+}
+
+// Estado resume la situación del servicio
+type Estado struct {
+	Instalado  bool
+	Habilitado string
+	Activo     string
+	Ruta       string
+}
+
+// RutaUnidad devuelve dónde queda el archivo .service
+func (i *Instalador) RutaUnidad(nombre string) string {
+	return filepath.Join(i.Raiz, "etc", "systemd", "system", nombre+".service")
+}
+
+func (i *Instalador) enVivo() bool {
+	return filepath.Clean(i.Raiz) == "/"
+}
+
+// systemctl agrega --root cuando no se trabaja sobre el sistema real
+func (i *Instalador) systemctl(args ...string) (string, error) {
+	args = i.argsConRaiz(args...)
+	output, err := i.Systemctl(args...)
+	if err != nil {
+		return output, fmt.Errorf("systemctl %s: %v: %s", strings.Join(args, " "), err, output)
+	}
+	return output, nil
+}
+
+// Instalar escribe la unidad, la habilita y (en el sistema real) la inicia
+func (i *Instalador) Instalar(u Unidad) error {
+	if !filepath.IsAbs(u.Ejecutable) || !filepath.IsAbs(u.DirectorioTrabajo) {
+		return fmt.Errorf("la unidad necesita rutas absolutas (ejecutable: %s, directorio: %s)", u.Ejecutable, u.DirectorioTrabajo)
+	}
+
+	ruta := i.RutaUnidad(u.Nombre)
+	if err := os.MkdirAll(filepath.Dir(ruta), 0755); err != nil {
+		return fmt.Errorf("error creando %s: %v", filepath.Dir(ruta), err)
+	}
+	if err := os.WriteFile(ruta, []byte(u.Texto()), 0644); err != nil {
+		return fmt.Errorf("error escribiendo %s: %v", ruta, err)
+	}
+
+	if i.enVivo() {
+		if _, err := i.systemctl("daemon-reload"); err != nil {
+			return err
+		}
+		_, err := i.systemctl("enable", "--now", u.Archivo())
+		return err
+	}
+	_, err := i.systemctl("enable", u.Archivo())
+	return err
+}
+
+// Desinstalar detiene y deshabilita el servicio y borra el archivo .service
+func (i *Instalador) Desinstalar(nombre string) error {
+	ruta := i.RutaUnidad(nombre)
+	if _, err := os.Stat(ruta); os.IsNotExist(err) {
+		return fmt.Errorf("el servicio %s no está instalado (%s no existe)", nombre, ruta)
+	}
+
+	args := []string{"disable", nombre + ".service"}
+	if i.enVivo() {
+		args = []string{"disable", "--now", nombre + ".service"}
+	}
+	if _, err := i.systemctl(args...); err != nil {
+		return err
+	}
+
+	if err := os.Remove(ruta); err != nil {
+		return fmt.Errorf("error eliminando %s: %v", ruta, err)
+	}
+
+	if i.enVivo() {
+		_, err := i.systemctl("daemon-reload")
+		return err
+	}
+	return nil
+}
+
+// Consultar devuelve si la unidad existe, si está habilitada y si está activa.
+// Fuera del sistema real el estado activo es siempre "desconocido".
+func (i *Instalador) Consultar(nombre string) Estado {
+	e := Estado{Ruta: i.RutaUnidad(nombre), Activo: "desconocido"}
+	if _, err := os.Stat(e.Ruta); err != nil {
+		e.Habilitado = "no instalado"
+		return e
+	}
+	e.Instalado = true
+
+	// is-enabled/is-active devuelven código distinto de 0 cuando la respuesta es "no",
+	// así que se usa la salida aunque haya error
+	habilitado, _ := i.Systemctl(i.argsConRaiz("is-enabled", nombre+".service")...)
+	e.Habilitado = primeraLinea(habilitado)
+	if i.enVivo() {
+		activo, _ := i.Systemctl("is-active", nombre+".service")
+		e.Activo = primeraLinea(activo)
+	}
+	return e
+}
+
+func (i *Instalador) argsConRaiz(args ...string) []string {
+	if i.enVivo() {
+		return args
+	}
+	return append([]string{"--root=" + i.Raiz}, args...)
+}
+
+func primeraLinea(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "desconocido"
+	}
+	return strings.SplitN(s, "\n", 2)[0]
+}
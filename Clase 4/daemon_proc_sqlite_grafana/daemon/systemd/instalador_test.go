@@ -0,0 +1,164 @@
+package systemd
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// systemctlFalso registra las llamadas y responde con respuestas fijas
+type systemctlFalso struct {
+	llamadas   []string
+	respuestas map[string]string
+	fallar     string
+}
+
+func (s *systemctlFalso) ejecutar(args ...string) (string, error) {
+	llamada := strings.Join(args, " ")
+	s.llamadas = append(s.llamadas, llamada)
+	if s.fallar != "" && strings.Contains(llamada, s.fallar) {
+		return "Failed", errors.New("exit status 1")
+	}
+	return s.respuestas[llamada], nil
+}
+
+func nuevoInstalador(t *testing.T) (*Instalador, *systemctlFalso) {
+	falso := &systemctlFalso{respuestas: map[string]string{}}
+	i := NuevoInstalador(t.TempDir())
+	i.Systemctl = falso.ejecutar
+	return i, falso
+}
+
+func unidadPrueba() Unidad {
+	return Unidad{
+		Nombre:            "sopes1-daemon",
+		Descripcion:       "Daemon de prueba",
+		Ejecutable:        "/home/alumno/Clase 4/daemon/daemon",
+		Args:              []string{"run", "-config", "/home/alumno/Clase 4/daemon/daemon.json"},
+		DirectorioTrabajo: "/home/alumno/Clase 4/daemon",
+		WatchdogSeg:       30,
+	}
+}
+
+func TestTextoUnidad(t *testing.T) {
+	texto := unidadPrueba().Texto()
+	for _, linea := range []string{
+		"Type=notify",
+		`ExecStart="/home/alumno/Clase 4/daemon/daemon" run -config "/home/alumno/Clase 4/daemon/daemon.json"`,
+		// systemd no quita comillas en WorkingDirectory
+		"WorkingDirectory=/home/alumno/Clase 4/daemon\n",
+		"WatchdogSec=30",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(texto, linea) {
+			t.Errorf("falta %q en la unidad:\n%s", linea, texto)
+		}
+	}
+}
+
+func TestTextoEscapaEspecificadores(t *testing.T) {
+	u := unidadPrueba()
+	u.DirectorioTrabajo = "/srv/100%"
+	u.Args = []string{"-x", "50%"}
+	texto := u.Texto()
+	if !strings.Contains(texto, "WorkingDirectory=/srv/100%%\n") || !strings.Contains(texto, " -x 50%%\n") {
+		t.Fatalf("los %% deben duplicarse:\n%s", texto)
+	}
+}
+
+func TestInstalar(t *testing.T) {
+	i, falso := nuevoInstalador(t)
+	u := unidadPrueba()
+
+	if err := i.Instalar(u); err != nil {
+		t.Fatal(err)
+	}
+	contenido, err := os.ReadFile(i.RutaUnidad(u.Nombre))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contenido) != u.Texto() {
+		t.Fatalf("contenido de la unidad:\n%s", contenido)
+	}
+
+	// Fuera del sistema real no se recarga ni se inicia nada
+	esperada := "--root=" + i.Raiz + " enable sopes1-daemon.service"
+	if len(falso.llamadas) != 1 || falso.llamadas[0] != esperada {
+		t.Fatalf("llamadas = %q, se esperaba %q", falso.llamadas, esperada)
+	}
+}
+
+func TestInstalarRutasRelativas(t *testing.T) {
+	i, falso := nuevoInstalador(t)
+	u := unidadPrueba()
+	u.Ejecutable = "./daemon"
+	if err := i.Instalar(u); err == nil {
+		t.Fatal("una ruta relativa debería fallar")
+	}
+	if _, err := os.Stat(i.RutaUnidad(u.Nombre)); !os.IsNotExist(err) {
+		t.Fatal("no se debería escribir la unidad")
+	}
+	if len(falso.llamadas) != 0 {
+		t.Fatalf("no se debería llamar a systemctl: %q", falso.llamadas)
+	}
+}
+
+func TestInstalarFallaSystemctl(t *testing.T) {
+	i, falso := nuevoInstalador(t)
+	falso.fallar = "enable"
+	err := i.Instalar(unidadPrueba())
+	if err == nil || !strings.Contains(err.Error(), "Failed") {
+		t.Fatalf("err = %v, se esperaba el error de systemctl con su salida", err)
+	}
+}
+
+func TestDesinstalar(t *testing.T) {
+	i, falso := nuevoInstalador(t)
+	u := unidadPrueba()
+
+	if err := i.Desinstalar(u.Nombre); err == nil {
+		t.Fatal("desinstalar un servicio que no existe debería fallar")
+	}
+
+	if err := i.Instalar(u); err != nil {
+		t.Fatal(err)
+	}
+	falso.llamadas = nil
+	if err := i.Desinstalar(u.Nombre); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(i.RutaUnidad(u.Nombre)); !os.IsNotExist(err) {
+		t.Fatal("la unidad debería borrarse")
+	}
+	esperada := "--root=" + i.Raiz + " disable sopes1-daemon.service"
+	if len(falso.llamadas) != 1 || falso.llamadas[0] != esperada {
+		t.Fatalf("llamadas = %q, se esperaba %q", falso.llamadas, esperada)
+	}
+}
+
+func TestConsultar(t *testing.T) {
+	i, falso := nuevoInstalador(t)
+	u := unidadPrueba()
+
+	if e := i.Consultar(u.Nombre); e.Instalado || e.Habilitado != "no instalado" {
+		t.Fatalf("estado sin instalar = %+v", e)
+	}
+
+	if err := i.Instalar(u); err != nil {
+		t.Fatal(err)
+	}
+	falso.respuestas["--root="+i.Raiz+" is-enabled sopes1-daemon.service"] = "enabled\n"
+	e := i.Consultar(u.Nombre)
+	if !e.Instalado || e.Habilitado != "enabled" || e.Activo != "desconocido" {
+		t.Fatalf("estado = %+v", e)
+	}
+}
+
+func TestEnVivo(t *testing.T) {
+	for raiz, esperado := range map[string]bool{"": true, "/": true, "//": true, "/tmp/raiz": false} {
+		if got := NuevoInstalador(raiz).enVivo(); got != esperado {
+			t.Errorf("raíz %q: enVivo = %v", raiz, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package systemd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// BajoJournald indica si la salida estándar está conectada a journald
+func BajoJournald() bool {
+	return os.Getenv("JOURNAL_STREAM") != ""
+}
+
+// NuevoLogger crea un logger estructurado (clave=valor). Bajo journald cada
+// línea lleva el prefijo de prioridad <N> que journald interpreta como nivel
+// y se omite la hora (journald ya la registra); fuera de journald se escribe
+// texto normal con la hora.
+func NuevoLogger(w io.Writer, nivel slog.Level) *slog.Logger {
+	if !BajoJournald() {
+		return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: nivel}))
+	}
+	return slog.New(&manejadorJournal{w: w, nivel: nivel, mu: &sync.Mutex{}})
+}
+
+// manejadorJournal usa un TextHandler sin hora y antepone la prioridad syslog
+type manejadorJournal struct {
+	w      io.Writer
+	nivel  slog.Level
+	attrs  []slog.Attr
+	grupos []string
+	mu     *sync.Mutex
+}
+
+func (m *manejadorJournal) Enabled(_ context.Context, nivel slog.Level) bool {
+	return nivel >= m.nivel
+}
+
+func (m *manejadorJournal) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	buf.WriteString(prioridad(r.Level))
+
+	var h slog.Handler = slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: m.nivel,
+		ReplaceAttr: func(grupos []string, a slog.Attr) slog.Attr {
+			if len(grupos) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	if len(m.attrs) > 0 {
+		h = h.WithAttrs(m.attrs)
+	}
+	for _, g := range m.grupos {
+		h = h.WithGroup(g)
+	}
+	if err := h.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.w.Write(buf.Bytes())
+	return err
+}
+
+func (m *manejadorJournal) WithAttrs(attrs []slog.Attr) slog.Handler {
+	n := *m
+	n.attrs = append(append([]slog.Attr(nil), m.attrs...), attrs...)
+	return &n
+}
+
+func (m *manejadorJournal) WithGroup(nombre string) slog.Handler {
+	n := *m
+	n.grupos = append(append([]string(nil), m.grupos...), nombre)
+	return &n
+}
+
+// prioridad traduce el nivel de slog a los prefijos de sd-daemon(3)
+func prioridad(nivel slog.Level) string {
+	switch {
+	case nivel >= slog.LevelError:
+		return "<3>"
+	case nivel >= slog.LevelWarn:
+		return "<4>"
+	case nivel >= slog.LevelInfo:
+		return "<6>"
+	default:
+		return "<7>"
+	}
+}
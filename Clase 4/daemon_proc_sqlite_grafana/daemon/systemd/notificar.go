@@ -0,0 +1,62 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notificar envía un mensaje sd_notify (READY=1, WATCHDOG=1, STOPPING=1, ...)
+// al socket de $NOTIFY_SOCKET. Si el daemon no corre bajo systemd devuelve
+// false sin error.
+func Notificar(estado string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// Los sockets abstractos de Linux se indican con '@' y empiezan con un byte nulo
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(estado)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Listo avisa que el daemon terminó de iniciar (Type=notify)
+func Listo() (bool, error) {
+	return Notificar("READY=1")
+}
+
+// Deteniendo avisa que el daemon empezó a apagarse
+func Deteniendo() (bool, error) {
+	return Notificar("STOPPING=1")
+}
+
+// Latido envía el ping del watchdog
+func Latido() (bool, error) {
+	return Notificar("WATCHDOG=1")
+}
+
+// IntervaloWatchdog devuelve cada cuánto hay que enviar Latido: la mitad de
+// WatchdogSec, como recomienda systemd. Devuelve 0 si el watchdog no está activo
+// para este proceso.
+func IntervaloWatchdog() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
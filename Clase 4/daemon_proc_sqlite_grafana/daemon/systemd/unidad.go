@@ -0,0 +1,72 @@
+// Package systemd instala el daemon como servicio de systemd y le permite
+// avisar a systemd que está listo (sd_notify) y que sigue vivo (watchdog).
+package systemd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unidad describe el archivo .service que se genera
+type Unidad struct {
+	Nombre            string
+	Descripcion       string
+	Ejecutable        string
+	Args              []string
+	DirectorioTrabajo string
+	// WatchdogSeg es el tiempo que systemd espera un WATCHDOG=1 antes de reiniciar el servicio
+	WatchdogSeg int
+}
+
+// Archivo devuelve el nombre del archivo de la unidad (nombre.service)
+func (u Unidad) Archivo() string {
+	return u.Nombre + ".service"
+}
+
+// Texto genera el contenido del archivo .service
+func (u Unidad) Texto() string {
+	comando := []string{citar(u.Ejecutable)}
+	for _, a := range u.Args {
+		comando = append(comando, citar(a))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[Unit]\n")
+	fmt.Fprintf(&sb, "Description=%s\n", u.Descripcion)
+	sb.WriteString("After=network.target docker.service\n")
+	sb.WriteString("\n[Service]\n")
+	// Type=notify: systemd considera iniciado el servicio cuando el daemon envía READY=1
+	sb.WriteString("Type=notify\n")
+	sb.WriteString("NotifyAccess=main\n")
+	fmt.Fprintf(&sb, "ExecStart=%s\n", strings.Join(comando, " "))
+	// systemctl reload envía SIGHUP, que recarga la configuración de muestreo
+	sb.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	// WorkingDirectory no acepta comillas: la ruta va tal cual aunque tenga espacios
+	fmt.Fprintf(&sb, "WorkingDirectory=%s\n", especificadores(u.DirectorioTrabajo))
+	sb.WriteString("Restart=always\n")
+	sb.WriteString("RestartSec=10\n")
+	if u.WatchdogSeg > 0 {
+		fmt.Fprintf(&sb, "WatchdogSec=%d\n", u.WatchdogSeg)
+	}
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=multi-user.target\n")
+	return sb.String()
+}
+
+// citar pone entre comillas los valores con espacios (como "Clase 4") para
+// la línea de comando de ExecStart
+func citar(s string) string {
+	s = especificadores(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// especificadores escapa los % para que systemd no los tome como
+// especificadores (%h, %n...)
+func especificadores(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}